@@ -1,92 +1,194 @@
 package gop2b
 
 import (
-	"bytes"
-	"encoding/json"
-	"errors"
+	"context"
 	"fmt"
 	"github.com/shopspring/decimal"
-	"io"
-	"net/http"
-	"strconv"
+	"sort"
 	"time"
 )
 
-type AccountBalancesResp struct {
-	Response
-	Result map[string]AccountBalance `json:"result"`
-}
+type AccountBalancesResp Envelope[map[Currency]AccountBalance]
 
 type AccountBalance struct {
 	Available decimal.Decimal `json:"available,string"`
 	Freeze    decimal.Decimal `json:"freeze,string"`
 }
 
+// Total returns available plus frozen funds
+func (b AccountBalance) Total() decimal.Decimal {
+	return b.Available.Add(b.Freeze)
+}
+
+// CurrencyBalance is a balance together with its currency
+type CurrencyBalance struct {
+	Currency Currency
+	AccountBalance
+}
+
+// BalanceOrder selects the order of AccountBalancesResp.Sorted
+type BalanceOrder int
+
+const (
+	// ByCurrency sorts alphabetically by currency
+	ByCurrency BalanceOrder = iota
+	// ByTotalDesc sorts by total balance, largest first, ties by currency
+	ByTotalDesc
+)
+
+// NonZero returns the balances whose total is greater than zero
+func (r *AccountBalancesResp) NonZero() map[Currency]AccountBalance {
+	result := make(map[Currency]AccountBalance)
+	for currency, balance := range r.Result {
+		if balance.Total().IsPositive() {
+			result[currency] = balance
+		}
+	}
+	return result
+}
+
+// Sorted returns all balances as a slice in the given order
+func (r *AccountBalancesResp) Sorted(order BalanceOrder) []CurrencyBalance {
+	result := make([]CurrencyBalance, 0, len(r.Result))
+	for currency, balance := range r.Result {
+		result = append(result, CurrencyBalance{Currency: currency, AccountBalance: balance})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if order == ByTotalDesc {
+			if cmp := result[i].Total().Cmp(result[j].Total()); cmp != 0 {
+				return cmp > 0
+			}
+		}
+		return result[i].Currency < result[j].Currency
+	})
+	return result
+}
+
 type AccountBalancesRequest struct {
 	Request
 }
 
-type AccountCurrencyBalanceResp struct {
-	Response
-	Result AccountBalance `json:"result"`
-}
+func (*AccountBalancesRequest) endpointPath() string { return "/account/balances" }
+
+// AccountCurrencyBalanceResp holds a zero Result for an unknown currency, ResultPresent
+// tells it apart from a zero balance
+type AccountCurrencyBalanceResp = Envelope[AccountBalance]
 
 type AccountCurrencyBalanceRequest struct {
 	Request
-	Currency string `json:"currency"`
+	Currency Currency `json:"currency"`
 }
 
-func (c *client) PostBalances(request *AccountBalancesRequest) (*AccountBalancesResp, error) {
-	url := fmt.Sprintf("%s/account/balances", c.url)
-	asJSON, err := json.Marshal(request)
-	if err != nil {
-		return nil, err
-	}
-	resp, err := c.sendPost(url, nil, bytes.NewReader(asJSON))
-	if err != nil {
-		return nil, err
-	}
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-	err = checkHTTPStatus(*resp, http.StatusOK)
-	if err != nil {
-		return nil, errors.New(fmt.Sprintf("%s: %s\n", err.Error(), string(bodyBytes)))
-	}
+func (*AccountCurrencyBalanceRequest) endpointPath() string { return "/account/balance" }
 
+func (c *client) PostBalances(request *AccountBalancesRequest) (*AccountBalancesResp, error) {
 	var result AccountBalancesResp
-	err = json.Unmarshal(bodyBytes, &result)
-	if err != nil {
+	if err := c.postEndpoint(context.Background(), request, &result); err != nil {
 		return nil, err
 	}
 	return &result, nil
 }
 
 func (c *client) PostCurrencyBalance(request *AccountCurrencyBalanceRequest) (*AccountCurrencyBalanceResp, error) {
-	url := fmt.Sprintf("%s/account/balance", c.url)
-	request.Request.Nonce = strconv.FormatInt(time.Now().UnixMilli(), 10)
-	request.Request.Request = "/api/v2/account/balance"
-	asJSON, err := json.Marshal(request)
-	if err != nil {
+	var result AccountCurrencyBalanceResp
+	if err := c.postEndpoint(context.Background(), request, &result); err != nil {
 		return nil, err
 	}
-	resp, err := c.sendPost(url, nil, bytes.NewReader(asJSON))
+	return &result, nil
+}
+
+// marketBalances fetches the balances of the stock and money currencies of market. It uses a
+// single request for all balances, as concurrent signed requests may reach the exchange out of
+// nonce order.
+func (c *client) marketBalances(ctx context.Context, market string) (map[Currency]AccountBalance, error) {
+	market, err := c.ResolveMarket(ctx, market)
 	if err != nil {
 		return nil, err
 	}
-	bodyBytes, err := io.ReadAll(resp.Body)
+	markets, err := c.cachedMarkets(ctx)
 	if err != nil {
 		return nil, err
 	}
-	err = checkHTTPStatus(*resp, http.StatusOK)
-	if err != nil {
-		return nil, errors.New(fmt.Sprintf("%s: %s\n", err.Error(), string(bodyBytes)))
+	info, ok := markets[Market(market)]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownMarket, market)
 	}
-	var result AccountCurrencyBalanceResp
-	err = json.Unmarshal(bodyBytes, &result)
-	if err != nil {
+	var resp AccountBalancesResp
+	if err := c.postEndpoint(ctx, &AccountBalancesRequest{}, &resp); err != nil {
 		return nil, err
 	}
-	return &result, nil
+	if err := resp.Err(); err != nil {
+		return nil, err
+	}
+	result := make(map[Currency]AccountBalance, 2)
+	for _, currency := range []Currency{info.Stock, info.Money} {
+		result[currency] = resp.Result[currency]
+	}
+	return result, nil
+}
+
+// BalanceSnapshot is a frozen copy of the account balances
+type BalanceSnapshot struct {
+	At       time.Time
+	Balances map[Currency]AccountBalance
+}
+
+// Snapshot copies the balances, timestamped with the server time of the response or now when missing
+func (r *AccountBalancesResp) Snapshot() BalanceSnapshot {
+	at := time.Now()
+	if r.CurrentTime > 0 {
+		at = TimestampToTime(r.CurrentTime)
+	}
+	balances := make(map[Currency]AccountBalance, len(r.Result))
+	for currency, balance := range r.Result {
+		balances[currency] = balance
+	}
+	return BalanceSnapshot{At: at, Balances: balances}
+}
+
+// BalanceDelta is the change of a currency balance between two snapshots
+type BalanceDelta struct {
+	Currency Currency
+	Before   AccountBalance
+	After    AccountBalance
+	// Available and Freeze are after minus before
+	Available decimal.Decimal
+	Freeze    decimal.Decimal
+	// Added and Removed are set when the currency is only present in after or before
+	Added   bool
+	Removed bool
+}
+
+// DiffBalances returns the per currency changes from before to after, sorted by currency.
+// A currency missing on one side counts as a zero balance there, currencies whose
+// available and frozen amounts are both unchanged are left out.
+func DiffBalances(before, after map[Currency]AccountBalance) []BalanceDelta {
+	var deltas []BalanceDelta
+	diff := func(currency Currency) {
+		b, inBefore := before[currency]
+		a, inAfter := after[currency]
+		delta := BalanceDelta{
+			Currency:  currency,
+			Before:    b,
+			After:     a,
+			Available: a.Available.Sub(b.Available),
+			Freeze:    a.Freeze.Sub(b.Freeze),
+			Added:     !inBefore,
+			Removed:   !inAfter,
+		}
+		if delta.Available.IsZero() && delta.Freeze.IsZero() {
+			return
+		}
+		deltas = append(deltas, delta)
+	}
+	for currency := range before {
+		diff(currency)
+	}
+	for currency := range after {
+		if _, ok := before[currency]; !ok {
+			diff(currency)
+		}
+	}
+	sort.Slice(deltas, func(i, j int) bool { return deltas[i].Currency < deltas[j].Currency })
+	return deltas
 }
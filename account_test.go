@@ -0,0 +1,123 @@
+package gop2b_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/sutapurachina/gop2b"
+)
+
+// balancesResp decodes the result of an account/balances response
+func balancesResp(t *testing.T, result string) *gop2b.AccountBalancesResp {
+	t.Helper()
+	var resp gop2b.AccountBalancesResp
+	if err := json.Unmarshal([]byte(`{"success":true,"message":"","result":`+result+`}`), &resp); err != nil {
+		t.Fatal(err)
+	}
+	return &resp
+}
+
+func TestAccountBalancesNonZero(t *testing.T) {
+	tests := []struct {
+		name   string
+		result string
+		want   []gop2b.Currency
+	}{
+		{"empty", `{}`, nil},
+		{"zero", `{"BTC":{"available":"0","freeze":"0"}}`, nil},
+		{"zero with scale", `{"BTC":{"available":"0.00000000","freeze":"0.00000000"}}`, nil},
+		{"zero in scientific notation", `{"BTC":{"available":"0e-8","freeze":"0E+2"}}`, nil},
+		{"available only", `{"BTC":{"available":"0.5","freeze":"0"}}`, []gop2b.Currency{"BTC"}},
+		{"frozen only", `{"BTC":{"available":"0","freeze":"0.5"}}`, []gop2b.Currency{"BTC"}},
+		{"dust", `{"BTC":{"available":"0.00000001","freeze":"0"}}`, []gop2b.Currency{"BTC"}},
+		{"dust in scientific notation", `{"BTC":{"available":"0","freeze":"1e-8"}}`, []gop2b.Currency{"BTC"}},
+		{"mixed", `{"BTC":{"available":"1","freeze":"0"},"ETH":{"available":"0","freeze":"0"},"USDT":{"available":"0","freeze":"2"}}`,
+			[]gop2b.Currency{"BTC", "USDT"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := balancesResp(t, tt.result)
+			got := resp.NonZero()
+			if len(got) != len(tt.want) {
+				t.Fatalf("non-zero balances %v, want %v", got, tt.want)
+			}
+			for _, currency := range tt.want {
+				balance, ok := got[currency]
+				if !ok {
+					t.Fatalf("%s missing from %v", currency, got)
+				}
+				if balance != resp.Result[currency] {
+					t.Errorf("%s balance %+v, want %+v", currency, balance, resp.Result[currency])
+				}
+			}
+		})
+	}
+}
+
+func TestAccountBalancesSorted(t *testing.T) {
+	// totals: BTC 3, ETH 0, LTC 3 with everything frozen, USDT 10, XRP 0.5, DOGE 10 split over both
+	const result = `{
+		"XRP":{"available":"0.5","freeze":"0"},
+		"USDT":{"available":"10","freeze":"0"},
+		"ETH":{"available":"0","freeze":"0"},
+		"LTC":{"available":"0","freeze":"3"},
+		"DOGE":{"available":"4","freeze":"6"},
+		"BTC":{"available":"1","freeze":"2"}}`
+	tests := []struct {
+		name  string
+		order gop2b.BalanceOrder
+		want  []gop2b.Currency
+	}{
+		{"by currency", gop2b.ByCurrency, []gop2b.Currency{"BTC", "DOGE", "ETH", "LTC", "USDT", "XRP"}},
+		// equal totals are ordered by currency
+		{"by total", gop2b.ByTotalDesc, []gop2b.Currency{"DOGE", "USDT", "BTC", "LTC", "XRP", "ETH"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := balancesResp(t, result)
+			// map iteration order differs between runs, sort a few times
+			for i := 0; i < 10; i++ {
+				var got []gop2b.Currency
+				for _, b := range resp.Sorted(tt.order) {
+					got = append(got, b.Currency)
+					if b.AccountBalance != resp.Result[b.Currency] {
+						t.Errorf("%s balance %+v, want %+v", b.Currency, b.AccountBalance, resp.Result[b.Currency])
+					}
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Fatalf("order %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestCurrencyBalanceResultPresent(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		present bool
+		total   string
+	}{
+		{"balance", `{"success":true,"message":"","result":{"available":"0.5","freeze":"0"}}`, true, "0.5"},
+		{"zero balance", `{"success":true,"message":"","result":{"available":"0","freeze":"0"}}`, true, "0"},
+		{"empty object", `{"success":true,"message":"","result":{}}`, true, "0"},
+		{"null", `{"success":true,"message":"","result":null}`, false, "0"},
+		{"missing", `{"success":true,"message":""}`, false, "0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := newTestClient(t)
+			server.SetResponse("/account/balance", tt.body)
+			resp, err := client.PostCurrencyBalance(&gop2b.AccountCurrencyBalanceRequest{Currency: "BTC"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.ResultPresent() != tt.present {
+				t.Errorf("result present %v, want %v", resp.ResultPresent(), tt.present)
+			}
+			checkDecimal(t, "total", resp.Result.Total(), tt.total)
+		})
+	}
+}
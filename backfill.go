@@ -0,0 +1,126 @@
+package gop2b
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// klinePageLimit is the maximum amount of candles the kline endpoint returns per call
+const klinePageLimit = 100
+
+// KlineGap is a period [From, To) without any candle
+type KlineGap struct {
+	From time.Time
+	To   time.Time
+}
+
+// KlineBackfill is the result of BackfillKlines
+type KlineBackfill struct {
+	// Klines are sorted by time without duplicates
+	Klines []Kline
+	// Gaps lists the periods within the requested range the exchange returned no candles for
+	Gaps []KlineGap
+}
+
+// BackfillKlines fetches all candles of market opened in [from, to), paging through the
+// kline endpoint as far back as needed. Candles repeated across page boundaries are
+// deduplicated and missing periods are reported as gaps. Requests go through the client rate limiter.
+func (c *client) BackfillKlines(ctx context.Context, market string, interval KlineInterval, from, to time.Time) (*KlineBackfill, error) {
+	step := interval.Duration()
+	if step == 0 {
+		return nil, fmt.Errorf("unknown kline interval %q", interval)
+	}
+	if !from.Before(to) {
+		return nil, errors.New("from must be before to")
+	}
+
+	// skip the pages newer than to, leaving a page of margin for the clock estimate
+	offset := int(time.Since(to)/step) - klinePageLimit
+	if offset < 0 {
+		offset = 0
+	}
+	seen := make(map[int64]Kline)
+	var oldest time.Time
+	for {
+		page, err := c.GetKlines(ctx, market, interval, offset, klinePageLimit)
+		if err != nil {
+			return nil, err
+		}
+		if !page.Success {
+			return nil, page.Err()
+		}
+		if len(page.Result) == 0 {
+			break
+		}
+		pageOldest, pageNewest := page.Result[0].Time, page.Result[0].Time
+		for _, k := range page.Result {
+			if k.Time.Before(pageOldest) {
+				pageOldest = k.Time
+			}
+			if k.Time.After(pageNewest) {
+				pageNewest = k.Time
+			}
+			if !k.Time.Before(from) && k.Time.Before(to) {
+				seen[k.Time.Unix()] = k
+			}
+		}
+		// the estimate overshot, step back towards the present
+		if len(seen) == 0 && oldest.IsZero() && pageNewest.Before(to.Add(-step)) && offset > 0 {
+			offset -= klinePageLimit
+			if offset < 0 {
+				offset = 0
+			}
+			continue
+		}
+		if !pageOldest.After(from) || !oldest.IsZero() && !pageOldest.Before(oldest) {
+			break
+		}
+		oldest = pageOldest
+		offset += len(page.Result)
+	}
+
+	result := &KlineBackfill{Klines: make([]Kline, 0, len(seen))}
+	for _, k := range seen {
+		result.Klines = append(result.Klines, k)
+	}
+	sort.Slice(result.Klines, func(i, j int) bool { return result.Klines[i].Time.Before(result.Klines[j].Time) })
+	result.Gaps = klineGaps(result.Klines, step, from, to)
+	return result, nil
+}
+
+// klineGaps returns the periods of [from, to) with no candle, candles being sorted
+func klineGaps(klines []Kline, step time.Duration, from, to time.Time) []KlineGap {
+	var gaps []KlineGap
+	slot := alignToEpoch(from, step)
+	if slot.Before(from) {
+		slot = slot.Add(step)
+	}
+	i := 0
+	for ; slot.Before(to); slot = slot.Add(step) {
+		for i < len(klines) && klines[i].Time.Before(slot) {
+			i++
+		}
+		if i < len(klines) && klines[i].Time.Equal(slot) {
+			continue
+		}
+		if n := len(gaps); n > 0 && gaps[n-1].To.Equal(slot) {
+			gaps[n-1].To = slot.Add(step)
+			continue
+		}
+		gaps = append(gaps, KlineGap{From: slot, To: slot.Add(step)})
+	}
+	return gaps
+}
+
+// GetKlineRange returns the candles of market opened in [start, end), sorted and
+// deduplicated. It is BackfillKlines without the gap report.
+func (c *client) GetKlineRange(ctx context.Context, market string, interval KlineInterval, start, end time.Time) ([]Kline, error) {
+	result, err := c.BackfillKlines(ctx, market, interval, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return result.Klines, nil
+}
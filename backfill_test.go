@@ -0,0 +1,158 @@
+package gop2b_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sutapurachina/gop2b"
+)
+
+// klineServer serves 1m candles newest first by offset like the kline endpoint
+type klineServer struct {
+	mu    sync.Mutex
+	times []time.Time
+	// growing adds a newer candle after every request, shifting the offsets of the next page
+	growing bool
+	// message fails every request with success false when set
+	message string
+}
+
+// newKlineServer returns a server with n consecutive 1m candles up to the minute now,
+// leaving out the ones at missing
+func newKlineServer(t *testing.T, now time.Time, n int, missing ...time.Time) (*klineServer, gop2b.Client) {
+	t.Helper()
+	s := &klineServer{}
+	skip := make(map[int64]bool)
+	for _, m := range missing {
+		skip[m.Unix()] = true
+	}
+	for i := 0; i < n; i++ {
+		if at := now.Add(-time.Duration(i) * time.Minute); !skip[at.Unix()] {
+			s.times = append(s.times, at)
+		}
+	}
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+	client, err := gop2b.NewClient("", "", gop2b.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, client
+}
+
+func (s *klineServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	if s.message != "" {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"success": false, "message": s.message, "result": []interface{}{}})
+		return
+	}
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	result := []interface{}{}
+	for i := offset; i < offset+limit && i < len(s.times); i++ {
+		result = append(result, []interface{}{s.times[i].Unix(), "1", "1", "1", "1", "1", "1", "ETH_BTC"})
+	}
+	if s.growing {
+		s.times = append([]time.Time{s.times[0].Add(time.Minute)}, s.times...)
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "message": "", "result": result})
+}
+
+// checkBackfill checks the candles are the minutes of [from, to) except the ones in gaps, in order and once each
+func checkBackfill(t *testing.T, got *gop2b.KlineBackfill, from, to time.Time, gaps []gop2b.KlineGap) {
+	t.Helper()
+	var want []time.Time
+	for at := from.Truncate(time.Minute); at.Before(to); at = at.Add(time.Minute) {
+		if at.Before(from) {
+			continue
+		}
+		missing := false
+		for _, g := range gaps {
+			missing = missing || !at.Before(g.From) && at.Before(g.To)
+		}
+		if !missing {
+			want = append(want, at)
+		}
+	}
+	var times []time.Time
+	for _, k := range got.Klines {
+		times = append(times, k.Time)
+	}
+	if len(times) != len(want) {
+		t.Fatalf("%d candles, want %d", len(times), len(want))
+	}
+	for i := range want {
+		if !times[i].Equal(want[i]) {
+			t.Fatalf("candle %d at %s, want %s", i, times[i].UTC(), want[i].UTC())
+		}
+	}
+	if !reflect.DeepEqual(got.Gaps, gaps) {
+		t.Errorf("gaps %v, want %v", got.Gaps, gaps)
+	}
+}
+
+func TestBackfillKlinesGaps(t *testing.T) {
+	now := time.Now().Truncate(time.Minute)
+	from, to := now.Add(-400*time.Minute), now.Add(-100*time.Minute)
+	// a single missing candle, three in a row across a page boundary and the first of the range
+	missing := []time.Time{from, now.Add(-150 * time.Minute), now.Add(-201 * time.Minute), now.Add(-200 * time.Minute), now.Add(-199 * time.Minute)}
+	_, client := newKlineServer(t, now, 500, missing...)
+	got, err := client.BackfillKlines(context.Background(), "ETH_BTC", gop2b.Interval1m, from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkBackfill(t, got, from, to, []gop2b.KlineGap{
+		{From: from, To: from.Add(time.Minute)},
+		{From: now.Add(-201 * time.Minute), To: now.Add(-198 * time.Minute)},
+		{From: now.Add(-150 * time.Minute), To: now.Add(-149 * time.Minute)},
+	})
+}
+
+func TestBackfillKlinesOverlappingPages(t *testing.T) {
+	now := time.Now().Truncate(time.Minute)
+	from, to := now.Add(-400*time.Minute), now.Add(-100*time.Minute)
+	s, client := newKlineServer(t, now, 500)
+	// every page repeats the last candle of the previous one
+	s.growing = true
+	got, err := client.BackfillKlines(context.Background(), "ETH_BTC", gop2b.Interval1m, from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkBackfill(t, got, from, to, nil)
+}
+
+func TestBackfillKlinesUnalignedRange(t *testing.T) {
+	now := time.Now().Truncate(time.Minute)
+	// the candle opened at to minus 30s is in the range, the one opened at from minus 30s isn't
+	from, to := now.Add(-300*time.Minute+30*time.Second), now.Add(-100*time.Minute-30*time.Second)
+	_, client := newKlineServer(t, now, 500)
+	got, err := client.BackfillKlines(context.Background(), "ETH_BTC", gop2b.Interval1m, from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkBackfill(t, got, from, to, nil)
+	if first, last := got.Klines[0].Time, got.Klines[len(got.Klines)-1].Time; !first.Equal(now.Add(-299*time.Minute)) || !last.Equal(now.Add(-101*time.Minute)) {
+		t.Errorf("candles from %s to %s", first.UTC(), last.UTC())
+	}
+}
+
+func TestBackfillKlinesPageError(t *testing.T) {
+	now := time.Now().Truncate(time.Minute)
+	s, client := newKlineServer(t, now, 500)
+	s.message = "Market is not available"
+	_, err := client.BackfillKlines(context.Background(), "ETH_BTC", gop2b.Interval1m, now.Add(-time.Hour), now)
+	var apiErr *gop2b.APIError
+	if !errors.As(err, &apiErr) || apiErr.Message != "Market is not available" {
+		t.Errorf("error %#v, want the *APIError of the page", err)
+	}
+}
@@ -0,0 +1,29 @@
+package gop2b
+
+import (
+	"errors"
+	"time"
+)
+
+// banErr returns an *IPBannedError while a ban reported with a duration lasts, so the
+// client stops sending requests instead of extending the ban
+func (c *client) banErr() error {
+	until := c.bannedUntil.Load()
+	if until == 0 {
+		return nil
+	}
+	if remaining := time.Until(time.Unix(0, until)); remaining > 0 {
+		return &IPBannedError{RetryAfter: remaining}
+	}
+	return nil
+}
+
+// checkResponse is checkResponse recording the end of an IP ban
+func (c *client) checkResponse(resp *response, body []byte) error {
+	err := checkResponse(resp, body)
+	var ban *IPBannedError
+	if errors.As(err, &ban) && ban.RetryAfter > 0 {
+		c.bannedUntil.Store(time.Now().Add(ban.RetryAfter).UnixNano())
+	}
+	return err
+}
@@ -0,0 +1,223 @@
+package gop2b
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// OrderBook is a local order book kept up to date from depth updates. It is safe for concurrent use.
+type OrderBook struct {
+	mu     sync.RWMutex
+	market Market
+	asks   []PriceLevel
+	bids   []PriceLevel
+	at     time.Time
+	seq    int64
+}
+
+// NewOrderBook creates an empty book of market
+func NewOrderBook(market string) *OrderBook {
+	return &OrderBook{market: Market(market)}
+}
+
+// Reset replaces the whole book with snapshot
+func (b *OrderBook) Reset(snapshot DepthSnapshot) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.asks, b.bids = nil, nil
+	b.asks = mergeLevels(b.asks, snapshot.Asks, false)
+	b.bids = mergeLevels(b.bids, snapshot.Bids, true)
+	b.at = snapshot.At
+	b.seq = 0
+}
+
+// Apply applies a depth update, replacing the book when it is a full snapshot.
+// Levels with a zero amount are removed. A diff whose Sequence doesn't follow the one of the
+// previous update is not applied and returns ErrSequenceGap, the book needs a new snapshot then.
+func (b *OrderBook) Apply(update DepthUpdate) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !update.Full && sequenceGap(b.seq, update.Sequence) {
+		return fmt.Errorf("%w: %s expected %d, got %d", ErrSequenceGap, b.market, b.seq+1, update.Sequence)
+	}
+	if update.Full {
+		b.asks, b.bids = nil, nil
+	}
+	b.asks = mergeLevels(b.asks, update.Asks, false)
+	b.bids = mergeLevels(b.bids, update.Bids, true)
+	b.at = update.At
+	b.seq = update.Sequence
+	return nil
+}
+
+// Sequence returns the Sequence of the last update applied, zero after Reset
+func (b *OrderBook) Sequence() int64 {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.seq
+}
+
+// Best returns the best bid and ask, ok is false when either side is empty
+func (b *OrderBook) Best() (bid, ask PriceLevel, ok bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if len(b.bids) == 0 || len(b.asks) == 0 {
+		return PriceLevel{}, PriceLevel{}, false
+	}
+	return b.bids[0], b.asks[0], true
+}
+
+// Snapshot copies the best depth levels of each side, all of them when depth <= 0
+func (b *OrderBook) Snapshot(depth int) DepthSnapshot {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	levels := func(side []PriceLevel) []PriceLevel {
+		if depth > 0 && len(side) > depth {
+			side = side[:depth]
+		}
+		return append([]PriceLevel(nil), side...)
+	}
+	return DepthSnapshot{Market: b.market, At: b.at, Asks: levels(b.asks), Bids: levels(b.bids)}
+}
+
+// Render formats the best depth levels of each side for a terminal, all of them when depth <= 0.
+// Asks are stacked above bids with the best prices next to the spread line, Total is the
+// cumulative amount from the best price. Every column is aligned on the decimal point.
+func (b *OrderBook) Render(depth int) string {
+	snapshot := b.Snapshot(depth)
+	rows := make([][4]string, 0, len(snapshot.Asks)+len(snapshot.Bids)+1)
+	var prices, amounts, totals []decimal.Decimal
+	side := func(levels []PriceLevel) {
+		total := decimal.Zero
+		for _, l := range levels {
+			total = total.Add(l.Amount)
+			prices = append(prices, l.Price)
+			amounts = append(amounts, l.Amount)
+			totals = append(totals, total)
+		}
+	}
+	side(snapshot.Asks)
+	side(snapshot.Bids)
+	p, a, t := alignDecimals(prices), alignDecimals(amounts), alignDecimals(totals)
+
+	rows = append(rows, [4]string{"", "PRICE", "AMOUNT", "TOTAL"})
+	for i := len(snapshot.Asks) - 1; i >= 0; i-- {
+		rows = append(rows, [4]string{"ask", p[i], a[i], t[i]})
+	}
+	spread := len(rows)
+	rows = append(rows, [4]string{})
+	for i := len(snapshot.Asks); i < len(prices); i++ {
+		rows = append(rows, [4]string{"bid", p[i], a[i], t[i]})
+	}
+
+	var widths [4]int
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], len(cell))
+		}
+	}
+	var sb strings.Builder
+	if snapshot.Market != "" {
+		sb.WriteString(snapshot.Market.String() + "\n")
+	}
+	for i, row := range rows {
+		if i == spread {
+			label := "spread"
+			if len(snapshot.Asks) > 0 && len(snapshot.Bids) > 0 {
+				label += " " + snapshot.Asks[0].Price.Sub(snapshot.Bids[0].Price).String()
+			}
+			sb.WriteString("--- " + label + " ---\n")
+			continue
+		}
+		line := fmt.Sprintf("%-*s  %*s  %*s  %*s", widths[0], row[0], widths[1], row[1], widths[2], row[2], widths[3], row[3])
+		sb.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+	return sb.String()
+}
+
+// alignDecimals formats values in fixed notation padded to a common width with the decimal points aligned
+func alignDecimals(values []decimal.Decimal) []string {
+	ints := make([]string, len(values))
+	fracs := make([]string, len(values))
+	var intWidth, fracWidth int
+	for i, v := range values {
+		ints[i], fracs[i], _ = strings.Cut(v.String(), ".")
+		intWidth = max(intWidth, len(ints[i]))
+		fracWidth = max(fracWidth, len(fracs[i]))
+	}
+	result := make([]string, len(values))
+	for i := range values {
+		s := fmt.Sprintf("%*s", intWidth, ints[i])
+		switch {
+		case fracs[i] != "":
+			s += "." + fracs[i] + strings.Repeat(" ", fracWidth-len(fracs[i]))
+		case fracWidth > 0:
+			s += strings.Repeat(" ", fracWidth+1)
+		}
+		result[i] = s
+	}
+	return result
+}
+
+// mergeLevels applies changes to a side sorted best first, descending for bids
+func mergeLevels(side []PriceLevel, changes []PriceLevel, descending bool) []PriceLevel {
+	for _, change := range changes {
+		i := sort.Search(len(side), func(i int) bool {
+			if descending {
+				return side[i].Price.LessThanOrEqual(change.Price)
+			}
+			return side[i].Price.GreaterThanOrEqual(change.Price)
+		})
+		exists := i < len(side) && side[i].Price.Equal(change.Price)
+		switch {
+		case change.Amount.Sign() <= 0 && exists:
+			side = append(side[:i], side[i+1:]...)
+		case change.Amount.Sign() <= 0:
+		case exists:
+			side[i].Amount = change.Amount
+		default:
+			side = append(side, PriceLevel{})
+			copy(side[i+1:], side[i:])
+			side[i] = change
+		}
+	}
+	return side
+}
+
+// DepthUpdate is a notification of the depth channel
+type DepthUpdate struct {
+	Market Market
+	// Full is set when the update is a whole snapshot replacing the book
+	Full bool
+	Asks []PriceLevel
+	Bids []PriceLevel
+	// At is the time the update was received
+	At time.Time
+	// Reconnected is set on the first update after the connection was re-established
+	Reconnected bool
+	// Sequence is the update id sent by the exchange, each diff following the previous
+	// update by one. It is zero when the exchange doesn't send one.
+	Sequence int64
+}
+
+// Snapshot returns the levels of the update as a DepthSnapshot, the whole book for a Full update
+func (u DepthUpdate) Snapshot() DepthSnapshot {
+	return DepthSnapshot{Market: u.Market, At: u.At, Asks: u.Asks, Bids: u.Bids}
+}
+
+// DepthGap is a break in the depth update sequence of Market, Got arriving instead of Expected
+type DepthGap struct {
+	Market   Market
+	Expected int64
+	Got      int64
+}
+
+// sequenceGap reports whether next doesn't follow last, sequences being unknown when zero
+func sequenceGap(last, next int64) bool {
+	return last != 0 && next != 0 && next != last+1
+}
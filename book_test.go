@@ -0,0 +1,70 @@
+package gop2b_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/sutapurachina/gop2b"
+)
+
+func level(price, amount string) gop2b.PriceLevel {
+	return gop2b.PriceLevel{Price: decimal.RequireFromString(price), Amount: decimal.RequireFromString(amount)}
+}
+
+func TestOrderBookApplySequence(t *testing.T) {
+	book := gop2b.NewOrderBook("ETH_BTC")
+	snapshot := gop2b.DepthUpdate{
+		Full:     true,
+		Asks:     []gop2b.PriceLevel{level("0.0551", "2"), level("0.0552", "4")},
+		Bids:     []gop2b.PriceLevel{level("0.0549", "1")},
+		Sequence: 10,
+	}
+	if err := book.Apply(snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if err := book.Apply(gop2b.DepthUpdate{Asks: []gop2b.PriceLevel{level("0.0551", "0")}, Sequence: 11}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 12 is missing, and 11 is a replay
+	for _, seq := range []int64{13, 11} {
+		err := book.Apply(gop2b.DepthUpdate{Bids: []gop2b.PriceLevel{level("0.055", "5")}, Sequence: seq})
+		if !errors.Is(err, gop2b.ErrSequenceGap) {
+			t.Errorf("update %d: error %v, want ErrSequenceGap", seq, err)
+		}
+	}
+	if seq := book.Sequence(); seq != 11 {
+		t.Errorf("sequence %d, want 11", seq)
+	}
+	bid, ask, ok := book.Best()
+	if !ok || !bid.Price.Equal(decimal.RequireFromString("0.0549")) || !ask.Price.Equal(decimal.RequireFromString("0.0552")) {
+		t.Errorf("best %v / %v, the gapped updates were applied", bid, ask)
+	}
+
+	// a snapshot resets the sequence whatever it is
+	snapshot.Sequence = 20
+	if err := book.Apply(snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if err := book.Apply(gop2b.DepthUpdate{Sequence: 21}); err != nil {
+		t.Errorf("update after the new snapshot: %v", err)
+	}
+}
+
+func TestOrderBookApplyWithoutSequence(t *testing.T) {
+	book := gop2b.NewOrderBook("ETH_BTC")
+	for _, update := range []gop2b.DepthUpdate{
+		{Full: true, Asks: []gop2b.PriceLevel{level("1", "1")}},
+		{Asks: []gop2b.PriceLevel{level("2", "1")}},
+		{Bids: []gop2b.PriceLevel{level("0.5", "1")}},
+	} {
+		if err := book.Apply(update); err != nil {
+			t.Fatal(err)
+		}
+	}
+	snapshot := book.Snapshot(0)
+	if len(snapshot.Asks) != 2 || len(snapshot.Bids) != 1 {
+		t.Errorf("book %+v", snapshot)
+	}
+}
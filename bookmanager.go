@@ -0,0 +1,108 @@
+package gop2b
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/shopspring/decimal"
+)
+
+// BookManager keeps the live order books of a set of markets from the depth channel of a
+// single websocket, subscribed to all of them with WSClient.SubscribeDepthMarkets.
+type BookManager struct {
+	books map[string]*OrderBook
+	conn  *WSClient
+	wg    sync.WaitGroup
+}
+
+// NewBookManager connects a websocket from ws and subscribes to the depth of markets with limit
+// levels per side. The updates are routed to the book of their market, which is reset by its
+// snapshots, after a reconnect or a sequence gap, and kept up to date by the diffs in between.
+// The books stay empty until their first snapshot. Everything is closed once ctx is done or
+// Close is called.
+func NewBookManager(ctx context.Context, ws WsProvider, limit int, markets ...string) (*BookManager, error) {
+	if len(markets) == 0 {
+		return nil, errors.New("no markets to subscribe")
+	}
+	m := &BookManager{books: make(map[string]*OrderBook, len(markets))}
+	for _, market := range markets {
+		if _, ok := m.books[market]; !ok {
+			m.books[market] = NewOrderBook(market)
+		}
+	}
+	m.conn = ws.NewWS()
+	if err := m.conn.Connect(ctx); err != nil {
+		_ = m.conn.Close()
+		return nil, err
+	}
+	updates, err := m.conn.SubscribeDepthMarkets(ctx, limit, "0", m.Markets()...)
+	if err != nil {
+		_ = m.conn.Close()
+		return nil, fmt.Errorf("subscribe depth: %w", err)
+	}
+	m.wg.Add(1)
+	go m.run(ctx, updates)
+	return m, nil
+}
+
+// run applies the updates to the books of their markets until the subscription ends
+func (m *BookManager) run(ctx context.Context, updates <-chan DepthUpdate) {
+	defer m.wg.Done()
+	for {
+		select {
+		case <-ctx.Done():
+			_ = m.conn.Close()
+			return
+		case update, ok := <-updates:
+			if !ok {
+				return
+			}
+			book := m.books[string(update.Market)]
+			if book == nil {
+				continue
+			}
+			if err := book.Apply(update); err != nil {
+				// the stream filters gaps out already, ask for snapshots all the same
+				m.conn.resync(ChannelDepth)
+			}
+		}
+	}
+}
+
+// Book returns the live book of market, nil for a market the manager doesn't subscribe to
+func (m *BookManager) Book(market string) *OrderBook {
+	return m.books[market]
+}
+
+// BestQuotes returns the best bid and ask prices of market, zero while either side is empty
+func (m *BookManager) BestQuotes(market string) (bid, ask decimal.Decimal) {
+	book := m.books[market]
+	if book == nil {
+		return decimal.Zero, decimal.Zero
+	}
+	bestBid, bestAsk, ok := book.Best()
+	if !ok {
+		return decimal.Zero, decimal.Zero
+	}
+	return bestBid.Price, bestAsk.Price
+}
+
+// Markets returns the subscribed markets, sorted
+func (m *BookManager) Markets() []string {
+	markets := make([]string, 0, len(m.books))
+	for market := range m.books {
+		markets = append(markets, market)
+	}
+	sort.Strings(markets)
+	return markets
+}
+
+// Close closes the websocket and waits for the books to stop updating
+func (m *BookManager) Close() error {
+	err := m.conn.Close()
+	m.wg.Wait()
+	return err
+}
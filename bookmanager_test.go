@@ -0,0 +1,131 @@
+package gop2b_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/sutapurachina/gop2b"
+	"github.com/sutapurachina/gop2b/gop2btest"
+)
+
+// wsServer is a WsProvider connecting new websockets to the server
+type wsServer struct {
+	*gop2btest.WsServer
+}
+
+func (p wsServer) NewWS(opts ...gop2b.WSOption) *gop2b.WSClient {
+	return gop2b.NewWSClient(append([]gop2b.WSOption{gop2b.WithWSURL(p.URL)}, opts...)...)
+}
+
+// marketDepthFrame is a depth notification of market with one ask and one bid
+func marketDepthFrame(market string, full bool, updateID int64, ask, bid string) string {
+	return gop2btest.Notification("depth", full, map[string]interface{}{
+		"asks":      [][]string{{ask, "1"}},
+		"bids":      [][]string{{bid, "1"}},
+		"update_id": updateID,
+	}, market)
+}
+
+// quotesAre returns whether the best quotes of market in m are bid and ask
+func quotesAre(m *gop2b.BookManager, market, bid, ask string) func() bool {
+	return func() bool {
+		gotBid, gotAsk := m.BestQuotes(market)
+		return gotBid.Equal(decimal.RequireFromString(bid)) && gotAsk.Equal(decimal.RequireFromString(ask))
+	}
+}
+
+func TestBookManager(t *testing.T) {
+	server := gop2btest.NewWsServer()
+	t.Cleanup(server.Close)
+	server.OnSubscribe("depth",
+		marketDepthFrame("ETH_BTC", true, 10, "0.055", "0.054"),
+		marketDepthFrame("LTC_BTC", true, 10, "0.003", "0.0029"))
+
+	m, err := gop2b.NewBookManager(context.Background(), wsServer{server}, 10, "LTC_BTC", "ETH_BTC", "ETH_BTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	if markets := m.Markets(); !reflect.DeepEqual(markets, []string{"ETH_BTC", "LTC_BTC"}) {
+		t.Errorf("markets %v", markets)
+	}
+	if n := server.Connections(); n != 1 {
+		t.Errorf("%d connections, want one for all markets", n)
+	}
+	subscribes := server.Requests("depth.subscribe_multi")
+	if len(subscribes) != 1 {
+		t.Fatalf("%d depth subscribes, want 1", len(subscribes))
+	}
+	var params []string
+	for _, p := range subscribes[0].Params {
+		params = append(params, string(p))
+	}
+	if want := []string{`["ETH_BTC",10,"0"]`, `["LTC_BTC",10,"0"]`}; !reflect.DeepEqual(params, want) {
+		t.Errorf("subscribed %v, want %v", params, want)
+	}
+	eventually(t, "ETH_BTC snapshot", quotesAre(m, "ETH_BTC", "0.054", "0.055"))
+	eventually(t, "LTC_BTC snapshot", quotesAre(m, "LTC_BTC", "0.0029", "0.003"))
+
+	// a diff only changes the book of its market, the sequences of the markets are apart
+	server.Send(gop2btest.Notification("depth", false, map[string]interface{}{
+		"asks":      [][]string{{"0.055", "0"}, {"0.0555", "2"}},
+		"update_id": 11,
+	}, "ETH_BTC"))
+	eventually(t, "ETH_BTC diff", quotesAre(m, "ETH_BTC", "0.054", "0.0555"))
+	if !quotesAre(m, "LTC_BTC", "0.0029", "0.003")() {
+		t.Error("LTC_BTC book changed by a diff of ETH_BTC")
+	}
+
+	// a gap in one market resnapshots every market of the connection
+	server.OnSubscribe("depth",
+		marketDepthFrame("ETH_BTC", true, 20, "0.0556", "0.0541"),
+		marketDepthFrame("LTC_BTC", true, 30, "0.0031", "0.0030"))
+	server.Send(marketDepthFrame("LTC_BTC", false, 13, "0.0032", "0.0028"))
+	eventually(t, "LTC_BTC resnapshot", quotesAre(m, "LTC_BTC", "0.0030", "0.0031"))
+	eventually(t, "ETH_BTC resnapshot", quotesAre(m, "ETH_BTC", "0.0541", "0.0556"))
+	if n := len(server.Requests("depth.subscribe_multi")); n != 2 {
+		t.Errorf("%d depth subscribes, want a resnapshot", n)
+	}
+	if n := server.Connections(); n != 1 {
+		t.Errorf("%d connections after the resnapshot, want 1", n)
+	}
+
+	if m.Book("BTC_USDT") != nil {
+		t.Error("book of a market not subscribed")
+	}
+	if bid, ask := m.BestQuotes("BTC_USDT"); !bid.IsZero() || !ask.IsZero() {
+		t.Errorf("quotes %s/%s of a market not subscribed", bid, ask)
+	}
+	if err := m.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBookManagerEmptyUntilSnapshot(t *testing.T) {
+	server := gop2btest.NewWsServer()
+	t.Cleanup(server.Close)
+	m, err := gop2b.NewBookManager(context.Background(), wsServer{server}, 10, "ETH_BTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	if bid, ask := m.BestQuotes("ETH_BTC"); !bid.IsZero() || !ask.IsZero() {
+		t.Errorf("quotes %s/%s before the snapshot", bid, ask)
+	}
+	server.Send(marketDepthFrame("ETH_BTC", true, 1, "0.055", "0.054"))
+	eventually(t, "snapshot", quotesAre(m, "ETH_BTC", "0.054", "0.055"))
+}
+
+func TestBookManagerErrors(t *testing.T) {
+	if _, err := gop2b.NewBookManager(context.Background(), wsServer{}, 10); err == nil {
+		t.Error("no error without markets")
+	}
+	server := gop2btest.NewWsServer()
+	t.Cleanup(server.Close)
+	server.SetAckError("depth.subscribe_multi", 2, "invalid market")
+	if _, err := gop2b.NewBookManager(context.Background(), wsServer{server}, 10, "NOPE_BTC"); err == nil {
+		t.Error("no error for a rejected subscription")
+	}
+}
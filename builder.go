@@ -0,0 +1,211 @@
+package gop2b
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	// klineMaxLimit is the largest amount of candles FetchKlines collects
+	klineMaxLimit = 1000
+	// defaultKlineInterval is the interval of a KlineRequest without one
+	defaultKlineInterval = Interval1h
+)
+
+// KlineRequest selects candles for FetchKlines. Either Offset and Limit page back from the
+// most recent candle, or Start and End select a time range, Limit then keeping the latest candles.
+type KlineRequest struct {
+	Market   string
+	Interval KlineInterval
+	// Offset counts candles back from the most recent one
+	Offset int
+	// Limit is the amount of candles, up to 1000, zero for one page of 100
+	Limit int
+	Start time.Time
+	End   time.Time
+}
+
+// Validate checks the request and fills in the defaults
+func (r *KlineRequest) Validate() error {
+	if r.Market == "" {
+		return fmt.Errorf("%w: market is required", ErrInvalidRequest)
+	}
+	if r.Interval == "" {
+		r.Interval = defaultKlineInterval
+	}
+	if r.Interval.Duration() == 0 {
+		return fmt.Errorf("%w: unknown kline interval %q", ErrInvalidRequest, r.Interval)
+	}
+	if r.Limit < 0 || r.Limit > klineMaxLimit {
+		return fmt.Errorf("%w: limit %d out of range [0, %d], 0 being one page of %d", ErrInvalidRequest, r.Limit, klineMaxLimit, klinePageLimit)
+	}
+	if r.Offset < 0 {
+		return fmt.Errorf("%w: negative offset %d", ErrInvalidRequest, r.Offset)
+	}
+	if r.Start.IsZero() != r.End.IsZero() {
+		return fmt.Errorf("%w: range needs both start and end", ErrInvalidRequest)
+	}
+	if !r.Start.IsZero() {
+		if !r.Start.Before(r.End) {
+			return fmt.Errorf("%w: start must be before end", ErrInvalidRequest)
+		}
+		if r.Offset != 0 {
+			return fmt.Errorf("%w: offset can't be combined with a range", ErrInvalidRequest)
+		}
+	}
+	return nil
+}
+
+// KlineRequestBuilder builds a KlineRequest
+type KlineRequestBuilder struct {
+	req KlineRequest
+}
+
+// NewKlineRequest starts a kline request of market
+func NewKlineRequest(market string) *KlineRequestBuilder {
+	return &KlineRequestBuilder{req: KlineRequest{Market: market}}
+}
+
+// WithInterval sets the candle interval, 1h by default
+func (b *KlineRequestBuilder) WithInterval(interval KlineInterval) *KlineRequestBuilder {
+	b.req.Interval = interval
+	return b
+}
+
+// WithOffset skips the offset most recent candles
+func (b *KlineRequestBuilder) WithOffset(offset int) *KlineRequestBuilder {
+	b.req.Offset = offset
+	return b
+}
+
+// WithLimit sets the amount of candles, up to 1000. 0 keeps the default of one page of 100.
+func (b *KlineRequestBuilder) WithLimit(limit int) *KlineRequestBuilder {
+	b.req.Limit = limit
+	return b
+}
+
+// WithRange selects the candles opened in [start, end)
+func (b *KlineRequestBuilder) WithRange(start, end time.Time) *KlineRequestBuilder {
+	b.req.Start, b.req.End = start, end
+	return b
+}
+
+// Build returns the validated request
+func (b *KlineRequestBuilder) Build() (KlineRequest, error) {
+	req := b.req
+	if err := req.Validate(); err != nil {
+		return KlineRequest{}, err
+	}
+	return req, nil
+}
+
+// FetchKlines returns the candles selected by request sorted by time, paging as needed
+func (c *client) FetchKlines(ctx context.Context, request KlineRequest) ([]Kline, error) {
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+	if !request.Start.IsZero() {
+		klines, err := c.GetKlineRange(ctx, request.Market, request.Interval, request.Start, request.End)
+		if err != nil {
+			return nil, err
+		}
+		if request.Limit > 0 && len(klines) > request.Limit {
+			klines = klines[len(klines)-request.Limit:]
+		}
+		return klines, nil
+	}
+
+	limit := request.Limit
+	if limit == 0 {
+		limit = klinePageLimit
+	}
+	var klines []Kline
+	for offset := request.Offset; len(klines) < limit; {
+		pageLimit := min(klinePageLimit, limit-len(klines))
+		page, err := c.GetKlines(ctx, request.Market, request.Interval, offset, pageLimit)
+		if err != nil {
+			return nil, err
+		}
+		if !page.Success {
+			return nil, page.Err()
+		}
+		klines = append(klines, page.Result...)
+		if len(page.Result) < pageLimit {
+			break
+		}
+		offset += len(page.Result)
+	}
+	// a candle opening between two calls shifts the offsets, repeating one at the page boundary
+	sort.Slice(klines, func(i, j int) bool { return klines[i].Time.Before(klines[j].Time) })
+	deduped := klines[:0]
+	for i, k := range klines {
+		if i == 0 || !k.Time.Equal(klines[i-1].Time) {
+			deduped = append(deduped, k)
+		}
+	}
+	return deduped, nil
+}
+
+// HistoryRequest selects public trades for GetHistory
+type HistoryRequest struct {
+	Market string
+	// LastID only returns trades with a greater id
+	LastID int64
+	// Limit is the amount of trades, up to 100, zero for the server default
+	Limit int
+}
+
+// Validate checks the request
+func (r *HistoryRequest) Validate() error {
+	if r.Market == "" {
+		return fmt.Errorf("%w: market is required", ErrInvalidRequest)
+	}
+	if r.LastID < 0 {
+		return fmt.Errorf("%w: negative last id %d", ErrInvalidRequest, r.LastID)
+	}
+	if r.Limit < 0 || r.Limit > tradeBackfillLimit {
+		return fmt.Errorf("%w: limit %d out of range [0, %d], 0 being the server default", ErrInvalidRequest, r.Limit, tradeBackfillLimit)
+	}
+	return nil
+}
+
+// HistoryRequestBuilder builds a HistoryRequest
+type HistoryRequestBuilder struct {
+	req HistoryRequest
+}
+
+// NewHistoryRequest starts a trade history request of market
+func NewHistoryRequest(market string) *HistoryRequestBuilder {
+	return &HistoryRequestBuilder{req: HistoryRequest{Market: market}}
+}
+
+// WithLastID only selects trades with an id greater than lastID
+func (b *HistoryRequestBuilder) WithLastID(lastID int64) *HistoryRequestBuilder {
+	b.req.LastID = lastID
+	return b
+}
+
+// WithLimit sets the amount of trades, up to 100. 0 keeps the server default.
+func (b *HistoryRequestBuilder) WithLimit(limit int) *HistoryRequestBuilder {
+	b.req.Limit = limit
+	return b
+}
+
+// Build returns the validated request
+func (b *HistoryRequestBuilder) Build() (HistoryRequest, error) {
+	req := b.req
+	if err := req.Validate(); err != nil {
+		return HistoryRequest{}, err
+	}
+	return req, nil
+}
+
+// FetchHistory returns the public trades selected by request
+func (c *client) FetchHistory(ctx context.Context, request HistoryRequest) (*HistoryResp, error) {
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+	return c.GetHistory(ctx, request.Market, request.LastID, request.Limit)
+}
@@ -0,0 +1,92 @@
+package gop2b_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sutapurachina/gop2b"
+)
+
+func TestKlineRequestBuilderDefaults(t *testing.T) {
+	req, err := gop2b.NewKlineRequest("ETH_BTC").Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := gop2b.KlineRequest{Market: "ETH_BTC", Interval: gop2b.Interval1h}
+	if req != want {
+		t.Errorf("request %+v, want %+v", req, want)
+	}
+}
+
+func TestKlineRequestBuilder(t *testing.T) {
+	start := time.Date(2023, 11, 14, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+	req, err := gop2b.NewKlineRequest("ETH_BTC").WithInterval(gop2b.Interval1m).WithLimit(200).WithRange(start, end).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := gop2b.KlineRequest{Market: "ETH_BTC", Interval: gop2b.Interval1m, Limit: 200, Start: start, End: end}
+	if req != want {
+		t.Errorf("request %+v, want %+v", req, want)
+	}
+}
+
+func TestKlineRequestBuilderInvalid(t *testing.T) {
+	start := time.Date(2023, 11, 14, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		builder *gop2b.KlineRequestBuilder
+	}{
+		{"no market", gop2b.NewKlineRequest("")},
+		{"unknown interval", gop2b.NewKlineRequest("ETH_BTC").WithInterval("7m")},
+		{"negative limit", gop2b.NewKlineRequest("ETH_BTC").WithLimit(-1)},
+		{"limit above 1000", gop2b.NewKlineRequest("ETH_BTC").WithLimit(1001)},
+		{"negative offset", gop2b.NewKlineRequest("ETH_BTC").WithOffset(-1)},
+		{"start only", gop2b.NewKlineRequest("ETH_BTC").WithRange(start, time.Time{})},
+		{"empty range", gop2b.NewKlineRequest("ETH_BTC").WithRange(start, start)},
+		{"range and offset", gop2b.NewKlineRequest("ETH_BTC").WithRange(start, start.Add(time.Hour)).WithOffset(5)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := tt.builder.Build()
+			if !errors.Is(err, gop2b.ErrInvalidRequest) {
+				t.Errorf("error %v, want ErrInvalidRequest", err)
+			}
+			if req != (gop2b.KlineRequest{}) {
+				t.Errorf("request %+v returned with the error", req)
+			}
+		})
+	}
+}
+
+func TestRequestBuilderZeroLimit(t *testing.T) {
+	klines, err := gop2b.NewKlineRequest("ETH_BTC").WithLimit(0).Build()
+	if err != nil || klines.Limit != 0 {
+		t.Errorf("kline request %+v, %v, want limit 0 for the default", klines, err)
+	}
+	history, err := gop2b.NewHistoryRequest("ETH_BTC").WithLimit(0).Build()
+	if err != nil || history.Limit != 0 {
+		t.Errorf("history request %+v, %v, want limit 0 for the server default", history, err)
+	}
+}
+
+func TestHistoryRequestBuilder(t *testing.T) {
+	req, err := gop2b.NewHistoryRequest("ETH_BTC").WithLastID(1001).WithLimit(100).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (gop2b.HistoryRequest{Market: "ETH_BTC", LastID: 1001, Limit: 100}); req != want {
+		t.Errorf("request %+v, want %+v", req, want)
+	}
+	for _, builder := range []*gop2b.HistoryRequestBuilder{
+		gop2b.NewHistoryRequest(""),
+		gop2b.NewHistoryRequest("ETH_BTC").WithLastID(-1),
+		gop2b.NewHistoryRequest("ETH_BTC").WithLimit(101),
+		gop2b.NewHistoryRequest("ETH_BTC").WithLimit(-1),
+	} {
+		if _, err := builder.Build(); !errors.Is(err, gop2b.ErrInvalidRequest) {
+			t.Errorf("error %v, want ErrInvalidRequest", err)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package gop2b
+
+import (
+	"sync"
+	"time"
+)
+
+// CacheStats reports the usage of the response cache
+type CacheStats struct {
+	Hits    uint64
+	Misses  uint64
+	Entries int
+}
+
+type cacheEntry struct {
+	body    []byte
+	expires time.Time
+}
+
+// responseCache keeps successful public GET response bodies keyed by full URL.
+// A nil cache never hits.
+type responseCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]cacheEntry
+	hits    uint64
+	misses  uint64
+}
+
+func newResponseCache(ttl time.Duration) *responseCache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &responseCache{ttl: ttl, entries: make(map[string]cacheEntry)}
+}
+
+func (rc *responseCache) get(key string) ([]byte, bool) {
+	if rc == nil {
+		return nil, false
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	entry, ok := rc.entries[key]
+	if ok && time.Now().Before(entry.expires) {
+		rc.hits++
+		return entry.body, true
+	}
+	if ok {
+		delete(rc.entries, key)
+	}
+	rc.misses++
+	return nil, false
+}
+
+func (rc *responseCache) put(key string, body []byte) {
+	if rc == nil {
+		return
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.entries[key] = cacheEntry{body: body, expires: time.Now().Add(rc.ttl)}
+}
+
+func (rc *responseCache) stats() CacheStats {
+	if rc == nil {
+		return CacheStats{}
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return CacheStats{Hits: rc.hits, Misses: rc.misses, Entries: len(rc.entries)}
+}
+
+func (rc *responseCache) purge() {
+	if rc == nil {
+		return
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.entries = make(map[string]cacheEntry)
+}
+
+// CacheStats returns the response cache counters, all zero when caching is disabled
+func (c *client) CacheStats() CacheStats {
+	return c.cache.stats()
+}
+
+// PurgeCache drops all cached responses
+func (c *client) PurgeCache() {
+	c.cache.purge()
+}
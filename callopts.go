@@ -0,0 +1,43 @@
+package gop2b
+
+import (
+	"context"
+	"time"
+)
+
+// CallOptions override client defaults for the requests made with a context, see WithCallOptions
+type CallOptions struct {
+	// Timeout bounds the whole call, retries and rate limit waits included. A shorter deadline
+	// of the context or the http.Client timeout still applies, zero keeps the defaults.
+	Timeout time.Duration
+	// NoRetry disables WithRetry for the call
+	NoRetry bool
+}
+
+type callOptionsKey struct{}
+
+// WithCallOptions returns a context whose requests use opts instead of the client defaults,
+// replacing options set on ctx before
+func WithCallOptions(ctx context.Context, opts CallOptions) context.Context {
+	return context.WithValue(ctx, callOptionsKey{}, opts)
+}
+
+// WithCallTimeout sets CallOptions.Timeout, keeping the other options of ctx
+func WithCallTimeout(ctx context.Context, d time.Duration) context.Context {
+	opts := callOptionsFromContext(ctx)
+	opts.Timeout = d
+	return WithCallOptions(ctx, opts)
+}
+
+// WithCallRetryDisabled sets CallOptions.NoRetry, keeping the other options of ctx
+func WithCallRetryDisabled(ctx context.Context) context.Context {
+	opts := callOptionsFromContext(ctx)
+	opts.NoRetry = true
+	return WithCallOptions(ctx, opts)
+}
+
+// callOptionsFromContext returns the options set by WithCallOptions, zero when there are none
+func callOptionsFromContext(ctx context.Context) CallOptions {
+	opts, _ := ctx.Value(callOptionsKey{}).(CallOptions)
+	return opts
+}
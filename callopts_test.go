@@ -0,0 +1,65 @@
+package gop2b_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/sutapurachina/gop2b"
+)
+
+func TestCallTimeout(t *testing.T) {
+	client, server := newTestClient(t)
+	server.SetLatency("/public/markets", 300*time.Millisecond)
+	if _, err := client.GetMarkets(context.Background()); err != nil {
+		t.Fatalf("without call options: %v", err)
+	}
+
+	start := time.Now()
+	_, err := client.GetMarkets(gop2b.WithCallTimeout(context.Background(), 50*time.Millisecond))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error %v, want the call timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 250*time.Millisecond {
+		t.Errorf("call timed out after %s", elapsed)
+	}
+}
+
+func TestCallTimeoutDoesNotExtendDeadline(t *testing.T) {
+	client, server := newTestClient(t)
+	server.SetLatency("/public/markets", 300*time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := client.GetMarkets(gop2b.WithCallTimeout(ctx, time.Minute))
+	if !errors.Is(err, context.DeadlineExceeded) || time.Since(start) > 250*time.Millisecond {
+		t.Errorf("error %v after %s, want the shorter context deadline", err, time.Since(start))
+	}
+}
+
+func TestCallNoRetry(t *testing.T) {
+	client, server := newTestClient(t, gop2b.WithRetry(3, time.Millisecond))
+	server.SetError("/public/markets", http.StatusBadGateway, "bad gateway")
+
+	ctx := gop2b.WithCallOptions(context.Background(), gop2b.CallOptions{NoRetry: true})
+	if _, err := client.GetMarkets(ctx); err == nil {
+		t.Fatal("no error")
+	}
+	if n := server.Requests("/public/markets"); n != 1 {
+		t.Errorf("%d requests with NoRetry, want 1", n)
+	}
+
+	// the options compose, a timeout set afterwards keeps NoRetry
+	ctx = gop2b.WithCallTimeout(gop2b.WithCallRetryDisabled(context.Background()), time.Second)
+	_, _ = client.GetMarkets(ctx)
+	if n := server.Requests("/public/markets"); n != 2 {
+		t.Errorf("%d requests, want 2", n)
+	}
+
+	_, _ = client.GetMarkets(context.Background())
+	if n := server.Requests("/public/markets"); n != 5 {
+		t.Errorf("%d requests, want the client default of 3 attempts", n-2)
+	}
+}
@@ -0,0 +1,140 @@
+package gop2b
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// captureBodyLimit is the size kept of each captured request and response body
+const captureBodyLimit = 4 << 10
+
+// Exchange is a captured HTTP attempt, see WithResponseCapture.
+// Headers, which hold the credentials and the signature, are not kept.
+type Exchange struct {
+	Time     time.Time
+	Method   string
+	Endpoint string
+	// Status is zero when no response arrived, Err tells why
+	Status   int
+	Duration time.Duration
+	Err      string
+	// RequestBody and ResponseBody are cut at 4KiB, Truncated is set when either was.
+	// ResponseBody is what the client read of the response.
+	RequestBody  string
+	ResponseBody string
+	Truncated    bool
+}
+
+// WithResponseCapture keeps the last n HTTP attempts with their bodies for RecentExchanges,
+// to look at the traffic after something went wrong without debug logging enabled in advance.
+// Memory is bounded by n and by the 4KiB kept of each body.
+func WithResponseCapture(n int) Option {
+	return func(c *client) {
+		c.capture = newExchangeCapture(n)
+	}
+}
+
+// RecentExchanges returns the captured HTTP attempts oldest first, none without WithResponseCapture.
+// An attempt is captured once its response body was closed.
+func (c *client) RecentExchanges() []Exchange {
+	return c.capture.recent()
+}
+
+// exchangeCapture is a ring buffer of exchanges. A nil capture keeps nothing.
+type exchangeCapture struct {
+	mu      sync.Mutex
+	entries []Exchange
+	next    int
+	full    bool
+}
+
+func newExchangeCapture(n int) *exchangeCapture {
+	if n <= 0 {
+		return nil
+	}
+	return &exchangeCapture{entries: make([]Exchange, n)}
+}
+
+func (ec *exchangeCapture) add(e Exchange) {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	ec.entries[ec.next] = e
+	ec.next = (ec.next + 1) % len(ec.entries)
+	if ec.next == 0 {
+		ec.full = true
+	}
+}
+
+func (ec *exchangeCapture) recent() []Exchange {
+	if ec == nil {
+		return nil
+	}
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	if !ec.full {
+		return append([]Exchange(nil), ec.entries[:ec.next]...)
+	}
+	return append(append([]Exchange(nil), ec.entries[ec.next:]...), ec.entries[:ec.next]...)
+}
+
+// record captures an attempt of request, resp being nil when err is set. The response
+// body is replaced by one capturing what is read of it, the attempt is kept when it is closed.
+func (ec *exchangeCapture) record(request *http.Request, endpoint string, resp *http.Response, err error, start time.Time, d time.Duration) {
+	if ec == nil {
+		return
+	}
+	e := Exchange{Time: start, Method: request.Method, Endpoint: endpoint, Duration: d}
+	if request.GetBody != nil {
+		if body, bodyErr := request.GetBody(); bodyErr == nil {
+			e.RequestBody, e.Truncated = readCapped(body)
+			body.Close()
+		}
+	}
+	if err != nil {
+		e.Err = err.Error()
+		ec.add(e)
+		return
+	}
+	e.Status = resp.StatusCode
+	resp.Body = &capturedBody{ReadCloser: resp.Body, exchange: e, capture: ec}
+}
+
+// readCapped reads up to captureBodyLimit bytes of r
+func readCapped(r io.Reader) (string, bool) {
+	data, _ := io.ReadAll(io.LimitReader(r, captureBodyLimit+1))
+	if len(data) > captureBodyLimit {
+		return string(data[:captureBodyLimit]), true
+	}
+	return string(data), false
+}
+
+// capturedBody keeps the first captureBodyLimit bytes read and captures the exchange on Close
+type capturedBody struct {
+	io.ReadCloser
+	exchange Exchange
+	capture  *exchangeCapture
+	data     []byte
+	read     int
+	once     sync.Once
+}
+
+func (b *capturedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if room := captureBodyLimit - len(b.data); room > 0 {
+		b.data = append(b.data, p[:min(n, room)]...)
+	}
+	if b.read += n; b.read > captureBodyLimit {
+		b.exchange.Truncated = true
+	}
+	return n, err
+}
+
+func (b *capturedBody) Close() error {
+	b.once.Do(func() {
+		b.exchange.ResponseBody = string(b.data)
+		b.capture.add(b.exchange)
+	})
+	return b.ReadCloser.Close()
+}
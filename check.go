@@ -0,0 +1,173 @@
+package gop2b
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// quoteCacheTTL is how long the best quotes valuing a market order are reused
+const quoteCacheTTL = 2 * time.Second
+
+type quoteCache struct {
+	mu     sync.Mutex
+	quotes map[string]cachedQuote
+}
+
+type cachedQuote struct {
+	bid, ask decimal.Decimal
+	at       time.Time
+}
+
+// cachedBestQuotes returns the best quotes of market, fetching them when missing or stale
+func (c *client) cachedBestQuotes(ctx context.Context, market string) (bid, ask decimal.Decimal, err error) {
+	c.quotes.mu.Lock()
+	q, ok := c.quotes.quotes[market]
+	c.quotes.mu.Unlock()
+	if ok && time.Since(q.at) < quoteCacheTTL {
+		return q.bid, q.ask, nil
+	}
+	bid, ask, err = c.GetBestQuotes(ctx, market)
+	if err != nil {
+		return bid, ask, err
+	}
+	c.quotes.mu.Lock()
+	defer c.quotes.mu.Unlock()
+	if c.quotes.quotes == nil {
+		c.quotes.quotes = make(map[string]cachedQuote)
+	}
+	c.quotes.quotes[market] = cachedQuote{bid: bid, ask: ask, at: time.Now()}
+	return bid, ask, nil
+}
+
+// CheckOrder checks request against the limits of its market with MarketInfo.Validate before
+// it is placed, so that it isn't rejected by the exchange. An amount or price out of the limits
+// fails with ErrInvalidRequest and a total, amount * price, below the market minimum with ErrBelowMinTotal.
+// A request without a price, such as a market order, is valued at the best price of the book
+// it would take, the ask for a buy and the bid for a sell, cached for two seconds.
+func (c *client) CheckOrder(ctx context.Context, request *NewOrderRequest) error {
+	market, err := c.ResolveMarket(ctx, request.Market)
+	if err != nil {
+		return err
+	}
+	markets, err := c.cachedMarkets(ctx)
+	if err != nil {
+		return err
+	}
+	info, ok := markets[Market(market)]
+	if !ok {
+		return fmt.Errorf("%w %q", ErrUnknownMarket, market)
+	}
+	if err := info.Validate(request.Price, request.Amount); err != nil || request.Price.IsPositive() || !info.Limits.MinTotal.IsPositive() {
+		return err
+	}
+	// without a price, value the order at the book price it would take
+	price, err := c.takePrice(ctx, market, request.Side)
+	if err != nil {
+		return err
+	}
+	return info.Validate(price, request.Amount)
+}
+
+// takePrice returns the best book price an order on side of market would take,
+// the ask for a buy and the bid for a sell
+func (c *client) takePrice(ctx context.Context, market string, side Side) (decimal.Decimal, error) {
+	side, err := ParseSide(string(side))
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+	bid, ask, err := c.cachedBestQuotes(ctx, market)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	price := ask
+	if side == SideSell {
+		price = bid
+	}
+	if !price.IsPositive() {
+		return decimal.Zero, fmt.Errorf("%w: no %s price in the book of %s", ErrInvalidRequest, side.Opposite(), market)
+	}
+	return price, nil
+}
+
+// checkPostOnly fails with ErrPostOnlyWouldTake when request would match the current book:
+// a buy at or above the best ask or a sell at or below the best bid
+func (c *client) checkPostOnly(ctx context.Context, request *NewOrderRequest) error {
+	if !request.Price.IsPositive() {
+		return fmt.Errorf("%w: post-only order without price", ErrInvalidRequest)
+	}
+	side, err := ParseSide(string(request.Side))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+	bid, ask, err := c.GetBestQuotes(ctx, request.Market)
+	if err != nil {
+		return err
+	}
+	if side == SideBuy && ask.IsPositive() && request.Price.GreaterThanOrEqual(ask) {
+		return fmt.Errorf("%w: buy at %s, best ask %s", ErrPostOnlyWouldTake, request.Price, ask)
+	}
+	if side == SideSell && bid.IsPositive() && request.Price.LessThanOrEqual(bid) {
+		return fmt.Errorf("%w: sell at %s, best bid %s", ErrPostOnlyWouldTake, request.Price, bid)
+	}
+	return nil
+}
+
+// orderValueCap is the limit set by WithMaxOrderValue
+type orderValueCap struct {
+	quote string
+	max   decimal.Decimal
+}
+
+// WithMaxOrderValue makes PostNewOrder reject orders worth more than max in quote with
+// ErrOrderValueExceedsCap before sending them. The value is amount * price, the price
+// being the best book price for an order without one, converted to quote with ConversionRate
+// when the market money currency is another one.
+func WithMaxOrderValue(quote string, max decimal.Decimal) Option {
+	return func(c *client) {
+		c.maxOrderValue = &orderValueCap{quote: strings.ToUpper(quote), max: max}
+	}
+}
+
+// checkOrderValue checks request against the WithMaxOrderValue cap, nil without a cap
+func (c *client) checkOrderValue(ctx context.Context, request *NewOrderRequest) error {
+	limit := c.maxOrderValue
+	if limit == nil {
+		return nil
+	}
+	market, err := c.ResolveMarket(ctx, request.Market)
+	if err != nil {
+		return err
+	}
+	markets, err := c.cachedMarkets(ctx)
+	if err != nil {
+		return err
+	}
+	info, ok := markets[Market(market)]
+	if !ok {
+		return fmt.Errorf("%w %q", ErrUnknownMarket, market)
+	}
+	price := request.Price
+	if !price.IsPositive() {
+		if price, err = c.takePrice(ctx, market, request.Side); err != nil {
+			return err
+		}
+	}
+	value := request.Amount.Mul(price)
+	if !info.Money.Is(limit.quote) {
+		conversion, err := c.ConversionRate(ctx, info.Money.String(), limit.quote)
+		if err != nil {
+			return err
+		}
+		value = value.Mul(conversion.Rate)
+	}
+	if value.GreaterThan(limit.max) {
+		return fmt.Errorf("%w: %s %s worth %s %s, cap %s %s", ErrOrderValueExceedsCap,
+			request.Amount, info.Stock, value, limit.quote, limit.max, limit.quote)
+	}
+	return nil
+}
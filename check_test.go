@@ -0,0 +1,46 @@
+package gop2b_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/sutapurachina/gop2b"
+)
+
+func TestCheckOrder(t *testing.T) {
+	// ETH_BTC of the markets fixture has a minimum amount of 0.001 and a minimum total of 0.0001 BTC,
+	// the book of the depth fixture its best bid at 0.0549 and best ask at 0.0551
+	tests := []struct {
+		name      string
+		side      gop2b.Side
+		amount    string
+		price     string
+		wantErr   error
+		wantBooks int
+	}{
+		{"limit above the minimum total", gop2b.SideBuy, "0.002", "0.05", nil, 0},
+		{"limit below the minimum total", gop2b.SideBuy, "0.001", "0.05", gop2b.ErrBelowMinTotal, 0},
+		{"amount below the minimum", gop2b.SideBuy, "0.0001", "0.05", gop2b.ErrInvalidRequest, 0},
+		{"market buy valued at the ask", gop2b.SideBuy, "0.002", "0", nil, 1},
+		{"market sell below the minimum total at the bid", gop2b.SideSell, "0.001", "0", gop2b.ErrBelowMinTotal, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := newTestClient(t)
+			err := client.CheckOrder(context.Background(), &gop2b.NewOrderRequest{
+				Market: "ETH_BTC",
+				Side:   tt.side,
+				Amount: decimal.RequireFromString(tt.amount),
+				Price:  decimal.RequireFromString(tt.price),
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("error %v, want %v", err, tt.wantErr)
+			}
+			if n := server.Requests("/public/depth/result"); n != tt.wantBooks {
+				t.Errorf("%d book requests, want %d", n, tt.wantBooks)
+			}
+		})
+	}
+}
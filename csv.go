@@ -0,0 +1,115 @@
+package gop2b
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// DealColumn is a column of the deals CSV export
+type DealColumn string
+
+const (
+	ColumnTime        DealColumn = "timestamp"
+	ColumnMarket      DealColumn = "market"
+	ColumnSide        DealColumn = "side"
+	ColumnRole        DealColumn = "role"
+	ColumnPrice       DealColumn = "price"
+	ColumnAmount      DealColumn = "amount"
+	ColumnTotal       DealColumn = "total"
+	ColumnFee         DealColumn = "fee"
+	ColumnFeeCurrency DealColumn = "fee_currency"
+	ColumnOrderID     DealColumn = "order_id"
+	ColumnDealID      DealColumn = "deal_id"
+)
+
+// DefaultDealColumns are the columns written when CSVOptions.Columns is empty
+var DefaultDealColumns = []DealColumn{
+	ColumnTime, ColumnMarket, ColumnSide, ColumnRole, ColumnPrice, ColumnAmount,
+	ColumnFee, ColumnFeeCurrency, ColumnOrderID, ColumnDealID,
+}
+
+// CSVOptions configures WriteDealsCSV
+type CSVOptions struct {
+	// Columns selects and orders the written columns, DefaultDealColumns when empty
+	Columns []DealColumn
+	// FixedPlaces renders every decimal with exactly that many places when positive,
+	// otherwise decimals are written as received. Decimals never use an exponent.
+	FixedPlaces int32
+	// NoHeader leaves out the header row
+	NoHeader bool
+	// Comma is the field delimiter, ',' when zero
+	Comma rune
+}
+
+// WriteDealsCSV writes deals as CSV, timestamps in RFC3339 UTC
+func WriteDealsCSV(w io.Writer, deals []Deal, opts CSVOptions) error {
+	columns := opts.Columns
+	if len(columns) == 0 {
+		columns = DefaultDealColumns
+	}
+	cw := csv.NewWriter(w)
+	if opts.Comma != 0 {
+		cw.Comma = opts.Comma
+	}
+	row := make([]string, len(columns))
+	if !opts.NoHeader {
+		for i, col := range columns {
+			row[i] = string(col)
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	for _, d := range deals {
+		for i, col := range columns {
+			value, err := dealCSVValue(d, col, opts.FixedPlaces)
+			if err != nil {
+				return err
+			}
+			row[i] = value
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func dealCSVValue(d Deal, col DealColumn, places int32) (string, error) {
+	formatDecimal := func(v decimal.Decimal) string {
+		if places > 0 {
+			return v.StringFixed(places)
+		}
+		return v.String()
+	}
+	switch col {
+	case ColumnTime:
+		return d.Time.UTC().Format(time.RFC3339Nano), nil
+	case ColumnMarket:
+		return d.Market.String(), nil
+	case ColumnSide:
+		return string(d.Side), nil
+	case ColumnRole:
+		return d.Role.String(), nil
+	case ColumnPrice:
+		return formatDecimal(d.Price), nil
+	case ColumnAmount:
+		return formatDecimal(d.Amount), nil
+	case ColumnTotal:
+		return formatDecimal(d.Total), nil
+	case ColumnFee:
+		return formatDecimal(d.Fee), nil
+	case ColumnFeeCurrency:
+		return d.FeeCurrency.String(), nil
+	case ColumnOrderID:
+		return d.OrderID.String(), nil
+	case ColumnDealID:
+		return d.ID.String(), nil
+	}
+	return "", fmt.Errorf("unknown deal column %q", col)
+}
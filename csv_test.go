@@ -0,0 +1,109 @@
+package gop2b_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sutapurachina/gop2b"
+)
+
+func testDeals() []gop2b.Deal {
+	return []gop2b.Deal{
+		{
+			ID:          1001,
+			OrderID:     25749,
+			Time:        gop2b.ExchangeTimeSeconds{Time: time.Date(2023, 11, 14, 22, 13, 20, 500_000_000, time.UTC)},
+			Price:       decimal.RequireFromString("0.055"),
+			Amount:      decimal.RequireFromString("0.25"),
+			Total:       decimal.RequireFromString("0.01375"),
+			Fee:         decimal.New(1, -12),
+			Role:        gop2b.RoleMaker,
+			Market:      "ETH_BTC",
+			Side:        gop2b.SideBuy,
+			FeeCurrency: "ETH",
+		},
+		{
+			ID:          1002,
+			OrderID:     25750,
+			Time:        gop2b.ExchangeTimeSeconds{Time: time.Date(2023, 11, 14, 22, 14, 0, 0, time.UTC)},
+			Price:       decimal.New(37, 20),
+			Amount:      decimal.New(5, -9),
+			Total:       decimal.New(185, 12),
+			Fee:         decimal.RequireFromString("0.37"),
+			Role:        gop2b.RoleTaker,
+			Market:      "BTC_USDT",
+			Side:        gop2b.SideSell,
+			FeeCurrency: "USDT",
+		},
+	}
+}
+
+func TestWriteDealsCSVRoundTrip(t *testing.T) {
+	deals := testDeals()
+	var buf bytes.Buffer
+	if err := gop2b.WriteDealsCSV(&buf, deals, gop2b.CSVOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != len(deals)+1 {
+		t.Fatalf("%d rows, want a header and %d deals", len(rows), len(deals))
+	}
+	header := strings.Join(rows[0], ",")
+	if want := "timestamp,market,side,role,price,amount,fee,fee_currency,order_id,deal_id"; header != want {
+		t.Errorf("header %s, want %s", header, want)
+	}
+	for i, row := range rows[1:] {
+		d := deals[i]
+		at, err := time.Parse(time.RFC3339, row[0])
+		if err != nil || !at.Equal(d.Time.Time) {
+			t.Errorf("deal %d: timestamp %s, want %s", i, row[0], d.Time.Time)
+		}
+		for _, field := range []struct {
+			value string
+			want  decimal.Decimal
+		}{{row[4], d.Price}, {row[5], d.Amount}, {row[6], d.Fee}} {
+			if strings.ContainsAny(field.value, "eE") {
+				t.Errorf("deal %d: %s has an exponent", i, field.value)
+			}
+			if v, err := decimal.NewFromString(field.value); err != nil || !v.Equal(field.want) {
+				t.Errorf("deal %d: %s, want %s", i, field.value, field.want)
+			}
+		}
+		if got := []string{row[1], row[2], row[3], row[7], row[8], row[9]}; strings.Join(got, ",") !=
+			strings.Join([]string{d.Market.String(), string(d.Side), d.Role.String(), d.FeeCurrency.String(), d.OrderID.String(), d.ID.String()}, ",") {
+			t.Errorf("deal %d: fields %v", i, got)
+		}
+	}
+}
+
+func TestWriteDealsCSVOptions(t *testing.T) {
+	var buf bytes.Buffer
+	err := gop2b.WriteDealsCSV(&buf, testDeals(), gop2b.CSVOptions{
+		Columns:     []gop2b.DealColumn{gop2b.ColumnDealID, gop2b.ColumnTotal, gop2b.ColumnFee},
+		FixedPlaces: 4,
+		NoHeader:    true,
+		Comma:       ';',
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "1001;0.0138;0.0000\n1002;185000000000000.0000;0.3700\n"
+	if buf.String() != want {
+		t.Errorf("got\n%swant\n%s", buf.String(), want)
+	}
+}
+
+func TestWriteDealsCSVUnknownColumn(t *testing.T) {
+	var buf bytes.Buffer
+	err := gop2b.WriteDealsCSV(&buf, testDeals(), gop2b.CSVOptions{Columns: []gop2b.DealColumn{"volume"}})
+	if err == nil {
+		t.Fatal("no error for an unknown column")
+	}
+}
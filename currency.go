@@ -0,0 +1,56 @@
+package gop2b
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxCurrencyLength bounds currency codes accepted by ParseCurrency
+const maxCurrencyLength = 16
+
+// Currency is an asset code such as "BTC". The exchange compares currency codes case
+// sensitively, so the type always encodes and decodes the upper case form.
+type Currency string
+
+// ParseCurrency trims and upper cases s and checks that only letters, digits, '-' and '_' are used
+func ParseCurrency(s string) (Currency, error) {
+	code := normalizeCurrency(s)
+	if code == "" {
+		return "", fmt.Errorf("%w: empty currency", ErrInvalidRequest)
+	}
+	if len(code) > maxCurrencyLength {
+		return "", fmt.Errorf("%w: currency %q longer than %d characters", ErrInvalidRequest, s, maxCurrencyLength)
+	}
+	for _, r := range code {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '-' && r != '_' {
+			return "", fmt.Errorf("%w: invalid character %q in currency %q", ErrInvalidRequest, r, s)
+		}
+	}
+	return Currency(code), nil
+}
+
+// String returns the currency code
+func (c Currency) String() string {
+	return string(c)
+}
+
+// Is reports whether c and other name the same currency, ignoring case and surrounding spaces
+func (c Currency) Is(other string) bool {
+	return normalizeCurrency(string(c)) == normalizeCurrency(other)
+}
+
+// MarshalText writes the upper case code, it's also used for map keys
+func (c Currency) MarshalText() ([]byte, error) {
+	return []byte(normalizeCurrency(string(c))), nil
+}
+
+// UnmarshalText reads a code in any case. Unlike ParseCurrency it doesn't check the
+// charset, so a single odd code from the exchange doesn't fail a whole response.
+func (c *Currency) UnmarshalText(data []byte) error {
+	*c = Currency(normalizeCurrency(string(data)))
+	return nil
+}
+
+func normalizeCurrency(s string) string {
+	return strings.ToUpper(strings.TrimSpace(s))
+}
@@ -0,0 +1,124 @@
+package gop2b
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// Role tells whether a deal added liquidity to the book or took it
+type Role int
+
+const (
+	RoleMaker Role = 1
+	RoleTaker Role = 2
+)
+
+func (r Role) String() string {
+	switch r {
+	case RoleMaker:
+		return "maker"
+	case RoleTaker:
+		return "taker"
+	}
+	return "unknown"
+}
+
+// UnmarshalJSON accepts the role as a number (1 maker, 2 taker) or as "maker" or "taker".
+// null leaves the role zero.
+func (r *Role) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*r = 0
+		return nil
+	}
+	var name string
+	if json.Unmarshal(data, &name) != nil {
+		var n int
+		if err := json.Unmarshal(data, &n); err != nil {
+			return fmt.Errorf("invalid deal role %s", data)
+		}
+		name = fmt.Sprint(n)
+	}
+	switch normalizeEnum(name) {
+	case "maker", "1":
+		*r = RoleMaker
+	case "taker", "2":
+		*r = RoleTaker
+	default:
+		return fmt.Errorf("invalid deal role %q", name)
+	}
+	return nil
+}
+
+// Deal is a single execution of one of the account orders
+type Deal struct {
+	ID      DealID              `json:"id"`
+	OrderID OrderID             `json:"dealOrderId"`
+	Time    ExchangeTimeSeconds `json:"time"`
+	Price   decimal.Decimal     `json:"price"`
+	Amount  decimal.Decimal     `json:"amount"`
+	// Total is the deal value in money, price * amount
+	Total decimal.Decimal `json:"deal"`
+	Fee   decimal.Decimal `json:"fee"`
+	Role  Role            `json:"role"`
+	// Market, Side and FeeCurrency aren't part of every deal payload and are left empty when unknown
+	Market      Market   `json:"market,omitempty"`
+	Side        Side     `json:"side,omitempty"`
+	FeeCurrency Currency `json:"feeCurrency,omitempty"`
+}
+
+// UnmarshalJSON accepts the order id as "dealOrderId" or "orderId" and the side as "side"
+// or "type", as the deal payloads of the exchange name them. A missing total is price * amount.
+func (d *Deal) UnmarshalJSON(data []byte) error {
+	type plain Deal
+	var v struct {
+		plain
+		OrderID *OrderID `json:"orderId"`
+		Type    *Side    `json:"type"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*d = Deal(v.plain)
+	if v.OrderID != nil && d.OrderID == 0 {
+		d.OrderID = *v.OrderID
+	}
+	if v.Type != nil && d.Side == "" {
+		d.Side = *v.Type
+	}
+	if d.Total.IsZero() {
+		d.Total = d.Price.Mul(d.Amount)
+	}
+	return nil
+}
+
+// DealFeeCurrency returns the currency the fee of a deal on side of market is charged in:
+// the currency received, stock for a buy and money for a sell
+func DealFeeCurrency(info MarketInfo, side Side) (Currency, error) {
+	switch side {
+	case SideBuy:
+		return info.Stock, nil
+	case SideSell:
+		return info.Money, nil
+	}
+	return "", fmt.Errorf("invalid side %q", side)
+}
+
+// ResolveFeeCurrency sets the market and fee currency of the deal from info when they are
+// unknown. The deal side must be known.
+func (d *Deal) ResolveFeeCurrency(info MarketInfo) error {
+	if d.Market != "" && d.Market != info.Name {
+		return fmt.Errorf("deal %d of %s resolved with market %s", d.ID, d.Market, info.Name)
+	}
+	d.Market = info.Name
+	if d.FeeCurrency != "" {
+		return nil
+	}
+	currency, err := DealFeeCurrency(info, d.Side)
+	if err != nil {
+		return fmt.Errorf("deal %d: %w", d.ID, err)
+	}
+	d.FeeCurrency = currency
+	return nil
+}
@@ -0,0 +1,55 @@
+package gop2b_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/sutapurachina/gop2b"
+)
+
+func TestRoleUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		data    string
+		want    gop2b.Role
+		wantErr bool
+	}{
+		{data: `1`, want: gop2b.RoleMaker},
+		{data: `2`, want: gop2b.RoleTaker},
+		{data: `"maker"`, want: gop2b.RoleMaker},
+		{data: `"Taker"`, want: gop2b.RoleTaker},
+		{data: `"2"`, want: gop2b.RoleTaker},
+		{data: `null`, want: 0},
+		{data: `3`, wantErr: true},
+		{data: `0`, wantErr: true},
+		{data: `"both"`, wantErr: true},
+		{data: `1.5`, wantErr: true},
+	}
+	for _, tt := range tests {
+		role := gop2b.RoleTaker
+		err := json.Unmarshal([]byte(tt.data), &role)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: error %v, want error %v", tt.data, err, tt.wantErr)
+			continue
+		}
+		if err == nil && role != tt.want {
+			t.Errorf("%s: role %v, want %v", tt.data, role, tt.want)
+		}
+	}
+}
+
+func TestDealResolveFeeCurrency(t *testing.T) {
+	info := gop2b.MarketInfo{Name: "ETH_BTC", Stock: "ETH", Money: "BTC"}
+	for side, want := range map[gop2b.Side]gop2b.Currency{gop2b.SideBuy: "ETH", gop2b.SideSell: "BTC"} {
+		deal := gop2b.Deal{ID: 1, Side: side}
+		if err := deal.ResolveFeeCurrency(info); err != nil {
+			t.Fatal(err)
+		}
+		if deal.Market != "ETH_BTC" || deal.FeeCurrency != want {
+			t.Errorf("%s deal resolved to %q paying fees in %q, want %q", side, deal.Market, deal.FeeCurrency, want)
+		}
+	}
+	deal := gop2b.Deal{ID: 1, Market: "LTC_BTC", Side: gop2b.SideBuy}
+	if err := deal.ResolveFeeCurrency(info); err == nil {
+		t.Error("deal of LTC_BTC resolved with ETH_BTC")
+	}
+}
@@ -0,0 +1,117 @@
+package gop2b_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/sutapurachina/gop2b"
+)
+
+// TestScientificNotation serves the fixtures of testdata/scientific, whose decimals are all
+// 1e-8 or 1E-8, and checks every decimal of the decoded responses and its plain encoding
+func TestScientificNotation(t *testing.T) {
+	tests := []struct {
+		path string
+		call func(ctx context.Context, c gop2b.Client) (interface{}, error)
+		// decimals is the amount of decimal fields the fixture sets
+		decimals int
+	}{
+		{"/public/ticker", func(ctx context.Context, c gop2b.Client) (interface{}, error) {
+			return c.GetTicker(ctx, "ETH_BTC")
+		}, 8},
+		{"/public/depth/result", func(ctx context.Context, c gop2b.Client) (interface{}, error) {
+			return c.GetDepth(ctx, "ETH_BTC", 0, "")
+		}, 4},
+		{"/public/history", func(ctx context.Context, c gop2b.Client) (interface{}, error) {
+			return c.GetHistory(ctx, "ETH_BTC", 0, 0)
+		}, 2},
+		{"/public/market/kline", func(ctx context.Context, c gop2b.Client) (interface{}, error) {
+			return c.GetKlines(ctx, "ETH_BTC", gop2b.Interval1h, 0, 0)
+		}, 6},
+		{"/account/balances", func(ctx context.Context, c gop2b.Client) (interface{}, error) {
+			return c.PostBalances(&gop2b.AccountBalancesRequest{})
+		}, 2},
+		{"/account/balance", func(ctx context.Context, c gop2b.Client) (interface{}, error) {
+			return c.PostCurrencyBalance(&gop2b.AccountCurrencyBalanceRequest{Currency: "BTC"})
+		}, 2},
+		{"/orders", func(ctx context.Context, c gop2b.Client) (interface{}, error) {
+			return c.PostOpenOrders(ctx, &gop2b.OpenOrdersRequest{Market: "ETH_BTC", Limit: 100})
+		}, 8},
+	}
+	want := decimal.New(1, -8)
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			body, err := os.ReadFile(filepath.Join("testdata", "scientific", strings.ReplaceAll(strings.TrimPrefix(tt.path, "/"), "/", "_")+".json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			client, server := newTestClient(t)
+			server.SetResponse(tt.path, string(body))
+			resp, err := tt.call(context.Background(), client)
+			if err != nil {
+				t.Fatal(err)
+			}
+			values := collectDecimals(reflect.ValueOf(resp))
+			if len(values) != tt.decimals {
+				t.Fatalf("%d decimals, want %d", len(values), tt.decimals)
+			}
+			for i, d := range values {
+				if !d.Equal(want) {
+					t.Errorf("decimal %d is %s, want %s", i, d, want)
+				}
+				data, err := json.Marshal(d)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(data) != `"0.00000001"` {
+					t.Errorf("decimal %d encodes as %s", i, data)
+				}
+				var back decimal.Decimal
+				if err := json.Unmarshal(data, &back); err != nil || !back.Equal(d) {
+					t.Errorf("decimal %d decodes back as %s, %v", i, back, err)
+				}
+			}
+		})
+	}
+}
+
+// collectDecimals returns the non-zero decimals reachable from v, in field order
+func collectDecimals(v reflect.Value) []decimal.Decimal {
+	if !v.IsValid() {
+		return nil
+	}
+	if d, ok := v.Interface().(decimal.Decimal); ok {
+		if d.IsZero() {
+			return nil
+		}
+		return []decimal.Decimal{d}
+	}
+	var values []decimal.Decimal
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			values = collectDecimals(v.Elem())
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				values = append(values, collectDecimals(v.Field(i))...)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			values = append(values, collectDecimals(v.Index(i))...)
+		}
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			values = append(values, collectDecimals(v.MapIndex(key))...)
+		}
+	}
+	return values
+}
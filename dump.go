@@ -0,0 +1,173 @@
+package gop2b
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// dumpHeader starts every dump, followed by the format version
+const dumpHeader = "gop2b-dump"
+
+// dumpVersion is the format version written by DumpRequest
+const dumpVersion = 1
+
+// dumpKeptHeaders are the request headers dumped with their value, the others are redacted
+var dumpKeptHeaders = map[string]bool{
+	"Accept":          true,
+	"Accept-Encoding": true,
+	"Content-Length":  true,
+	"Content-Type":    true,
+	"User-Agent":      true,
+}
+
+// RequestDump is a failed request as read back by ParseDump
+type RequestDump struct {
+	// Version is the format version the dump was written with
+	Version int
+	// Request has its headers redacted, Header only keeps the values of generic headers
+	Request RequestInfo
+	// Status is the HTTP status of the response, zero when the error didn't carry it
+	Status int
+	// ErrorBody is the response body carried by the error, cut at 4KiB
+	ErrorBody string
+	// Error is the message of an error carrying no body, such as a network error
+	Error string
+}
+
+// DumpRequest writes req, as received by a WithRequestObserver observer, and err, the error
+// of the call, as text safe to hand to exchange support. Nothing secret is written: the
+// values of headers other than generic ones such as Content-Type are redacted, including
+// the API key, payload and signature, and the body is given by its SHA-256 only.
+//
+// The text starts with a "gop2b-dump 1" line followed by one "key value" line per field,
+// values being quoted Go strings where they are free text. ParseDump reads it back.
+func DumpRequest(req RequestInfo, err error) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %d\n", dumpHeader, dumpVersion)
+	fmt.Fprintf(&b, "method %s\n", req.Method)
+	fmt.Fprintf(&b, "endpoint %s\n", req.Endpoint)
+	fmt.Fprintf(&b, "attempt %d\n", req.Attempt)
+	fmt.Fprintf(&b, "body-size %d\n", req.BodySize)
+	if req.PayloadSHA256 != "" {
+		fmt.Fprintf(&b, "payload-sha256 %s\n", req.PayloadSHA256)
+	}
+	keys := make([]string, 0, len(req.Header))
+	for key := range req.Header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, value := range req.Header[key] {
+			if !dumpKeptHeaders[http.CanonicalHeaderKey(key)] {
+				value = redacted
+			}
+			fmt.Fprintf(&b, "header %s %s\n", key, strconv.Quote(value))
+		}
+	}
+	status, body, carried := errorResponse(err)
+	if status != 0 {
+		fmt.Fprintf(&b, "status %d\n", status)
+	}
+	if carried {
+		if len(body) > captureBodyLimit {
+			body = body[:captureBodyLimit]
+		}
+		fmt.Fprintf(&b, "error-body %s\n", strconv.Quote(body))
+	} else if err != nil {
+		fmt.Fprintf(&b, "error %s\n", strconv.Quote(err.Error()))
+	}
+	return b.String()
+}
+
+// errorResponse returns the status and body of the response err was made of, if any
+func errorResponse(err error) (status int, body string, ok bool) {
+	var statusErr *StatusError
+	var banErr *IPBannedError
+	var maintenanceErr *MaintenanceError
+	switch {
+	case errors.As(err, &statusErr):
+		return statusErr.StatusCode, statusErr.Body, true
+	case errors.As(err, &banErr):
+		return http.StatusForbidden, banErr.Body, true
+	case errors.As(err, &maintenanceErr):
+		return 0, maintenanceErr.Body, true
+	}
+	return 0, "", false
+}
+
+// ParseDump reads a dump written by DumpRequest. Lines before the "gop2b-dump" line are
+// skipped, so a dump pasted in a message can be parsed as is, and the dump ends at the
+// first empty line. Keys unknown to this version are ignored, so dumps written by later
+// versions parse too.
+func ParseDump(text string) (*RequestDump, error) {
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	scanner.Buffer(nil, 1<<20)
+	var dump *RequestDump
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if dump == nil {
+			version, found := strings.CutPrefix(line, dumpHeader+" ")
+			if !found {
+				continue
+			}
+			v, err := strconv.Atoi(version)
+			if err != nil || v < 1 {
+				return nil, fmt.Errorf("%w: version %q", ErrInvalidDump, version)
+			}
+			dump = &RequestDump{Version: v}
+			continue
+		}
+		if line == "" {
+			break
+		}
+		key, value, _ := strings.Cut(line, " ")
+		if err := dump.set(key, value); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidDump, key, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if dump == nil {
+		return nil, fmt.Errorf("%w: no %s line", ErrInvalidDump, dumpHeader)
+	}
+	return dump, nil
+}
+
+// set reads the value of a dump line
+func (d *RequestDump) set(key, value string) error {
+	var err error
+	switch key {
+	case "method":
+		d.Request.Method = value
+	case "endpoint":
+		d.Request.Endpoint = value
+	case "attempt":
+		d.Request.Attempt, err = strconv.Atoi(value)
+	case "body-size":
+		d.Request.BodySize, err = strconv.ParseInt(value, 10, 64)
+	case "payload-sha256":
+		d.Request.PayloadSHA256 = value
+	case "header":
+		name, quoted, _ := strings.Cut(value, " ")
+		var v string
+		if v, err = strconv.Unquote(quoted); err == nil {
+			if d.Request.Header == nil {
+				d.Request.Header = make(http.Header)
+			}
+			d.Request.Header[name] = append(d.Request.Header[name], v)
+		}
+	case "status":
+		d.Status, err = strconv.Atoi(value)
+	case "error-body":
+		d.ErrorBody, err = strconv.Unquote(value)
+	case "error":
+		d.Error, err = strconv.Unquote(value)
+	}
+	return err
+}
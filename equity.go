@@ -0,0 +1,124 @@
+package gop2b
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// EquityPoint is one sample of the account value
+type EquityPoint struct {
+	At    time.Time
+	Total decimal.Decimal
+	// ByCurrency is the value of every valued holding in the sampler quote currency
+	ByCurrency map[Currency]decimal.Decimal
+	// Err is set when the sample failed, the point is then a gap without any value
+	Err error
+}
+
+// Gap reports whether the sample failed
+func (p EquityPoint) Gap() bool {
+	return p.Err != nil
+}
+
+// EquitySampler values the account with PortfolioValue every interval and keeps the
+// most recent samples in memory. Failed samples are kept as gaps.
+type EquitySampler struct {
+	rest     AccountClient
+	quote    string
+	interval time.Duration
+
+	mu     sync.RWMutex
+	points []EquityPoint
+	// next is the ring buffer slot written next, full once points reached its capacity
+	next int
+	full bool
+}
+
+// NewEquitySampler starts sampling the account value in quote every interval until ctx
+// is done, keeping the last capacity points. The first sample is taken immediately.
+func NewEquitySampler(ctx context.Context, rest AccountClient, quote string, interval time.Duration, capacity int) (*EquitySampler, error) {
+	if quote == "" {
+		return nil, errors.New("quote currency is required")
+	}
+	if interval <= 0 {
+		return nil, errors.New("sample interval must be positive")
+	}
+	if capacity <= 0 {
+		return nil, errors.New("capacity must be positive")
+	}
+	s := &EquitySampler{
+		rest:     rest,
+		quote:    quote,
+		interval: interval,
+		points:   make([]EquityPoint, 0, capacity),
+	}
+	go s.run(ctx)
+	return s, nil
+}
+
+// Series returns the points sampled in [from, to) oldest first, gaps included
+func (s *EquitySampler) Series(from, to time.Time) []EquityPoint {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var result []EquityPoint
+	for i := range s.points {
+		p := s.points[(s.next+i)%len(s.points)]
+		if !p.At.Before(from) && p.At.Before(to) {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+func (s *EquitySampler) run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		s.sample(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sample values the account, giving up after one interval so a slow valuation doesn't delay the next sample
+func (s *EquitySampler) sample(ctx context.Context) {
+	sampleCtx, cancel := context.WithTimeout(ctx, s.interval)
+	defer cancel()
+	point := EquityPoint{At: time.Now()}
+	portfolio, err := s.rest.PortfolioValue(sampleCtx, s.quote)
+	if err != nil {
+		if ctx.Err() != nil {
+			// stopped, not a gap
+			return
+		}
+		point.Err = err
+	} else {
+		point.Total = portfolio.Total
+		point.ByCurrency = make(map[Currency]decimal.Decimal, len(portfolio.Holdings))
+		for _, h := range portfolio.Holdings {
+			point.ByCurrency[h.Currency] = h.Value
+		}
+	}
+	s.add(point)
+}
+
+func (s *EquitySampler) add(p EquityPoint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.full {
+		s.points = append(s.points, p)
+		if len(s.points) == cap(s.points) {
+			s.full = true
+		}
+		return
+	}
+	s.points[s.next] = p
+	s.next = (s.next + 1) % len(s.points)
+}
@@ -0,0 +1,227 @@
+package gop2b
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrMaintenance matches a *MaintenanceError with errors.Is
+var ErrMaintenance = errors.New("exchange under maintenance")
+
+// ErrInvalidRequest is returned by request validation before anything is sent
+var ErrInvalidRequest = errors.New("invalid request")
+
+// ErrPaginationInconsistent is returned when pages overlap or skip records because the
+// data changed while paging. The records collected so far are returned along with it.
+var ErrPaginationInconsistent = errors.New("pagination inconsistent")
+
+// ErrIPBanned matches an *IPBannedError with errors.Is
+var ErrIPBanned = errors.New("ip banned by the exchange")
+
+// ErrSequenceGap is returned when a depth update doesn't follow the previous one
+var ErrSequenceGap = errors.New("depth sequence gap")
+
+// ErrBelowMinTotal is returned by CheckOrder for an order worth less than the market minimum total
+var ErrBelowMinTotal = errors.New("order total below the market minimum")
+
+// ErrOrderValueExceedsCap is returned by PostNewOrder for an order above the WithMaxOrderValue cap
+var ErrOrderValueExceedsCap = errors.New("order value exceeds the cap")
+
+// ErrPostOnlyWouldTake is returned by PostNewOrder for a PostOnly order crossing the book
+var ErrPostOnlyWouldTake = errors.New("post-only order would take liquidity")
+
+// ErrInvalidDump is returned by ParseDump for text that isn't a dump
+var ErrInvalidDump = errors.New("invalid request dump")
+
+// Sentinels matched by an *APIError carrying the corresponding ErrorCode
+var (
+	ErrInvalidSignature    = errors.New("invalid signature")
+	ErrInvalidNonce        = errors.New("invalid nonce")
+	ErrInsufficientBalance = errors.New("insufficient balance")
+	ErrMarketUnavailable   = errors.New("market not available")
+	ErrOrderNotFound       = errors.New("order not found")
+	// ErrInvalidPrecision matches amounts and prices violating the market precision
+	ErrInvalidPrecision = errors.New("invalid precision")
+)
+
+// ErrorCode is the errorCode of a response with success false. Codes without a constant
+// are kept as sent.
+type ErrorCode int
+
+const (
+	ErrorCodeMarketUnavailable   ErrorCode = 2
+	ErrorCodeOrderNotFound       ErrorCode = 3
+	ErrorCodeAmountPrecision     ErrorCode = 6
+	ErrorCodePricePrecision      ErrorCode = 7
+	ErrorCodeInsufficientBalance ErrorCode = 10
+	ErrorCodeInvalidSignature    ErrorCode = 1004
+	ErrorCodeInvalidNonce        ErrorCode = 1006
+)
+
+// errorCodeSentinels maps the codes to the sentinel an *APIError matches with errors.Is
+var errorCodeSentinels = map[ErrorCode]error{
+	ErrorCodeMarketUnavailable:   ErrMarketUnavailable,
+	ErrorCodeOrderNotFound:       ErrOrderNotFound,
+	ErrorCodeAmountPrecision:     ErrInvalidPrecision,
+	ErrorCodePricePrecision:      ErrInvalidPrecision,
+	ErrorCodeInsufficientBalance: ErrInsufficientBalance,
+	ErrorCodeInvalidSignature:    ErrInvalidSignature,
+	ErrorCodeInvalidNonce:        ErrInvalidNonce,
+}
+
+// UnmarshalJSON accepts the code as number or string, null and "" being zero
+func (c *ErrorCode) UnmarshalJSON(data []byte) error {
+	var n flexNumber
+	if err := n.UnmarshalJSON(data); err != nil {
+		return fmt.Errorf("error code: %v", err)
+	}
+	code, err := n.Int64()
+	if err != nil {
+		return fmt.Errorf("error code: %v", err)
+	}
+	*c = ErrorCode(code)
+	return nil
+}
+
+// APIError is returned for a response with success false
+type APIError struct {
+	// Code is zero when the response had no errorCode
+	Code    ErrorCode
+	Message string
+}
+
+func (e *APIError) Error() string {
+	if e.Code == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// Is matches the sentinel of the error code, so errors.Is(err, ErrInsufficientBalance)
+// works as well as comparing Code
+func (e *APIError) Is(target error) bool {
+	sentinel, ok := errorCodeSentinels[e.Code]
+	return ok && sentinel == target
+}
+
+// StatusError is returned when the server answers with an unexpected HTTP status
+type StatusError struct {
+	StatusCode int
+	Expected   []int
+	Body       string
+	// RetryAfter is the wait given by the server with a 429 status, zero when it didn't tell
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("http response status != %+v, got %d: %s\n", e.Expected, e.StatusCode, e.Body)
+}
+
+// MaintenanceError is returned while the exchange is under maintenance
+type MaintenanceError struct {
+	// RetryAfter is the wait suggested by the server, zero when it didn't tell
+	RetryAfter time.Duration
+	Body       string
+}
+
+func (e *MaintenanceError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("%s, retry after %s", ErrMaintenance, e.RetryAfter)
+	}
+	return ErrMaintenance.Error()
+}
+
+func (e *MaintenanceError) Is(target error) bool {
+	return target == ErrMaintenance
+}
+
+// IPBannedError is returned when the exchange bans the IP of the client for abuse.
+// Retrying extends the ban, so requests are not retried and the client fails every
+// request with it until RetryAfter has passed.
+type IPBannedError struct {
+	// RetryAfter is the remaining ban given by the server, zero when it didn't tell
+	RetryAfter time.Duration
+	Body       string
+}
+
+func (e *IPBannedError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("%s, retry after %s", ErrIPBanned, e.RetryAfter)
+	}
+	return ErrIPBanned.Error()
+}
+
+func (e *IPBannedError) Is(target error) bool {
+	return target == ErrIPBanned
+}
+
+// checkResponse turns maintenance and ban responses and unexpected statuses into errors
+func checkResponse(resp *response, body []byte) error {
+	if resp.StatusCode == http.StatusServiceUnavailable || isMaintenanceBody(body) {
+		return &MaintenanceError{RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")), Body: string(body)}
+	}
+	if resp.StatusCode == http.StatusForbidden && isBanBody(body) {
+		return &IPBannedError{RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")), Body: string(body)}
+	}
+	if err := checkHTTPStatus(*resp, http.StatusOK); err != nil {
+		statusErr := &StatusError{StatusCode: resp.StatusCode, Expected: []int{http.StatusOK}, Body: string(body)}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			statusErr.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+		}
+		return statusErr
+	}
+	return nil
+}
+
+// decodeError is returned for a response body that doesn't decode into the result type
+type decodeError struct {
+	err error
+}
+
+func (e *decodeError) Error() string { return e.err.Error() }
+
+func (e *decodeError) Unwrap() error { return e.err }
+
+func isDecodeError(err error) bool {
+	var decode *decodeError
+	return errors.As(err, &decode)
+}
+
+func isMaintenanceBody(body []byte) bool {
+	var r Response
+	if json.Unmarshal(body, &r) != nil || r.Success {
+		return false
+	}
+	return strings.Contains(strings.ToLower(r.Message), "maintenance")
+}
+
+// isBanBody reports whether a 403 body is a ban rather than, for example, a rejected API key
+func isBanBody(body []byte) bool {
+	text := strings.ToLower(string(body))
+	var r Response
+	if json.Unmarshal(body, &r) == nil {
+		text = strings.ToLower(r.Message)
+	}
+	return strings.Contains(text, "ban") || strings.Contains(text, "blocked")
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if d := time.Until(at); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
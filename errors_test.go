@@ -0,0 +1,147 @@
+package gop2b_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sutapurachina/gop2b"
+	"github.com/sutapurachina/gop2b/gop2btest"
+)
+
+func fixture(t *testing.T, name string) string {
+	t.Helper()
+	body, err := gop2btest.Fixture(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func TestMaintenance(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+	}{
+		{"503", http.StatusServiceUnavailable},
+		// some maintenance pages come with a 200 and success false
+		{"200", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := newTestClient(t)
+			server.SetError("/public/markets", tt.status, fixture(t, "error_maintenance.json"))
+			_, err := client.GetMarkets(context.Background())
+			if !errors.Is(err, gop2b.ErrMaintenance) {
+				t.Fatalf("error %v, want ErrMaintenance", err)
+			}
+			var maintenance *gop2b.MaintenanceError
+			if !errors.As(err, &maintenance) || maintenance.RetryAfter != 0 {
+				t.Errorf("error %#v, want a *MaintenanceError without RetryAfter", err)
+			}
+		})
+	}
+}
+
+func TestMaintenanceRetryAfter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = io.WriteString(w, "<html>maintenance</html>")
+	}))
+	defer server.Close()
+	client, err := gop2b.NewClient("", "", gop2b.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.GetMarkets(context.Background())
+	var maintenance *gop2b.MaintenanceError
+	if !errors.As(err, &maintenance) {
+		t.Fatalf("error %v, want a *MaintenanceError", err)
+	}
+	if maintenance.RetryAfter != 2*time.Minute {
+		t.Errorf("retry after %s, want 2m0s", maintenance.RetryAfter)
+	}
+}
+
+func TestErrorsAreNotMaintenance(t *testing.T) {
+	client, server := newTestClient(t)
+	server.SetError("/public/markets", http.StatusOK, fixture(t, "error_invalid_market.json"))
+	resp, err := client.GetMarkets(context.Background())
+	if err == nil {
+		err = resp.Err()
+	}
+	if err == nil || errors.Is(err, gop2b.ErrMaintenance) {
+		t.Fatalf("error %v, want an error other than ErrMaintenance", err)
+	}
+}
+
+func TestIPBanned(t *testing.T) {
+	var requests atomic.Int32
+	var banned atomic.Bool
+	banned.Store(true)
+	markets := fixture(t, "public_markets.json")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if banned.Load() {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = io.WriteString(w, `{"success":false,"message":"Your IP is banned","result":[]}`)
+			return
+		}
+		_, _ = io.WriteString(w, markets)
+	}))
+	defer server.Close()
+	client, err := gop2b.NewClient("", "", gop2b.WithBaseURL(server.URL), gop2b.WithRetry(3, time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.GetMarkets(context.Background())
+	var ban *gop2b.IPBannedError
+	if !errors.As(err, &ban) || !errors.Is(err, gop2b.ErrIPBanned) {
+		t.Fatalf("error %v, want an *IPBannedError", err)
+	}
+	if ban.RetryAfter != time.Second {
+		t.Errorf("retry after %s, want 1s", ban.RetryAfter)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("%d requests, want 1: a ban must not be retried", n)
+	}
+
+	// the ban is lifted on the server, but the client holds back until RetryAfter
+	banned.Store(false)
+	if _, err := client.GetMarkets(context.Background()); !errors.Is(err, gop2b.ErrIPBanned) {
+		t.Errorf("error %v during the ban, want ErrIPBanned", err)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("%d requests, want none sent during the ban", n)
+	}
+
+	time.Sleep(ban.RetryAfter + 100*time.Millisecond)
+	resp, err := client.GetMarkets(context.Background())
+	if err != nil || !resp.Success {
+		t.Fatalf("after the ban: %v, %+v", err, resp)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("%d requests, want 2", n)
+	}
+}
+
+func TestForbiddenIsNotBan(t *testing.T) {
+	client, server := newTestClient(t)
+	server.SetError("/public/markets", http.StatusForbidden, fixture(t, "error_unauthorized.json"))
+	_, err := client.GetMarkets(context.Background())
+	var status *gop2b.StatusError
+	if errors.Is(err, gop2b.ErrIPBanned) || !errors.As(err, &status) || status.StatusCode != http.StatusForbidden {
+		t.Fatalf("error %v, want a 403 *StatusError", err)
+	}
+	if _, err := client.GetMarkets(context.Background()); errors.Is(err, gop2b.ErrIPBanned) {
+		t.Errorf("error %v, a rejected request must not block the next ones", err)
+	}
+}
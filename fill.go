@@ -0,0 +1,105 @@
+package gop2b
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// FeeSchedule holds the fee rates of an account, 0.002 being 0.2%
+type FeeSchedule struct {
+	Maker decimal.Decimal
+	Taker decimal.Decimal
+}
+
+// FillEstimate is the expected outcome of a marketable order walking the book.
+// Money values are in the quote currency, fees included at the taker rate.
+type FillEstimate struct {
+	Side Side
+	// Amount is the stock amount the book can fill
+	Amount decimal.Decimal
+	// AveragePrice is Cost / Amount
+	AveragePrice decimal.Decimal
+	BestPrice    decimal.Decimal
+	WorstPrice   decimal.Decimal
+	// Cost is the money exchanged before fees
+	Cost decimal.Decimal
+	Fee  decimal.Decimal
+	// Total is Cost plus Fee for buys and Cost minus Fee for sells
+	Total decimal.Decimal
+	// Slippage is the adverse relative distance of AveragePrice from BestPrice, 0.01 being 1%
+	Slippage decimal.Decimal
+	// Levels is the amount of book levels consumed
+	Levels int
+}
+
+// InsufficientDepthError is returned when the book can't fill the whole amount
+type InsufficientDepthError struct {
+	Requested decimal.Decimal
+	Available decimal.Decimal
+}
+
+func (e *InsufficientDepthError) Error() string {
+	return fmt.Sprintf("insufficient depth: requested %s, available %s", e.Requested, e.Available)
+}
+
+// EstimateFillCost walks the levels of book to estimate the cost of a marketable order of
+// amount stock on side. When the book is too thin it returns the estimate of what could be
+// filled together with an *InsufficientDepthError. A level with a price that isn't positive
+// fails the estimate, as the book can't be trusted.
+func EstimateFillCost(book DepthSnapshot, side Side, amount decimal.Decimal, fee FeeSchedule) (*FillEstimate, error) {
+	if !amount.IsPositive() {
+		return nil, errors.New("amount must be positive")
+	}
+	var levels []PriceLevel
+	switch side {
+	case SideBuy:
+		levels = book.Asks
+	case SideSell:
+		levels = book.Bids
+	default:
+		return nil, fmt.Errorf("invalid side %q", side)
+	}
+
+	estimate := &FillEstimate{Side: side}
+	remaining := amount
+	for _, level := range levels {
+		if !remaining.IsPositive() {
+			break
+		}
+		if !level.Amount.IsPositive() {
+			continue
+		}
+		if !level.Price.IsPositive() {
+			return nil, fmt.Errorf("invalid price %s in the book", level.Price)
+		}
+		take := decimal.Min(remaining, level.Amount)
+		if estimate.Levels == 0 {
+			estimate.BestPrice = level.Price
+		}
+		estimate.WorstPrice = level.Price
+		estimate.Amount = estimate.Amount.Add(take)
+		estimate.Cost = estimate.Cost.Add(take.Mul(level.Price))
+		estimate.Levels++
+		remaining = remaining.Sub(take)
+	}
+
+	if estimate.Amount.IsPositive() {
+		estimate.AveragePrice = estimate.Cost.Div(estimate.Amount)
+		estimate.Slippage = estimate.AveragePrice.Sub(estimate.BestPrice).Div(estimate.BestPrice)
+		if side == SideSell {
+			estimate.Slippage = estimate.Slippage.Neg()
+		}
+	}
+	estimate.Fee = estimate.Cost.Mul(fee.Taker)
+	if side == SideBuy {
+		estimate.Total = estimate.Cost.Add(estimate.Fee)
+	} else {
+		estimate.Total = estimate.Cost.Sub(estimate.Fee)
+	}
+	if remaining.IsPositive() {
+		return estimate, &InsufficientDepthError{Requested: amount, Available: estimate.Amount}
+	}
+	return estimate, nil
+}
@@ -0,0 +1,52 @@
+package gop2b_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/sutapurachina/gop2b"
+)
+
+func TestEstimateFillCost(t *testing.T) {
+	book := gop2b.DepthSnapshot{
+		Asks: []gop2b.PriceLevel{level("100", "1"), level("101", "2")},
+		Bids: []gop2b.PriceLevel{level("99", "1"), level("98", "2")},
+	}
+	fee := gop2b.FeeSchedule{Taker: decimal.RequireFromString("0.002")}
+	estimate, err := gop2b.EstimateFillCost(book, gop2b.SideBuy, decimal.NewFromInt(2), fee)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkDecimal(t, "cost", estimate.Cost, "201")
+	checkDecimal(t, "average price", estimate.AveragePrice, "100.5")
+	checkDecimal(t, "slippage", estimate.Slippage, "0.005")
+	checkDecimal(t, "total", estimate.Total, "201.402")
+
+	_, err = gop2b.EstimateFillCost(book, gop2b.SideSell, decimal.NewFromInt(4), fee)
+	var depthErr *gop2b.InsufficientDepthError
+	if !errors.As(err, &depthErr) {
+		t.Fatalf("error %v, want an *InsufficientDepthError", err)
+	}
+	checkDecimal(t, "available", depthErr.Available, "3")
+}
+
+func TestEstimateFillCostZeroPrice(t *testing.T) {
+	for _, side := range []gop2b.Side{gop2b.SideBuy, gop2b.SideSell} {
+		book := gop2b.DepthSnapshot{
+			Asks: []gop2b.PriceLevel{level("0", "1"), level("101", "2")},
+			Bids: []gop2b.PriceLevel{level("0", "1"), level("98", "2")},
+		}
+		estimate, err := gop2b.EstimateFillCost(book, side, decimal.NewFromInt(2), gop2b.FeeSchedule{})
+		if err == nil {
+			t.Errorf("%s: estimate %+v for a book priced at 0, want an error", side, estimate)
+		}
+	}
+	// a level without amount is skipped whatever its price
+	book := gop2b.DepthSnapshot{Asks: []gop2b.PriceLevel{level("0", "0"), level("101", "2")}}
+	estimate, err := gop2b.EstimateFillCost(book, gop2b.SideBuy, decimal.NewFromInt(1), gop2b.FeeSchedule{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkDecimal(t, "best price", estimate.BestPrice, "101")
+}
@@ -0,0 +1,155 @@
+package gop2b
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// errorFixtures are added as seeds of every decoder, they must fail cleanly
+var errorFixtures = []string{
+	"error_invalid_market.json",
+	"error_maintenance.json",
+	"error_too_many_requests.json",
+	"error_unauthorized.json",
+}
+
+// readFixture reads a golden response of gop2btest, which can't be imported from here
+func readFixture(f *testing.F, name string) []byte {
+	f.Helper()
+	data, err := os.ReadFile(filepath.Join("gop2btest", "fixtures", name))
+	if err != nil {
+		f.Fatal(err)
+	}
+	return data
+}
+
+// fuzzResponse fuzzes decodeResponse into a T, seeded with fixtures and the error fixtures.
+// A decoded value must encode again.
+func fuzzResponse[T any](f *testing.F, fixtures ...string) {
+	for _, name := range append(fixtures, errorFixtures...) {
+		f.Add(readFixture(f, name))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var v T
+		if decodeResponse(data, &v) != nil {
+			return
+		}
+		if _, err := json.Marshal(v); err != nil {
+			t.Fatalf("decoded %q but can't encode it: %v", data, err)
+		}
+	})
+}
+
+func FuzzDecodeTicker(f *testing.F) {
+	fuzzResponse[TickerResp](f, "public_ticker.json")
+}
+
+func FuzzDecodeTickers(f *testing.F) {
+	fuzzResponse[TickersResp](f, "public_tickers.json")
+}
+
+func FuzzDecodeDepth(f *testing.F) {
+	fuzzResponse[DepthResp](f, "public_depth_result.json")
+}
+
+func FuzzDecodeMarkets(f *testing.F) {
+	fuzzResponse[MarketsResp](f, "public_markets.json")
+}
+
+func FuzzDecodeHistory(f *testing.F) {
+	fuzzResponse[HistoryResp](f, "public_history.json")
+}
+
+func FuzzDecodeKlines(f *testing.F) {
+	fuzzResponse[KlinesResp](f, "public_market_kline.json")
+}
+
+func FuzzDecodeNewOrder(f *testing.F) {
+	fuzzResponse[NewOrderResp](f, "order_new.json")
+}
+
+func FuzzDecodeOpenOrders(f *testing.F) {
+	fuzzResponse[OpenOrdersResp](f, "orders.json")
+}
+
+func FuzzDecodeOrderHistory(f *testing.F) {
+	fuzzResponse[OrderHistoryResp](f, "account_order_history.json")
+}
+
+func FuzzDecodeBalances(f *testing.F) {
+	fuzzResponse[AccountBalancesResp](f, "account_balances.json")
+}
+
+func FuzzDecodeBalance(f *testing.F) {
+	fuzzResponse[AccountCurrencyBalanceResp](f, "account_balance.json")
+}
+
+func FuzzDecodeBestQuotes(f *testing.F) {
+	f.Add(readFixture(f, "public_depth_result.json"))
+	for _, name := range errorFixtures {
+		f.Add(readFixture(f, name))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		bid, ask, err := decodeBestQuotes(bytes.NewReader(data))
+		if err != nil {
+			return
+		}
+		if bid.IsNegative() || ask.IsNegative() {
+			t.Fatalf("negative best quotes %s/%s from %q", bid, ask, data)
+		}
+	})
+}
+
+// FuzzDecodeDepthFrame fuzzes a websocket depth notification, seeded with the levels of the
+// depth fixture as a full snapshot and as a diff
+func FuzzDecodeDepthFrame(f *testing.F) {
+	var resp struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(readFixture(f, "public_depth_result.json"), &resp); err != nil {
+		f.Fatal(err)
+	}
+	for _, full := range []string{"true", "false"} {
+		f.Add([]byte(`{"method":"depth.update","params":[` + full + `,` + string(resp.Result) + `,"ETH_BTC"],"id":null}`))
+	}
+	f.Add([]byte(`{"method":"depth.update","params":[false,{"asks":[["0.1","0"]],"update_id":7},"ETH_BTC"]}`))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var frame wsFrame
+		if json.Unmarshal(data, &frame) != nil {
+			return
+		}
+		update, err := decodeDepthUpdate(frame.Params)
+		if err != nil {
+			return
+		}
+		if _, err := json.Marshal(update); err != nil {
+			t.Fatalf("decoded %q but can't encode it: %v", data, err)
+		}
+	})
+}
+
+func FuzzDecodeDealsFrame(f *testing.F) {
+	var resp struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(readFixture(f, "public_history.json"), &resp); err != nil {
+		f.Fatal(err)
+	}
+	f.Add([]byte(`{"method":"deals.update","params":["ETH_BTC",` + string(resp.Result) + `],"id":null}`))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var frame wsFrame
+		if json.Unmarshal(data, &frame) != nil {
+			return
+		}
+		update, err := decodeDealsUpdate(frame.Params)
+		if err != nil {
+			return
+		}
+		if _, err := json.Marshal(update); err != nil {
+			t.Fatalf("decoded %q but can't encode it: %v", data, err)
+		}
+	})
+}
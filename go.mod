@@ -2,4 +2,10 @@ module github.com/sutapurachina/gop2b
 
 go 1.23.2
 
-require github.com/shopspring/decimal v1.4.0
+require (
+	github.com/gorilla/websocket v1.5.3
+	github.com/shopspring/decimal v1.4.0
+	go.uber.org/goleak v1.3.0
+)
+
+require github.com/stretchr/testify v1.9.0 // indirect
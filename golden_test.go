@@ -0,0 +1,299 @@
+package gop2b_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sutapurachina/gop2b"
+	"github.com/sutapurachina/gop2b/gop2btest"
+)
+
+// decodeGolden decodes body into a T, failing the test on error
+func decodeGolden[T any](t *testing.T, body []byte) T {
+	t.Helper()
+	var v T
+	if err := json.Unmarshal(body, &v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+// checkTime fails the test when got isn't want, within the float precision of the exchange timestamps
+func checkTime(t *testing.T, name string, got, want time.Time) {
+	t.Helper()
+	if got.Sub(want).Abs() > time.Microsecond {
+		t.Errorf("%s %s, want %s", name, got.UTC(), want.UTC())
+	}
+}
+
+// checkDecimals compares decimals pairwise with checkDecimal, named by field
+func checkDecimals(t *testing.T, prefix string, fields map[string]decimal.Decimal, want map[string]string) {
+	t.Helper()
+	for name, w := range want {
+		checkDecimal(t, prefix+name, fields[name], w)
+	}
+}
+
+// checkEnvelope checks the fields every successful fixture shares
+func checkEnvelope(t *testing.T, r gop2b.Response) {
+	t.Helper()
+	if !r.Success || r.Message != "" || r.Err() != nil {
+		t.Errorf("response %+v, want success", r)
+	}
+	if r.CacheTime != 1700000000.1 || r.CurrentTime != 1700000000.2 {
+		t.Errorf("cache time %v, current time %v", r.CacheTime, r.CurrentTime)
+	}
+}
+
+func orderDecimals(o gop2b.Order) map[string]decimal.Decimal {
+	return map[string]decimal.Decimal{
+		"price": o.Price, "amount": o.Amount, "left": o.Left, "dealStock": o.DealStock,
+		"dealMoney": o.DealMoney, "dealFee": o.DealFee, "takerFee": o.TakerFee, "makerFee": o.MakerFee,
+	}
+}
+
+func tickerDecimals(tk gop2b.Ticker) map[string]decimal.Decimal {
+	return map[string]decimal.Decimal{
+		"bid": tk.Bid, "ask": tk.Ask, "open": tk.Open, "low": tk.Low, "high": tk.High,
+		"last": tk.Last, "volume": tk.Volume, "deal": tk.Deal,
+	}
+}
+
+// TestGoldenFixtures decodes every golden fixture into its response type and checks the
+// values exactly, decimals against their literal and timestamps against fixed times
+func TestGoldenFixtures(t *testing.T) {
+	tests := []struct {
+		fixture string
+		check   func(t *testing.T, body []byte)
+	}{
+		{"account_balance.json", func(t *testing.T, body []byte) {
+			resp := decodeGolden[gop2b.AccountCurrencyBalanceResp](t, body)
+			checkEnvelope(t, resp.Response)
+			checkDecimal(t, "available", resp.Result.Available, "0.5")
+			checkDecimal(t, "freeze", resp.Result.Freeze, "0.04125")
+		}},
+		{"account_balances.json", func(t *testing.T, body []byte) {
+			resp := decodeGolden[gop2b.AccountBalancesResp](t, body)
+			checkEnvelope(t, resp.Response)
+			want := map[gop2b.Currency][2]string{"BTC": {"0.5", "0.04125"}, "ETH": {"3.2", "0"}, "USDT": {"1500.25", "0"}}
+			if len(resp.Result) != len(want) {
+				t.Fatalf("%d balances, want %d", len(resp.Result), len(want))
+			}
+			for currency, w := range want {
+				checkDecimal(t, string(currency)+" available", resp.Result[currency].Available, w[0])
+				checkDecimal(t, string(currency)+" freeze", resp.Result[currency].Freeze, w[1])
+			}
+		}},
+		{"account_order_history.json", func(t *testing.T, body []byte) {
+			resp := decodeGolden[gop2b.OrderHistoryResp](t, body)
+			checkEnvelope(t, resp.Response)
+			orders := resp.Result["ETH_BTC"]
+			if len(resp.Result) != 1 || len(orders) != 2 {
+				t.Fatalf("order history %+v, want 2 orders of ETH_BTC", resp.Result)
+			}
+			o := orders[0]
+			if o.ID != 25700 || o.Market != "ETH_BTC" || o.Side != gop2b.SideSell || o.Type != gop2b.OrderTypeLimit {
+				t.Errorf("order %+v", o)
+			}
+			checkDecimals(t, "order 25700 ", orderDecimals(o), map[string]string{
+				"price": "0.054", "amount": "1", "dealStock": "1", "dealMoney": "0.054",
+				"dealFee": "0.000108", "takerFee": "0.002", "makerFee": "0.002",
+			})
+			checkTime(t, "created", o.CreatedAt, time.Unix(1699990000, 100000000))
+			checkTime(t, "finished", o.FinishedAt, time.Unix(1699990100, 200000000))
+			o = orders[1]
+			if o.ID != 25701 || o.Side != gop2b.SideBuy {
+				t.Errorf("order %+v", o)
+			}
+			checkDecimals(t, "order 25701 ", orderDecimals(o), map[string]string{
+				"price": "0.05", "amount": "2", "dealStock": "0", "dealMoney": "0", "dealFee": "0",
+			})
+			checkTime(t, "created", o.CreatedAt, time.Unix(1699991000, 100000000))
+			checkTime(t, "finished", o.FinishedAt, time.Unix(1699991500, 200000000))
+		}},
+		{"order_new.json", func(t *testing.T, body []byte) {
+			resp := decodeGolden[gop2b.NewOrderResp](t, body)
+			checkEnvelope(t, resp.Response)
+			o := resp.Result
+			if o.ID != 25749 || o.Market != "ETH_BTC" || o.Side != gop2b.SideBuy || o.Type != gop2b.OrderTypeLimit {
+				t.Errorf("order %+v", o)
+			}
+			checkDecimals(t, "", orderDecimals(o), map[string]string{
+				"price": "0.055", "amount": "1", "left": "1", "dealStock": "0", "dealMoney": "0",
+				"dealFee": "0", "takerFee": "0.002", "makerFee": "0.002",
+			})
+			checkTime(t, "created", o.CreatedAt, time.Unix(1700000000, 300000000))
+			if !o.FinishedAt.IsZero() {
+				t.Errorf("finished at %s, want zero", o.FinishedAt)
+			}
+		}},
+		{"orders.json", func(t *testing.T, body []byte) {
+			resp := decodeGolden[gop2b.OpenOrdersResp](t, body)
+			checkEnvelope(t, resp.Response)
+			page := resp.Result
+			if page.Limit != 100 || page.Offset != 0 || page.Total != 1 || len(page.Records) != 1 {
+				t.Fatalf("page %+v", page)
+			}
+			o := page.Records[0]
+			if o.ID != 25749 || o.Market != "ETH_BTC" || o.Side != gop2b.SideBuy {
+				t.Errorf("order %+v", o)
+			}
+			checkDecimals(t, "", orderDecimals(o), map[string]string{
+				"price": "0.055", "amount": "1", "left": "0.75", "dealStock": "0.25",
+				"dealMoney": "0.01375", "dealFee": "0.0000275",
+			})
+			checkTime(t, "created", o.CreatedAt, time.Unix(1700000000, 300000000))
+		}},
+		{"public_depth_result.json", func(t *testing.T, body []byte) {
+			resp := decodeGolden[gop2b.DepthResp](t, body)
+			checkEnvelope(t, resp.Response)
+			want := map[string][][2]string{
+				"ask": {{"0.0551", "2.5"}, {"0.0552", "4"}, {"0.0555", "10"}},
+				"bid": {{"0.0549", "1.5"}, {"0.0548", "3"}, {"0.0545", "12"}},
+			}
+			for side, levels := range map[string][]gop2b.PriceLevel{"ask": resp.Result.Asks, "bid": resp.Result.Bids} {
+				if len(levels) != len(want[side]) {
+					t.Fatalf("%d %s levels, want %d", len(levels), side, len(want[side]))
+				}
+				for i, w := range want[side] {
+					checkDecimal(t, side+" price", levels[i].Price, w[0])
+					checkDecimal(t, side+" amount", levels[i].Amount, w[1])
+				}
+			}
+		}},
+		{"public_history.json", func(t *testing.T, body []byte) {
+			resp := decodeGolden[gop2b.HistoryResp](t, body)
+			checkEnvelope(t, resp.Response)
+			want := []struct {
+				id            int64
+				time          float64
+				price, amount string
+				side          gop2b.Side
+			}{
+				{1001, 1699999990.5, "0.055", "0.5", gop2b.SideBuy},
+				{1002, 1699999995.25, "0.0549", "1.25", gop2b.SideSell},
+			}
+			if len(resp.Result) != len(want) {
+				t.Fatalf("%d trades, want %d", len(resp.Result), len(want))
+			}
+			for i, w := range want {
+				trade := resp.Result[i]
+				if trade.ID != w.id || trade.Time != w.time || trade.Type != w.side {
+					t.Errorf("trade %+v, want id %d at %v on %s", trade, w.id, w.time, w.side)
+				}
+				checkDecimal(t, "price", trade.Price, w.price)
+				checkDecimal(t, "amount", trade.Amount, w.amount)
+			}
+		}},
+		{"public_market_kline.json", func(t *testing.T, body []byte) {
+			resp := decodeGolden[gop2b.KlinesResp](t, body)
+			checkEnvelope(t, resp.Response)
+			want := [][]string{
+				{"0.0548", "0.0549", "0.055", "0.0547", "12.5", "0.686"},
+				{"0.0549", "0.055", "0.0551", "0.0548", "8", "0.44"},
+				{"0.055", "0.055", "0.0551", "0.0549", "3.25", "0.17875"},
+			}
+			if len(resp.Result) != len(want) {
+				t.Fatalf("%d candles, want %d", len(resp.Result), len(want))
+			}
+			for i, w := range want {
+				k := resp.Result[i]
+				checkTime(t, "candle time", k.Time, time.Unix(1699992000+int64(i)*3600, 0))
+				if k.Market != "ETH_BTC" {
+					t.Errorf("candle market %q", k.Market)
+				}
+				for j, got := range []decimal.Decimal{k.Open, k.Close, k.High, k.Low, k.Volume, k.Deal} {
+					checkDecimal(t, "candle value", got, w[j])
+				}
+			}
+		}},
+		{"public_markets.json", func(t *testing.T, body []byte) {
+			resp := decodeGolden[gop2b.MarketsResp](t, body)
+			checkEnvelope(t, resp.Response)
+			if len(resp.Result) != 3 {
+				t.Fatalf("%d markets, want 3", len(resp.Result))
+			}
+			m := resp.Result[0]
+			if m.Name != "ETH_BTC" || m.Stock != "ETH" || m.Money != "BTC" {
+				t.Errorf("market %+v", m)
+			}
+			if m.Precision != (gop2b.MarketPrecision{Money: 6, Stock: 3, Fee: 4}) {
+				t.Errorf("precision %+v", m.Precision)
+			}
+			checkDecimals(t, "", map[string]decimal.Decimal{
+				"min_amount": m.Limits.MinAmount, "max_amount": m.Limits.MaxAmount, "step_size": m.Limits.StepSize,
+				"min_price": m.Limits.MinPrice, "max_price": m.Limits.MaxPrice, "tick_size": m.Limits.TickSize,
+				"min_total": m.Limits.MinTotal,
+			}, map[string]string{
+				"min_amount": "0.001", "max_amount": "100000", "step_size": "0.001", "min_price": "0.000001",
+				"max_price": "100000", "tick_size": "0.000001", "min_total": "0.0001",
+			})
+			names := []gop2b.Market{resp.Result[1].Name, resp.Result[2].Name}
+			if names[0] != "BTC_USDT" || names[1] != "ETH_USDT" {
+				t.Errorf("markets %v", names)
+			}
+		}},
+		{"public_ticker.json", func(t *testing.T, body []byte) {
+			resp := decodeGolden[gop2b.TickerResp](t, body)
+			checkEnvelope(t, resp.Response)
+			checkDecimals(t, "", tickerDecimals(resp.Result), map[string]string{
+				"bid": "0.0549", "ask": "0.0551", "open": "0.0545", "low": "0.054", "high": "0.0555",
+				"last": "0.055", "volume": "1250.5", "deal": "68.7775",
+			})
+		}},
+		{"public_tickers.json", func(t *testing.T, body []byte) {
+			resp := decodeGolden[gop2b.TickersResp](t, body)
+			checkEnvelope(t, resp.Response)
+			want := map[string]map[string]string{
+				"ETH_BTC":  {"bid": "0.0549", "ask": "0.0551", "last": "0.055", "volume": "1250.5", "deal": "68.7775"},
+				"BTC_USDT": {"bid": "36990.5", "ask": "37010.5", "last": "37000", "volume": "85.25", "deal": "3154250"},
+				"ETH_USDT": {"bid": "2034", "ask": "2036", "last": "2035", "volume": "910.75", "deal": "1853376.25"},
+			}
+			if len(resp.Result) != len(want) {
+				t.Fatalf("%d tickers, want %d", len(resp.Result), len(want))
+			}
+			for market, w := range want {
+				entry := resp.Result[market]
+				checkTime(t, market+" at", entry.At.Time, time.Unix(1700000000, 0))
+				checkDecimals(t, market+" ", tickerDecimals(entry.Ticker), w)
+			}
+		}},
+		{"error_invalid_market.json", checkGoldenError("Market is not available.")},
+		{"error_maintenance.json", checkGoldenError("Service is under maintenance")},
+		{"error_too_many_requests.json", checkGoldenError("Too many requests")},
+		{"error_unauthorized.json", checkGoldenError("Authentication failed")},
+	}
+	tested := make(map[string]bool)
+	for _, tt := range tests {
+		tested[tt.fixture] = true
+		t.Run(tt.fixture, func(t *testing.T) {
+			body, err := gop2btest.Fixture(tt.fixture)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, body)
+		})
+	}
+	for _, name := range gop2btest.Fixtures() {
+		if !tested[name] {
+			t.Errorf("fixture %s has no golden decode test", name)
+		}
+	}
+}
+
+// checkGoldenError returns the check of an error shape with message
+func checkGoldenError(message string) func(t *testing.T, body []byte) {
+	return func(t *testing.T, body []byte) {
+		resp := decodeGolden[gop2b.Envelope[json.RawMessage]](t, body)
+		if resp.Success || resp.Message != message {
+			t.Errorf("response %+v, want failure %q", resp.Response, message)
+		}
+		var apiErr *gop2b.APIError
+		if err := resp.Err(); !errors.As(err, &apiErr) || apiErr.Message != message {
+			t.Errorf("error %v, want an *APIError with %q", err, message)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+module github.com/sutapurachina/gop2b/gop2bprom
+
+go 1.23.2
+
+require (
+	github.com/prometheus/client_golang v1.20.5
+	github.com/sutapurachina/gop2b v0.0.0-00010101000000-000000000000
+)
+
+require (
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/gorilla/websocket v1.5.3 // indirect
+	github.com/kylelemons/godebug v1.1.0 // indirect
+	github.com/munnerz/goautoneg v0.0.0-20191010083416-a7dc8b61c822 // indirect
+	github.com/prometheus/client_model v0.6.1 // indirect
+	github.com/prometheus/common v0.55.0 // indirect
+	github.com/prometheus/procfs v0.15.1 // indirect
+	github.com/shopspring/decimal v1.4.0 // indirect
+	golang.org/x/sys v0.22.0 // indirect
+	google.golang.org/protobuf v1.34.2 // indirect
+)
+
+// the adapter is developed along the SDK, drop the replace when tagging a release
+replace github.com/sutapurachina/gop2b => ../
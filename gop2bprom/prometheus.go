@@ -0,0 +1,145 @@
+// Package gop2bprom exports the gop2b client metrics to Prometheus
+package gop2bprom
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sutapurachina/gop2b"
+)
+
+const namespace = "gop2b"
+
+// DefaultLatencyBuckets are the request latency buckets in seconds, 1ms to 5s
+var DefaultLatencyBuckets = []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5}
+
+type config struct {
+	latencyBuckets []float64
+}
+
+// CollectorOption configures a Collector
+type CollectorOption func(*config)
+
+// WithLatencyBuckets sets the buckets in seconds of the request latency histogram,
+// DefaultLatencyBuckets when empty
+func WithLatencyBuckets(buckets []float64) CollectorOption {
+	return func(cfg *config) {
+		if len(buckets) > 0 {
+			cfg.latencyBuckets = append([]float64(nil), buckets...)
+		}
+	}
+}
+
+// Collector is a gop2b.MetricsCollector backed by Prometheus metrics:
+//   - gop2b_requests_total, requests by endpoint and status ("0" when no response arrived)
+//   - gop2b_request_duration_seconds, request latency by endpoint
+//   - gop2b_rate_limit_wait_seconds, time spent waiting for the client rate limiter
+//   - gop2b_rate_limit_last_wait_seconds, the last of these waits
+//   - gop2b_rate_limit_remaining, request slots of the rate limiter left in the coming period
+//   - gop2b_retries_total, retried requests by endpoint
+//   - gop2b_ws_events_total, websocket notifications by channel
+//
+// Pass it to gop2b.WithWSMetrics as well to count websocket notifications.
+// The client has no circuit breaker, so there is no breaker state gauge.
+type Collector struct {
+	requests  *prometheus.CounterVec
+	latency   *prometheus.HistogramVec
+	wait      prometheus.Histogram
+	lastWait  prometheus.Gauge
+	remaining prometheus.Gauge
+	retries   *prometheus.CounterVec
+	wsEvents  *prometheus.CounterVec
+}
+
+var _ gop2b.MetricsCollector = (*Collector)(nil)
+
+// NewCollector creates a Collector and registers its metrics with reg
+func NewCollector(reg prometheus.Registerer, opts ...CollectorOption) (*Collector, error) {
+	cfg := config{latencyBuckets: DefaultLatencyBuckets}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	c := &Collector{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "requests_total",
+			Help:      "HTTP requests sent to the exchange by endpoint and response status.",
+		}, []string{"endpoint", "status"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "request_duration_seconds",
+			Help:      "Latency of HTTP requests to the exchange by endpoint.",
+			Buckets:   cfg.latencyBuckets,
+		}, []string{"endpoint"}),
+		wait: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "rate_limit_wait_seconds",
+			Help:      "Time requests waited for a slot of the client rate limiter.",
+			Buckets:   prometheus.DefBuckets,
+		}),
+		lastWait: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "rate_limit_last_wait_seconds",
+			Help:      "Time the last request waited for a slot of the client rate limiter.",
+		}),
+		remaining: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "rate_limit_remaining",
+			Help:      "Request slots of the client rate limiter not yet taken in the coming period.",
+		}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "retries_total",
+			Help:      "Requests sent again after a failure by endpoint.",
+		}, []string{"endpoint"}),
+		wsEvents: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "ws_events_total",
+			Help:      "Websocket notifications received by channel.",
+		}, []string{"channel"}),
+	}
+	for _, m := range []prometheus.Collector{c.requests, c.latency, c.wait, c.lastWait, c.remaining, c.retries, c.wsEvents} {
+		if err := reg.Register(m); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// WithPrometheus registers a Collector with reg and returns the client option reporting to it.
+// It panics when the metrics can't be registered, like prometheus.MustRegister.
+func WithPrometheus(reg prometheus.Registerer, opts ...CollectorOption) gop2b.Option {
+	c, err := NewCollector(reg, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return gop2b.WithMetrics(c)
+}
+
+// ObserveRequest implements gop2b.MetricsCollector
+func (c *Collector) ObserveRequest(endpoint string, status int, d time.Duration) {
+	c.requests.WithLabelValues(endpoint, strconv.Itoa(status)).Inc()
+	c.latency.WithLabelValues(endpoint).Observe(d.Seconds())
+}
+
+// ObserveRateLimitWait implements gop2b.MetricsCollector
+func (c *Collector) ObserveRateLimitWait(d time.Duration) {
+	c.wait.Observe(d.Seconds())
+	c.lastWait.Set(d.Seconds())
+}
+
+// ObserveRateLimitRemaining implements gop2b.MetricsCollector
+func (c *Collector) ObserveRateLimitRemaining(remaining int) {
+	c.remaining.Set(float64(remaining))
+}
+
+// IncRetry implements gop2b.MetricsCollector
+func (c *Collector) IncRetry(endpoint string) {
+	c.retries.WithLabelValues(endpoint).Inc()
+}
+
+// ObserveWsEvent implements gop2b.MetricsCollector
+func (c *Collector) ObserveWsEvent(channel string) {
+	c.wsEvents.WithLabelValues(channel).Inc()
+}
@@ -0,0 +1,184 @@
+package gop2bprom_test
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sutapurachina/gop2b"
+	"github.com/sutapurachina/gop2b/gop2bprom"
+	"github.com/sutapurachina/gop2b/gop2btest"
+)
+
+func TestCollector(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	collector, err := gop2bprom.NewCollector(reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := gop2btest.NewServer()
+	defer server.Close()
+	client, err := server.Client(
+		gop2b.WithMetrics(collector),
+		gop2b.WithRetry(2, time.Millisecond),
+		gop2b.WithRateLimit(100, time.Second),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if _, err := client.GetMarkets(ctx); err != nil {
+		t.Fatal(err)
+	}
+	server.SetError("/public/tickers", http.StatusBadGateway, "bad gateway")
+	if _, err := client.GetTickers(ctx); err == nil {
+		t.Fatal("no error from the failing endpoint")
+	}
+
+	expected := `
+# HELP gop2b_requests_total HTTP requests sent to the exchange by endpoint and response status.
+# TYPE gop2b_requests_total counter
+gop2b_requests_total{endpoint="/public/markets",status="200"} 1
+gop2b_requests_total{endpoint="/public/tickers",status="502"} 2
+# HELP gop2b_retries_total Requests sent again after a failure by endpoint.
+# TYPE gop2b_retries_total counter
+gop2b_retries_total{endpoint="/public/tickers"} 1
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(expected), "gop2b_requests_total", "gop2b_retries_total"); err != nil {
+		t.Error(err)
+	}
+	if n, err := testutil.GatherAndCount(reg, "gop2b_request_duration_seconds"); err != nil || n != 2 {
+		t.Errorf("%d latency series, want one per endpoint: %v", n, err)
+	}
+	if n, err := testutil.GatherAndCount(reg, "gop2b_rate_limit_wait_seconds", "gop2b_rate_limit_last_wait_seconds"); err != nil || n != 2 {
+		t.Errorf("%d rate limit series, want 2: %v", n, err)
+	}
+}
+
+func TestCollectorRateLimitRemaining(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	collector, err := gop2bprom.NewCollector(reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := gop2btest.NewServer()
+	defer server.Close()
+	// a slot every 10s, so none of the requests frees one up
+	client, err := server.Client(gop2b.WithMetrics(collector), gop2b.WithRateLimit(5, 50*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRemaining := func(want string) {
+		t.Helper()
+		expected := `
+# HELP gop2b_rate_limit_remaining Request slots of the client rate limiter not yet taken in the coming period.
+# TYPE gop2b_rate_limit_remaining gauge
+gop2b_rate_limit_remaining ` + want + "\n"
+		if err := testutil.GatherAndCompare(reg, strings.NewReader(expected), "gop2b_rate_limit_remaining"); err != nil {
+			t.Error(err)
+		}
+	}
+	if _, err := client.GetMarkets(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	checkRemaining("4")
+	// the next request waits 10s for its slot and gives up
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.GetMarkets(ctx); err == nil {
+		t.Fatal("request sent without waiting for its slot")
+	}
+	// the cancelled request keeps its slot
+	checkRemaining("3")
+}
+
+// latencyBounds returns the bucket upper bounds of the request latency histogram gathered from reg
+func latencyBounds(t *testing.T, reg *prometheus.Registry) []float64 {
+	t.Helper()
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != "gop2b_request_duration_seconds" {
+			continue
+		}
+		var bounds []float64
+		for _, bucket := range family.GetMetric()[0].GetHistogram().GetBucket() {
+			bounds = append(bounds, bucket.GetUpperBound())
+		}
+		return bounds
+	}
+	t.Fatal("no request latency histogram gathered")
+	return nil
+}
+
+func TestWithLatencyBuckets(t *testing.T) {
+	tests := []struct {
+		name    string
+		buckets []float64
+		want    []float64
+	}{
+		{"custom", []float64{.01, .1, 1, 10}, []float64{.01, .1, 1, 10}},
+		{"empty", []float64{}, gop2bprom.DefaultLatencyBuckets},
+		{"nil", nil, gop2bprom.DefaultLatencyBuckets},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reg := prometheus.NewRegistry()
+			collector, err := gop2bprom.NewCollector(reg, gop2bprom.WithLatencyBuckets(tt.buckets))
+			if err != nil {
+				t.Fatal(err)
+			}
+			collector.ObserveRequest("/public/markets", http.StatusOK, 20*time.Millisecond)
+			if got := latencyBounds(t, reg); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("bucket bounds %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollectorWSEvents(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	collector, err := gop2bprom.NewCollector(reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := gop2btest.NewWsServer()
+	defer server.Close()
+	ws := gop2b.NewWSClient(gop2b.WithWSURL(server.URL), gop2b.WithWSMetrics(collector))
+	defer ws.Close()
+	if err := ws.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	server.OnSubscribe("deals", gop2btest.Notification("deals", "ETH_BTC", []interface{}{}))
+	deals, err := ws.SubscribeDeals(context.Background(), "ETH_BTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-deals
+	expected := `
+# HELP gop2b_ws_events_total Websocket notifications received by channel.
+# TYPE gop2b_ws_events_total counter
+gop2b_ws_events_total{channel="deals"} 1
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(expected), "gop2b_ws_events_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWithPrometheusRegistersOnce(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	gop2bprom.WithPrometheus(reg)
+	defer func() {
+		if recover() == nil {
+			t.Error("no panic registering the metrics twice")
+		}
+	}()
+	gop2bprom.WithPrometheus(reg)
+}
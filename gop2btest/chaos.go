@@ -0,0 +1,119 @@
+package gop2btest
+
+import (
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Chaos are the faults a Server injects into the responses of an endpoint, see SetChaos.
+// Rates are probabilities from 0 to 1, drawn independently for every request in the order
+// of the fields. Faults are drawn from a generator seeded with SetChaosSeed, so a run
+// with the same requests injects the same faults.
+type Chaos struct {
+	// MinLatency and MaxLatency delay every response by a uniformly drawn duration
+	MinLatency time.Duration
+	MaxLatency time.Duration
+	// TooManyRequestsRate answers 429 with a Retry-After of RetryAfter, in whole seconds
+	TooManyRequestsRate float64
+	RetryAfter          time.Duration
+	// ServerErrorRate answers 500
+	ServerErrorRate float64
+	// MalformedRate answers 200 with a body that isn't JSON
+	MalformedRate float64
+	// TruncateRate answers 200 with the first half of the body
+	TruncateRate float64
+}
+
+// SetChaos injects faults into the responses of path, on top of SetLatency and SetError
+func (s *Server) SetChaos(path string, chaos Chaos) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.chaos[path] = chaos
+}
+
+// ClearChaos stops injecting faults into the responses of path
+func (s *Server) ClearChaos(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.chaos, path)
+}
+
+// SetChaosSeed reseeds the generator faults are drawn from, 1 by default
+func (s *Server) SetChaosSeed(seed int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rand = rand.New(rand.NewSource(seed))
+}
+
+// chaosFault is what Chaos decided for a request
+type chaosFault struct {
+	delay      time.Duration
+	status     int
+	retryAfter time.Duration
+	malformed  bool
+	truncate   bool
+}
+
+// drawFault draws the faults of a request of path, s.mu must be held
+func (s *Server) drawFault(path string) chaosFault {
+	chaos, ok := s.chaos[path]
+	if !ok {
+		return chaosFault{}
+	}
+	var f chaosFault
+	f.delay = chaos.MinLatency
+	if spread := chaos.MaxLatency - chaos.MinLatency; spread > 0 {
+		f.delay += time.Duration(s.rand.Int63n(int64(spread) + 1))
+	}
+	roll := func(rate float64) bool {
+		return rate > 0 && s.rand.Float64() < rate
+	}
+	switch {
+	case roll(chaos.TooManyRequestsRate):
+		f.status = http.StatusTooManyRequests
+		f.retryAfter = chaos.RetryAfter
+	case roll(chaos.ServerErrorRate):
+		f.status = http.StatusInternalServerError
+	case roll(chaos.MalformedRate):
+		f.malformed = true
+	case roll(chaos.TruncateRate):
+		f.truncate = true
+	}
+	return f
+}
+
+// write writes the response of an injected error status, false when there is none
+func (f chaosFault) write(w http.ResponseWriter) bool {
+	switch f.status {
+	case http.StatusTooManyRequests:
+		if f.retryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int((f.retryAfter+time.Second-1)/time.Second)))
+		}
+		body, err := Fixture("error_too_many_requests.json")
+		if err != nil {
+			writeError(w, f.status, "Too many requests")
+			return true
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(f.status)
+		_, _ = w.Write(body)
+		return true
+	case http.StatusInternalServerError:
+		writeError(w, f.status, "internal server error")
+		return true
+	}
+	return false
+}
+
+// mangle applies the body faults of f to body
+func (f chaosFault) mangle(body string) string {
+	switch {
+	case f.malformed:
+		return "<html>502 Bad Gateway</html>"
+	case f.truncate:
+		return body[:len(body)/2]
+	}
+	return body
+}
@@ -0,0 +1,84 @@
+package gop2btest
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/sutapurachina/gop2b"
+)
+
+// methodEndpoints lists the endpoints every Client method calls, keyed by method name.
+// Methods working on local state only, or on any endpoint like PostSigned and GetPublic, have none. A method added to Client needs an entry.
+var methodEndpoints = map[string][]string{
+	"PostCurrencyBalance": {"/account/balance"},
+	"PostBalances":        {"/account/balances"},
+	"PostNewOrder":        {"/order/new", "/public/markets", "/public/depth/result", "/public/tickers", "/account/balances"},
+	"CheckOrder":          {"/public/markets", "/public/depth/result"},
+	"HealthCheck":         {"/public/markets"},
+	"NewWS":               nil,
+	"PostOpenOrders":      {"/orders"},
+	"PostOrderHistory":    {"/account/order_history"},
+	"PostSigned":          nil,
+	"GetPublic":           nil,
+	"GetMarkets":          {"/public/markets"},
+	"GetTickers":          {"/public/tickers"},
+	"GetKlines":           {"/public/markets", "/public/market/kline"},
+	"BackfillKlines":      {"/public/markets", "/public/market/kline"},
+	"GetKlineRange":       {"/public/markets", "/public/market/kline"},
+	"FetchKlines":         {"/public/markets", "/public/market/kline"},
+	"GetHistory":          {"/public/markets", "/public/history"},
+	"FetchHistory":        {"/public/markets", "/public/history"},
+	"HistorySince":        {"/public/markets", "/public/history"},
+	"GetTicker":           {"/public/markets", "/public/ticker"},
+	"GetDepth":            {"/public/markets", "/public/depth/result"},
+	"GetBestQuotes":       {"/public/markets", "/public/depth/result"},
+	"PollDepth":           {"/public/markets", "/public/depth/result"},
+	"PortfolioValue":      {"/account/balances", "/public/markets", "/public/tickers"},
+	"ConversionRate":      {"/public/markets", "/public/tickers"},
+	"ResolveMarket":       {"/public/markets"},
+	"CacheStats":          nil,
+	"PurgeCache":          nil,
+	"Stats":               nil,
+	"ResetStats":          nil,
+	"RecentExchanges":     nil,
+	"Shutdown":            nil,
+}
+
+// Coverage returns the gaps of the testing surface, empty when there are none: Client
+// methods without a MockClient stub or unknown to this package, and the endpoints they
+// call that a Server has no golden fixture for. Call it from a test to catch a method
+// added to Client without its testing support.
+func Coverage() []string {
+	var gaps []string
+	client := reflect.TypeOf((*gop2b.Client)(nil)).Elem()
+	mock := reflect.TypeOf((*MockClient)(nil))
+	for i := 0; i < client.NumMethod(); i++ {
+		name := client.Method(i).Name
+		if _, ok := mock.MethodByName("On" + name); !ok {
+			gaps = append(gaps, fmt.Sprintf("%s: no MockClient.On%s", name, name))
+		}
+		endpoints, ok := methodEndpoints[name]
+		if !ok {
+			gaps = append(gaps, fmt.Sprintf("%s: endpoints unknown to gop2btest", name))
+			continue
+		}
+		for _, endpoint := range endpoints {
+			fixture, ok := endpointFixtures[endpoint]
+			if !ok {
+				gaps = append(gaps, fmt.Sprintf("%s: no Server fixture for %s", name, endpoint))
+				continue
+			}
+			if _, err := Fixture(fixture); err != nil {
+				gaps = append(gaps, fmt.Sprintf("%s: fixture %s of %s: %v", name, fixture, endpoint, err))
+			}
+		}
+	}
+	for name := range methodEndpoints {
+		if _, ok := client.MethodByName(name); !ok {
+			gaps = append(gaps, fmt.Sprintf("%s: listed by gop2btest but not a Client method", name))
+		}
+	}
+	sort.Strings(gaps)
+	return gaps
+}
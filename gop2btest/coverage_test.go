@@ -0,0 +1,9 @@
+package gop2btest
+
+import "testing"
+
+func TestCoverage(t *testing.T) {
+	for _, gap := range Coverage() {
+		t.Error(gap)
+	}
+}
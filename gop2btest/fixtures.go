@@ -0,0 +1,48 @@
+package gop2btest
+
+import (
+	"embed"
+	"path"
+)
+
+// fixtures holds sanitized exchange response bodies, one file per endpoint and error shape
+//
+//go:embed fixtures/*.json
+var fixtures embed.FS
+
+// endpointFixtures are the fixtures a new Server answers with, keyed by path below /api/v2
+var endpointFixtures = map[string]string{
+	"/public/markets":        "public_markets.json",
+	"/public/tickers":        "public_tickers.json",
+	"/public/ticker":         "public_ticker.json",
+	"/public/depth/result":   "public_depth_result.json",
+	"/public/market/kline":   "public_market_kline.json",
+	"/public/history":        "public_history.json",
+	"/order/new":             "order_new.json",
+	"/orders":                "orders.json",
+	"/account/balances":      "account_balances.json",
+	"/account/balance":       "account_balance.json",
+	"/account/order_history": "account_order_history.json",
+}
+
+// Fixture returns a golden response body by file name, such as "public_markets.json"
+// or "error_maintenance.json", for decode tests
+func Fixture(name string) ([]byte, error) {
+	return fixtures.ReadFile(path.Join("fixtures", name))
+}
+
+// Fixtures returns the names of all golden response bodies
+func Fixtures() []string {
+	entries, _ := fixtures.ReadDir("fixtures")
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+// EndpointFixture returns the name of the fixture a Server answers path with
+func EndpointFixture(endpoint string) (string, bool) {
+	name, ok := endpointFixtures[endpoint]
+	return name, ok
+}
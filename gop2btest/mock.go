@@ -0,0 +1,759 @@
+// Package gop2btest provides a programmable gop2b.Client for tests
+package gop2btest
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sutapurachina/gop2b"
+)
+
+// ErrUnexpectedCall is returned by MockClient methods without a programmed handler
+var ErrUnexpectedCall = errors.New("unexpected call")
+
+// TestingT is the part of *testing.T used by MockClient
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// Call is a call received by a MockClient
+type Call struct {
+	Method string
+	// Args are the call arguments in order, context included
+	Args []interface{}
+}
+
+var (
+	_ gop2b.Client        = (*MockClient)(nil)
+	_ gop2b.PublicClient  = (*MockClient)(nil)
+	_ gop2b.AccountClient = (*MockClient)(nil)
+	_ gop2b.TradingClient = (*MockClient)(nil)
+	_ gop2b.WsProvider    = (*MockClient)(nil)
+)
+
+// MockClient is a gop2b.Client whose methods are programmed with the On methods. It is also
+// the fake of the narrower PublicClient, AccountClient, TradingClient and WsProvider, only
+// the methods of the interface under test need programming. Every call is recorded. A call to a method without handler fails the test and
+// returns ErrUnexpectedCall. It is safe for concurrent use.
+type MockClient struct {
+	t TestingT
+
+	mu    sync.Mutex
+	calls []Call
+
+	// handlers, nil until programmed
+	postCurrencyBalance func(*gop2b.AccountCurrencyBalanceRequest) (*gop2b.AccountCurrencyBalanceResp, error)
+	postBalances        func(*gop2b.AccountBalancesRequest) (*gop2b.AccountBalancesResp, error)
+	postNewOrder        func(context.Context, *gop2b.NewOrderRequest) (*gop2b.NewOrderResp, error)
+	checkOrder          func(context.Context, *gop2b.NewOrderRequest) error
+	postOpenOrders      func(context.Context, *gop2b.OpenOrdersRequest) (*gop2b.OpenOrdersResp, error)
+	postOrderHistory    func(context.Context, *gop2b.OrderHistoryRequest) (*gop2b.OrderHistoryResp, error)
+	postSigned          func(context.Context, string, interface{}, interface{}) error
+	getPublic           func(context.Context, string, url.Values, interface{}) error
+	getMarkets          func(context.Context) (*gop2b.MarketsResp, error)
+	getTickers          func(context.Context) (*gop2b.TickersResp, error)
+	getKlines           func(context.Context, string, gop2b.KlineInterval, int, int) (*gop2b.KlinesResp, error)
+	backfillKlines      func(context.Context, string, gop2b.KlineInterval, time.Time, time.Time) (*gop2b.KlineBackfill, error)
+	getKlineRange       func(context.Context, string, gop2b.KlineInterval, time.Time, time.Time) ([]gop2b.Kline, error)
+	fetchKlines         func(context.Context, gop2b.KlineRequest) ([]gop2b.Kline, error)
+	getHistory          func(context.Context, string, int64, int) (*gop2b.HistoryResp, error)
+	fetchHistory        func(context.Context, gop2b.HistoryRequest) (*gop2b.HistoryResp, error)
+	historySince        func(context.Context, string, int64, int) ([]gop2b.Trade, error)
+	getTicker           func(context.Context, string) (*gop2b.TickerResp, error)
+	getDepth            func(context.Context, string, int, string) (*gop2b.DepthResp, error)
+	getBestQuotes       func(context.Context, string) (decimal.Decimal, decimal.Decimal, error)
+	pollDepth           func(context.Context, string, int, string, time.Duration) (<-chan gop2b.DepthResp, error)
+	portfolioValue      func(context.Context, string) (*gop2b.Portfolio, error)
+	conversionRate      func(context.Context, string, string) (*gop2b.Conversion, error)
+	resolveMarket       func(context.Context, string) (string, error)
+	healthCheck         func(context.Context) (gop2b.HealthReport, error)
+	newWS               func(...gop2b.WSOption) *gop2b.WSClient
+	cacheStats          func() gop2b.CacheStats
+	purgeCache          func()
+	stats               func() map[string]gop2b.EndpointStats
+	resetStats          func()
+	recentExchanges     func() []gop2b.Exchange
+	shutdown            func(context.Context) error
+}
+
+// NewMockClient creates a MockClient reporting unexpected calls to t
+func NewMockClient(t TestingT) *MockClient {
+	return &MockClient{t: t}
+}
+
+// Calls returns the recorded calls in order
+func (m *MockClient) Calls() []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Call(nil), m.calls...)
+}
+
+// CallsTo returns the recorded calls of method in order
+func (m *MockClient) CallsTo(method string) []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []Call
+	for _, c := range m.calls {
+		if c.Method == method {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// record stores the call and reports whether a handler is programmed, failing the test otherwise
+func (m *MockClient) record(method string, programmed bool, args ...interface{}) bool {
+	m.mu.Lock()
+	m.calls = append(m.calls, Call{Method: method, Args: args})
+	m.mu.Unlock()
+	if !programmed {
+		m.t.Helper()
+		m.t.Errorf("gop2btest: unexpected call to %s", method)
+	}
+	return programmed
+}
+
+// OnPostCurrencyBalance programs PostCurrencyBalance
+func (m *MockClient) OnPostCurrencyBalance(fn func(*gop2b.AccountCurrencyBalanceRequest) (*gop2b.AccountCurrencyBalanceResp, error)) *MockClient {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.postCurrencyBalance = fn
+	return m
+}
+
+// PostCurrencyBalance implements gop2b.Client
+func (m *MockClient) PostCurrencyBalance(request *gop2b.AccountCurrencyBalanceRequest) (*gop2b.AccountCurrencyBalanceResp, error) {
+	m.t.Helper()
+	m.mu.Lock()
+	fn := m.postCurrencyBalance
+	m.mu.Unlock()
+	if !m.record("PostCurrencyBalance", fn != nil, request) {
+		return nil, ErrUnexpectedCall
+	}
+	return fn(request)
+}
+
+// OnPostBalances programs PostBalances
+func (m *MockClient) OnPostBalances(fn func(*gop2b.AccountBalancesRequest) (*gop2b.AccountBalancesResp, error)) *MockClient {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.postBalances = fn
+	return m
+}
+
+// PostBalances implements gop2b.Client
+func (m *MockClient) PostBalances(request *gop2b.AccountBalancesRequest) (*gop2b.AccountBalancesResp, error) {
+	m.t.Helper()
+	m.mu.Lock()
+	fn := m.postBalances
+	m.mu.Unlock()
+	if !m.record("PostBalances", fn != nil, request) {
+		return nil, ErrUnexpectedCall
+	}
+	return fn(request)
+}
+
+// OnPostNewOrder programs PostNewOrder
+func (m *MockClient) OnPostNewOrder(fn func(context.Context, *gop2b.NewOrderRequest) (*gop2b.NewOrderResp, error)) *MockClient {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.postNewOrder = fn
+	return m
+}
+
+// PostNewOrder implements gop2b.Client
+func (m *MockClient) PostNewOrder(ctx context.Context, request *gop2b.NewOrderRequest) (*gop2b.NewOrderResp, error) {
+	m.t.Helper()
+	m.mu.Lock()
+	fn := m.postNewOrder
+	m.mu.Unlock()
+	if !m.record("PostNewOrder", fn != nil, ctx, request) {
+		return nil, ErrUnexpectedCall
+	}
+	return fn(ctx, request)
+}
+
+// OnCheckOrder programs CheckOrder
+func (m *MockClient) OnCheckOrder(fn func(context.Context, *gop2b.NewOrderRequest) error) *MockClient {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.checkOrder = fn
+	return m
+}
+
+// CheckOrder implements gop2b.Client
+func (m *MockClient) CheckOrder(ctx context.Context, request *gop2b.NewOrderRequest) error {
+	m.t.Helper()
+	m.mu.Lock()
+	fn := m.checkOrder
+	m.mu.Unlock()
+	if !m.record("CheckOrder", fn != nil, ctx, request) {
+		return ErrUnexpectedCall
+	}
+	return fn(ctx, request)
+}
+
+// OnPostOpenOrders programs PostOpenOrders
+func (m *MockClient) OnPostOpenOrders(fn func(context.Context, *gop2b.OpenOrdersRequest) (*gop2b.OpenOrdersResp, error)) *MockClient {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.postOpenOrders = fn
+	return m
+}
+
+// PostOpenOrders implements gop2b.Client
+func (m *MockClient) PostOpenOrders(ctx context.Context, request *gop2b.OpenOrdersRequest) (*gop2b.OpenOrdersResp, error) {
+	m.t.Helper()
+	m.mu.Lock()
+	fn := m.postOpenOrders
+	m.mu.Unlock()
+	if !m.record("PostOpenOrders", fn != nil, ctx, request) {
+		return nil, ErrUnexpectedCall
+	}
+	return fn(ctx, request)
+}
+
+// OnPostOrderHistory programs PostOrderHistory
+func (m *MockClient) OnPostOrderHistory(fn func(context.Context, *gop2b.OrderHistoryRequest) (*gop2b.OrderHistoryResp, error)) *MockClient {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.postOrderHistory = fn
+	return m
+}
+
+// PostOrderHistory implements gop2b.Client
+func (m *MockClient) PostOrderHistory(ctx context.Context, request *gop2b.OrderHistoryRequest) (*gop2b.OrderHistoryResp, error) {
+	m.t.Helper()
+	m.mu.Lock()
+	fn := m.postOrderHistory
+	m.mu.Unlock()
+	if !m.record("PostOrderHistory", fn != nil, ctx, request) {
+		return nil, ErrUnexpectedCall
+	}
+	return fn(ctx, request)
+}
+
+// OnPostSigned programs PostSigned
+func (m *MockClient) OnPostSigned(fn func(context.Context, string, interface{}, interface{}) error) *MockClient {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.postSigned = fn
+	return m
+}
+
+// PostSigned implements gop2b.Client
+func (m *MockClient) PostSigned(ctx context.Context, path string, body interface{}, out interface{}) error {
+	m.t.Helper()
+	m.mu.Lock()
+	fn := m.postSigned
+	m.mu.Unlock()
+	if !m.record("PostSigned", fn != nil, ctx, path, body, out) {
+		return ErrUnexpectedCall
+	}
+	return fn(ctx, path, body, out)
+}
+
+// OnGetPublic programs GetPublic
+func (m *MockClient) OnGetPublic(fn func(context.Context, string, url.Values, interface{}) error) *MockClient {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.getPublic = fn
+	return m
+}
+
+// GetPublic implements gop2b.Client
+func (m *MockClient) GetPublic(ctx context.Context, path string, params url.Values, out interface{}) error {
+	m.t.Helper()
+	m.mu.Lock()
+	fn := m.getPublic
+	m.mu.Unlock()
+	if !m.record("GetPublic", fn != nil, ctx, path, params, out) {
+		return ErrUnexpectedCall
+	}
+	return fn(ctx, path, params, out)
+}
+
+// OnGetMarkets programs GetMarkets
+func (m *MockClient) OnGetMarkets(fn func(context.Context) (*gop2b.MarketsResp, error)) *MockClient {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.getMarkets = fn
+	return m
+}
+
+// GetMarkets implements gop2b.Client
+func (m *MockClient) GetMarkets(ctx context.Context) (*gop2b.MarketsResp, error) {
+	m.t.Helper()
+	m.mu.Lock()
+	fn := m.getMarkets
+	m.mu.Unlock()
+	if !m.record("GetMarkets", fn != nil, ctx) {
+		return nil, ErrUnexpectedCall
+	}
+	return fn(ctx)
+}
+
+// OnGetTickers programs GetTickers
+func (m *MockClient) OnGetTickers(fn func(context.Context) (*gop2b.TickersResp, error)) *MockClient {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.getTickers = fn
+	return m
+}
+
+// GetTickers implements gop2b.Client
+func (m *MockClient) GetTickers(ctx context.Context) (*gop2b.TickersResp, error) {
+	m.t.Helper()
+	m.mu.Lock()
+	fn := m.getTickers
+	m.mu.Unlock()
+	if !m.record("GetTickers", fn != nil, ctx) {
+		return nil, ErrUnexpectedCall
+	}
+	return fn(ctx)
+}
+
+// OnGetKlines programs GetKlines
+func (m *MockClient) OnGetKlines(fn func(context.Context, string, gop2b.KlineInterval, int, int) (*gop2b.KlinesResp, error)) *MockClient {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.getKlines = fn
+	return m
+}
+
+// GetKlines implements gop2b.Client
+func (m *MockClient) GetKlines(ctx context.Context, market string, interval gop2b.KlineInterval, offset int, limit int) (*gop2b.KlinesResp, error) {
+	m.t.Helper()
+	m.mu.Lock()
+	fn := m.getKlines
+	m.mu.Unlock()
+	if !m.record("GetKlines", fn != nil, ctx, market, interval, offset, limit) {
+		return nil, ErrUnexpectedCall
+	}
+	return fn(ctx, market, interval, offset, limit)
+}
+
+// OnBackfillKlines programs BackfillKlines
+func (m *MockClient) OnBackfillKlines(fn func(context.Context, string, gop2b.KlineInterval, time.Time, time.Time) (*gop2b.KlineBackfill, error)) *MockClient {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.backfillKlines = fn
+	return m
+}
+
+// BackfillKlines implements gop2b.Client
+func (m *MockClient) BackfillKlines(ctx context.Context, market string, interval gop2b.KlineInterval, from time.Time, to time.Time) (*gop2b.KlineBackfill, error) {
+	m.t.Helper()
+	m.mu.Lock()
+	fn := m.backfillKlines
+	m.mu.Unlock()
+	if !m.record("BackfillKlines", fn != nil, ctx, market, interval, from, to) {
+		return nil, ErrUnexpectedCall
+	}
+	return fn(ctx, market, interval, from, to)
+}
+
+// OnGetKlineRange programs GetKlineRange
+func (m *MockClient) OnGetKlineRange(fn func(context.Context, string, gop2b.KlineInterval, time.Time, time.Time) ([]gop2b.Kline, error)) *MockClient {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.getKlineRange = fn
+	return m
+}
+
+// GetKlineRange implements gop2b.Client
+func (m *MockClient) GetKlineRange(ctx context.Context, market string, interval gop2b.KlineInterval, start time.Time, end time.Time) ([]gop2b.Kline, error) {
+	m.t.Helper()
+	m.mu.Lock()
+	fn := m.getKlineRange
+	m.mu.Unlock()
+	if !m.record("GetKlineRange", fn != nil, ctx, market, interval, start, end) {
+		return nil, ErrUnexpectedCall
+	}
+	return fn(ctx, market, interval, start, end)
+}
+
+// OnFetchKlines programs FetchKlines
+func (m *MockClient) OnFetchKlines(fn func(context.Context, gop2b.KlineRequest) ([]gop2b.Kline, error)) *MockClient {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fetchKlines = fn
+	return m
+}
+
+// FetchKlines implements gop2b.Client
+func (m *MockClient) FetchKlines(ctx context.Context, request gop2b.KlineRequest) ([]gop2b.Kline, error) {
+	m.t.Helper()
+	m.mu.Lock()
+	fn := m.fetchKlines
+	m.mu.Unlock()
+	if !m.record("FetchKlines", fn != nil, ctx, request) {
+		return nil, ErrUnexpectedCall
+	}
+	return fn(ctx, request)
+}
+
+// OnGetHistory programs GetHistory
+func (m *MockClient) OnGetHistory(fn func(context.Context, string, int64, int) (*gop2b.HistoryResp, error)) *MockClient {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.getHistory = fn
+	return m
+}
+
+// GetHistory implements gop2b.Client
+func (m *MockClient) GetHistory(ctx context.Context, market string, lastID int64, limit int) (*gop2b.HistoryResp, error) {
+	m.t.Helper()
+	m.mu.Lock()
+	fn := m.getHistory
+	m.mu.Unlock()
+	if !m.record("GetHistory", fn != nil, ctx, market, lastID, limit) {
+		return nil, ErrUnexpectedCall
+	}
+	return fn(ctx, market, lastID, limit)
+}
+
+// OnFetchHistory programs FetchHistory
+func (m *MockClient) OnFetchHistory(fn func(context.Context, gop2b.HistoryRequest) (*gop2b.HistoryResp, error)) *MockClient {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fetchHistory = fn
+	return m
+}
+
+// FetchHistory implements gop2b.Client
+func (m *MockClient) FetchHistory(ctx context.Context, request gop2b.HistoryRequest) (*gop2b.HistoryResp, error) {
+	m.t.Helper()
+	m.mu.Lock()
+	fn := m.fetchHistory
+	m.mu.Unlock()
+	if !m.record("FetchHistory", fn != nil, ctx, request) {
+		return nil, ErrUnexpectedCall
+	}
+	return fn(ctx, request)
+}
+
+// OnHistorySince programs HistorySince
+func (m *MockClient) OnHistorySince(fn func(context.Context, string, int64, int) ([]gop2b.Trade, error)) *MockClient {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.historySince = fn
+	return m
+}
+
+// HistorySince implements gop2b.Client
+func (m *MockClient) HistorySince(ctx context.Context, market string, lastID int64, max int) ([]gop2b.Trade, error) {
+	m.t.Helper()
+	m.mu.Lock()
+	fn := m.historySince
+	m.mu.Unlock()
+	if !m.record("HistorySince", fn != nil, ctx, market, lastID, max) {
+		return nil, ErrUnexpectedCall
+	}
+	return fn(ctx, market, lastID, max)
+}
+
+// OnGetTicker programs GetTicker
+func (m *MockClient) OnGetTicker(fn func(context.Context, string) (*gop2b.TickerResp, error)) *MockClient {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.getTicker = fn
+	return m
+}
+
+// GetTicker implements gop2b.Client
+func (m *MockClient) GetTicker(ctx context.Context, market string) (*gop2b.TickerResp, error) {
+	m.t.Helper()
+	m.mu.Lock()
+	fn := m.getTicker
+	m.mu.Unlock()
+	if !m.record("GetTicker", fn != nil, ctx, market) {
+		return nil, ErrUnexpectedCall
+	}
+	return fn(ctx, market)
+}
+
+// OnGetDepth programs GetDepth
+func (m *MockClient) OnGetDepth(fn func(context.Context, string, int, string) (*gop2b.DepthResp, error)) *MockClient {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.getDepth = fn
+	return m
+}
+
+// GetDepth implements gop2b.Client
+func (m *MockClient) GetDepth(ctx context.Context, market string, limit int, interval string) (*gop2b.DepthResp, error) {
+	m.t.Helper()
+	m.mu.Lock()
+	fn := m.getDepth
+	m.mu.Unlock()
+	if !m.record("GetDepth", fn != nil, ctx, market, limit, interval) {
+		return nil, ErrUnexpectedCall
+	}
+	return fn(ctx, market, limit, interval)
+}
+
+// OnGetBestQuotes programs GetBestQuotes
+func (m *MockClient) OnGetBestQuotes(fn func(context.Context, string) (decimal.Decimal, decimal.Decimal, error)) *MockClient {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.getBestQuotes = fn
+	return m
+}
+
+// GetBestQuotes implements gop2b.Client
+func (m *MockClient) GetBestQuotes(ctx context.Context, market string) (decimal.Decimal, decimal.Decimal, error) {
+	m.t.Helper()
+	m.mu.Lock()
+	fn := m.getBestQuotes
+	m.mu.Unlock()
+	if !m.record("GetBestQuotes", fn != nil, ctx, market) {
+		return decimal.Zero, decimal.Zero, ErrUnexpectedCall
+	}
+	return fn(ctx, market)
+}
+
+// OnPollDepth programs PollDepth
+func (m *MockClient) OnPollDepth(fn func(context.Context, string, int, string, time.Duration) (<-chan gop2b.DepthResp, error)) *MockClient {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pollDepth = fn
+	return m
+}
+
+// PollDepth implements gop2b.Client
+func (m *MockClient) PollDepth(ctx context.Context, market string, limit int, interval string, refresh time.Duration) (<-chan gop2b.DepthResp, error) {
+	m.t.Helper()
+	m.mu.Lock()
+	fn := m.pollDepth
+	m.mu.Unlock()
+	if !m.record("PollDepth", fn != nil, ctx, market, limit, interval, refresh) {
+		return nil, ErrUnexpectedCall
+	}
+	return fn(ctx, market, limit, interval, refresh)
+}
+
+// OnPortfolioValue programs PortfolioValue
+func (m *MockClient) OnPortfolioValue(fn func(context.Context, string) (*gop2b.Portfolio, error)) *MockClient {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.portfolioValue = fn
+	return m
+}
+
+// PortfolioValue implements gop2b.Client
+func (m *MockClient) PortfolioValue(ctx context.Context, quote string) (*gop2b.Portfolio, error) {
+	m.t.Helper()
+	m.mu.Lock()
+	fn := m.portfolioValue
+	m.mu.Unlock()
+	if !m.record("PortfolioValue", fn != nil, ctx, quote) {
+		return nil, ErrUnexpectedCall
+	}
+	return fn(ctx, quote)
+}
+
+// OnConversionRate programs ConversionRate
+func (m *MockClient) OnConversionRate(fn func(context.Context, string, string) (*gop2b.Conversion, error)) *MockClient {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.conversionRate = fn
+	return m
+}
+
+// ConversionRate implements gop2b.Client
+func (m *MockClient) ConversionRate(ctx context.Context, from string, to string) (*gop2b.Conversion, error) {
+	m.t.Helper()
+	m.mu.Lock()
+	fn := m.conversionRate
+	m.mu.Unlock()
+	if !m.record("ConversionRate", fn != nil, ctx, from, to) {
+		return nil, ErrUnexpectedCall
+	}
+	return fn(ctx, from, to)
+}
+
+// OnResolveMarket programs ResolveMarket
+func (m *MockClient) OnResolveMarket(fn func(context.Context, string) (string, error)) *MockClient {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.resolveMarket = fn
+	return m
+}
+
+// ResolveMarket implements gop2b.Client
+func (m *MockClient) ResolveMarket(ctx context.Context, market string) (string, error) {
+	m.t.Helper()
+	m.mu.Lock()
+	fn := m.resolveMarket
+	m.mu.Unlock()
+	if !m.record("ResolveMarket", fn != nil, ctx, market) {
+		return "", ErrUnexpectedCall
+	}
+	return fn(ctx, market)
+}
+
+// OnHealthCheck programs HealthCheck
+func (m *MockClient) OnHealthCheck(fn func(context.Context) (gop2b.HealthReport, error)) *MockClient {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.healthCheck = fn
+	return m
+}
+
+// HealthCheck implements gop2b.Client
+func (m *MockClient) HealthCheck(ctx context.Context) (gop2b.HealthReport, error) {
+	m.t.Helper()
+	m.mu.Lock()
+	fn := m.healthCheck
+	m.mu.Unlock()
+	if !m.record("HealthCheck", fn != nil, ctx) {
+		return gop2b.HealthReport{}, ErrUnexpectedCall
+	}
+	return fn(ctx)
+}
+
+// OnNewWS programs NewWS
+func (m *MockClient) OnNewWS(fn func(...gop2b.WSOption) *gop2b.WSClient) *MockClient {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.newWS = fn
+	return m
+}
+
+// NewWS implements gop2b.Client
+func (m *MockClient) NewWS(opts ...gop2b.WSOption) *gop2b.WSClient {
+	m.t.Helper()
+	m.mu.Lock()
+	fn := m.newWS
+	m.mu.Unlock()
+	if !m.record("NewWS", fn != nil, opts) {
+		return nil
+	}
+	return fn(opts...)
+}
+
+// OnCacheStats programs CacheStats
+func (m *MockClient) OnCacheStats(fn func() gop2b.CacheStats) *MockClient {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cacheStats = fn
+	return m
+}
+
+// CacheStats implements gop2b.Client
+func (m *MockClient) CacheStats() gop2b.CacheStats {
+	m.t.Helper()
+	m.mu.Lock()
+	fn := m.cacheStats
+	m.mu.Unlock()
+	if !m.record("CacheStats", fn != nil) {
+		return gop2b.CacheStats{}
+	}
+	return fn()
+}
+
+// OnPurgeCache programs PurgeCache
+func (m *MockClient) OnPurgeCache(fn func()) *MockClient {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.purgeCache = fn
+	return m
+}
+
+// PurgeCache implements gop2b.Client
+func (m *MockClient) PurgeCache() {
+	m.t.Helper()
+	m.mu.Lock()
+	fn := m.purgeCache
+	m.mu.Unlock()
+	if !m.record("PurgeCache", fn != nil) {
+		return
+	}
+	fn()
+}
+
+// OnStats programs Stats
+func (m *MockClient) OnStats(fn func() map[string]gop2b.EndpointStats) *MockClient {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stats = fn
+	return m
+}
+
+// Stats implements gop2b.Client
+func (m *MockClient) Stats() map[string]gop2b.EndpointStats {
+	m.t.Helper()
+	m.mu.Lock()
+	fn := m.stats
+	m.mu.Unlock()
+	if !m.record("Stats", fn != nil) {
+		return nil
+	}
+	return fn()
+}
+
+// OnResetStats programs ResetStats
+func (m *MockClient) OnResetStats(fn func()) *MockClient {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.resetStats = fn
+	return m
+}
+
+// ResetStats implements gop2b.Client
+func (m *MockClient) ResetStats() {
+	m.t.Helper()
+	m.mu.Lock()
+	fn := m.resetStats
+	m.mu.Unlock()
+	if !m.record("ResetStats", fn != nil) {
+		return
+	}
+	fn()
+}
+
+// OnRecentExchanges programs RecentExchanges
+func (m *MockClient) OnRecentExchanges(fn func() []gop2b.Exchange) *MockClient {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.recentExchanges = fn
+	return m
+}
+
+// RecentExchanges implements gop2b.Client
+func (m *MockClient) RecentExchanges() []gop2b.Exchange {
+	m.t.Helper()
+	m.mu.Lock()
+	fn := m.recentExchanges
+	m.mu.Unlock()
+	if !m.record("RecentExchanges", fn != nil) {
+		return nil
+	}
+	return fn()
+}
+
+// OnShutdown programs Shutdown
+func (m *MockClient) OnShutdown(fn func(context.Context) error) *MockClient {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.shutdown = fn
+	return m
+}
+
+// Shutdown implements gop2b.Client
+func (m *MockClient) Shutdown(ctx context.Context) error {
+	m.t.Helper()
+	m.mu.Lock()
+	fn := m.shutdown
+	m.mu.Unlock()
+	if !m.record("Shutdown", fn != nil, ctx) {
+		return ErrUnexpectedCall
+	}
+	return fn(ctx)
+}
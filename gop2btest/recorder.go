@@ -0,0 +1,201 @@
+package gop2btest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ErrNoRecording is returned by a replaying Recorder for a request that wasn't recorded
+var ErrNoRecording = errors.New("no recorded response")
+
+// RecorderMode selects whether a Recorder records or replays
+type RecorderMode int
+
+const (
+	// ModeReplay serves the recorded responses without any network access
+	ModeReplay RecorderMode = iota
+	// ModeRecord forwards requests and records the responses
+	ModeRecord
+)
+
+// volatileFields are left out of recorded request bodies, they change on every request
+var volatileFields = []string{"nonce", "request"}
+
+// Interaction is a recorded request and its response. Request headers, which hold the
+// credentials and the signature, are not recorded, nor are the nonce and request path fields of signed bodies.
+type Interaction struct {
+	Method string `json:"method"`
+	// URL is the path and sorted query, without the host
+	URL        string          `json:"url"`
+	Body       json.RawMessage `json:"body,omitempty"`
+	StatusCode int             `json:"status_code"`
+	Header     http.Header     `json:"header,omitempty"`
+	Response   string          `json:"response"`
+}
+
+// Recorder is an http.RoundTripper recording the exchange responses to a golden file and
+// replaying them, for offline integration tests. Use it with gop2b.WithTransport.
+// Replayed requests are matched on method, path, query and body. Identical requests are
+// answered in recorded order, the last answer repeating once they are used up.
+type Recorder struct {
+	mode RecorderMode
+	file string
+	next http.RoundTripper
+
+	mu           sync.Mutex
+	interactions []Interaction
+	replayed     map[string]int
+}
+
+// NewRecorder creates a recorder of file. A replaying recorder loads file, a recording one
+// truncates it and forwards requests to next, http.DefaultTransport when nil.
+func NewRecorder(file string, mode RecorderMode, next http.RoundTripper) (*Recorder, error) {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	r := &Recorder{mode: mode, file: file, next: next, replayed: make(map[string]int)}
+	if mode == ModeRecord {
+		return r, r.save()
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &r.interactions); err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	return r, nil
+}
+
+// RoundTrip implements http.RoundTripper
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	recorded := Interaction{Method: req.Method, URL: normalizeURL(req), Body: normalizeBody(body)}
+	if r.mode == ModeReplay {
+		return r.replay(req, recorded)
+	}
+
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	recorded.StatusCode = resp.StatusCode
+	recorded.Header = http.Header{}
+	for _, h := range []string{"Content-Type", "Retry-After"} {
+		if v := resp.Header.Get(h); v != "" {
+			recorded.Header.Set(h, v)
+		}
+	}
+	recorded.Response = string(respBody)
+	r.mu.Lock()
+	r.interactions = append(r.interactions, recorded)
+	r.mu.Unlock()
+	if err := r.save(); err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request, key Interaction) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var matches []Interaction
+	for _, i := range r.interactions {
+		if i.Method == key.Method && i.URL == key.URL && bytes.Equal(i.Body, key.Body) {
+			matches = append(matches, i)
+		}
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("%w for %s %s %s", ErrNoRecording, key.Method, key.URL, key.Body)
+	}
+	id := key.Method + " " + key.URL + " " + string(key.Body)
+	n := r.replayed[id]
+	r.replayed[id]++
+	match := matches[min(n, len(matches)-1)]
+	header := match.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", match.StatusCode, http.StatusText(match.StatusCode)),
+		StatusCode:    match.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(match.Response)),
+		ContentLength: int64(len(match.Response)),
+		Request:       req,
+	}, nil
+}
+
+// save rewrites the whole file, keeping it valid after every request
+func (r *Recorder) save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	interactions := r.interactions
+	if interactions == nil {
+		interactions = []Interaction{}
+	}
+	var data bytes.Buffer
+	enc := json.NewEncoder(&data)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(interactions); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.file), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(r.file, data.Bytes(), 0o644)
+}
+
+// normalizeURL returns the path and the query sorted by key
+func normalizeURL(req *http.Request) string {
+	u := req.URL.Path
+	if q := req.URL.Query(); len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	return u
+}
+
+// normalizeBody drops the volatile fields of a JSON body and sorts its keys
+func normalizeBody(body []byte) json.RawMessage {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return body
+	}
+	for _, f := range volatileFields {
+		delete(fields, f)
+	}
+	normalized, err := json.Marshal(fields)
+	if err != nil {
+		return body
+	}
+	return normalized
+}
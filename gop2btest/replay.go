@@ -0,0 +1,65 @@
+package gop2btest
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/sutapurachina/gop2b"
+)
+
+// CapturedFrame is a line of a websocket capture. A capture is newline-delimited JSON,
+// one CapturedFrame per line in the order the frames were seen, for example
+//
+//	{"time":"2024-05-01T10:00:00.123Z","direction":"in","frame":"{\"method\":\"depth.update\",...}"}
+//
+// Frame is a string rather than nested JSON so malformed frames can be captured too.
+type CapturedFrame struct {
+	Time      time.Time            `json:"time"`
+	Direction gop2b.FrameDirection `json:"direction"`
+	Frame     string               `json:"frame"`
+}
+
+// FrameWriter returns a gop2b.WithWSFrameHook hook writing a capture of the session to w.
+// Write errors are ignored, the session goes on without them.
+func FrameWriter(w io.Writer) func(direction gop2b.FrameDirection, frame []byte) {
+	var mu sync.Mutex
+	enc := json.NewEncoder(w)
+	return func(direction gop2b.FrameDirection, frame []byte) {
+		mu.Lock()
+		defer mu.Unlock()
+		_ = enc.Encode(CapturedFrame{Time: time.Now(), Direction: direction, Frame: string(frame)})
+	}
+}
+
+// ReplayFrames sends the received frames of the capture read from r to the connections of
+// ws in capture order. Sent frames are skipped, ws answers the requests of the client itself.
+// speed scales the original gaps between frames, 10 replaying ten times faster; frames are
+// sent back to back when speed is zero or less. The client must be connected and subscribed
+// before, as the frames are only sent to the connections open at the time.
+func ReplayFrames(ws *WsServer, r io.Reader, speed float64) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16<<20)
+	var last time.Time
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var f CapturedFrame
+		if err := json.Unmarshal(scanner.Bytes(), &f); err != nil {
+			return fmt.Errorf("capture line %d: %v", line, err)
+		}
+		if f.Direction != gop2b.FrameIn {
+			continue
+		}
+		if speed > 0 && !last.IsZero() && f.Time.After(last) {
+			time.Sleep(time.Duration(float64(f.Time.Sub(last)) / speed))
+		}
+		last = f.Time
+		ws.Send(f.Frame)
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,207 @@
+package gop2btest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sutapurachina/gop2b"
+)
+
+// ErrUnknownCommand is returned by Runner.Run for a step whose command it doesn't know
+var ErrUnknownCommand = errors.New("unknown command")
+
+// Command names the Client method a Step calls
+type Command string
+
+const (
+	CmdBalances        Command = "balances"
+	CmdCurrencyBalance Command = "currency_balance"
+	CmdNewOrder        Command = "new_order"
+	CmdOpenOrders      Command = "open_orders"
+	CmdOrderHistory    Command = "order_history"
+	CmdMarkets         Command = "markets"
+	CmdTickers         Command = "tickers"
+	CmdTicker          Command = "ticker"
+	CmdDepth           Command = "depth"
+	CmdHistory         Command = "history"
+	CmdKlines          Command = "klines"
+)
+
+// Step is a scripted call. Params of signed commands are the JSON of their request, such as
+// gop2b.NewOrderRequest for CmdNewOrder, the nonce and request path being set by the client.
+// Params of public commands are the fields of MarketParams they use.
+type Step struct {
+	Command Command         `json:"command"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	// Delay is waited before the step, in Go duration syntax in JSON such as "250ms"
+	Delay Duration `json:"delay,omitempty"`
+}
+
+// MarketParams are the arguments of the public commands
+type MarketParams struct {
+	Market   string `json:"market"`
+	Limit    int    `json:"limit"`
+	Offset   int    `json:"offset"`
+	Interval string `json:"interval"`
+	LastID   int64  `json:"last_id"`
+}
+
+// Duration is a time.Duration written as a string such as "1s" in JSON
+type Duration time.Duration
+
+// UnmarshalJSON parses a duration string
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// MarshalJSON formats the duration as a string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// StepResult is the outcome of a step. Result is the response of the called method.
+type StepResult struct {
+	Step     Step
+	Start    time.Time
+	Duration time.Duration
+	Result   interface{}
+	Err      error
+}
+
+// Runner runs scripted calls against a client one after the other, for load tests and to
+// reproduce ordering sensitive issues. Signed calls take their nonces from the client as
+// usual, so they reach the server in script order.
+type Runner struct {
+	client gop2b.Client
+	// StopOnError stops the script at the first step returning an error
+	StopOnError bool
+}
+
+// NewRunner creates a runner calling client, a real client or a MockClient
+func NewRunner(client gop2b.Client) *Runner {
+	return &Runner{client: client}
+}
+
+// ParseScript decodes a JSON array of steps
+func ParseScript(data []byte) ([]Step, error) {
+	var steps []Step
+	if err := json.Unmarshal(data, &steps); err != nil {
+		return nil, err
+	}
+	return steps, nil
+}
+
+// Run runs steps in order and returns their results. It fails before running anything when
+// a step has an unknown command or invalid params, and stops when ctx is done, returning the
+// results so far with the context error.
+func (r *Runner) Run(ctx context.Context, steps []Step) ([]StepResult, error) {
+	calls := make([]func(ctx context.Context) (interface{}, error), len(steps))
+	for i, step := range steps {
+		call, err := r.prepare(step)
+		if err != nil {
+			return nil, fmt.Errorf("step %d: %w", i, err)
+		}
+		calls[i] = call
+	}
+	results := make([]StepResult, 0, len(steps))
+	for i, step := range steps {
+		if step.Delay > 0 {
+			timer := time.NewTimer(time.Duration(step.Delay))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return results, ctx.Err()
+			case <-timer.C:
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		result := StepResult{Step: step, Start: time.Now()}
+		result.Result, result.Err = calls[i](ctx)
+		result.Duration = time.Since(result.Start)
+		results = append(results, result)
+		if result.Err != nil && r.StopOnError {
+			break
+		}
+	}
+	return results, nil
+}
+
+// prepare decodes the params of step into the call of its command
+func (r *Runner) prepare(step Step) (func(ctx context.Context) (interface{}, error), error) {
+	decode := func(v interface{}) error {
+		if len(step.Params) == 0 {
+			return nil
+		}
+		if err := json.Unmarshal(step.Params, v); err != nil {
+			return fmt.Errorf("%s params: %v", step.Command, err)
+		}
+		return nil
+	}
+	var p MarketParams
+	switch step.Command {
+	case CmdBalances:
+		var request gop2b.AccountBalancesRequest
+		return func(ctx context.Context) (interface{}, error) {
+			return r.client.PostBalances(&request)
+		}, decode(&request)
+	case CmdCurrencyBalance:
+		var request gop2b.AccountCurrencyBalanceRequest
+		return func(ctx context.Context) (interface{}, error) {
+			return r.client.PostCurrencyBalance(&request)
+		}, decode(&request)
+	case CmdNewOrder:
+		var request gop2b.NewOrderRequest
+		return func(ctx context.Context) (interface{}, error) {
+			return r.client.PostNewOrder(ctx, &request)
+		}, decode(&request)
+	case CmdOpenOrders:
+		var request gop2b.OpenOrdersRequest
+		return func(ctx context.Context) (interface{}, error) {
+			return r.client.PostOpenOrders(ctx, &request)
+		}, decode(&request)
+	case CmdOrderHistory:
+		var request gop2b.OrderHistoryRequest
+		return func(ctx context.Context) (interface{}, error) {
+			return r.client.PostOrderHistory(ctx, &request)
+		}, decode(&request)
+	case CmdMarkets:
+		return func(ctx context.Context) (interface{}, error) {
+			return r.client.GetMarkets(ctx)
+		}, nil
+	case CmdTickers:
+		return func(ctx context.Context) (interface{}, error) {
+			return r.client.GetTickers(ctx)
+		}, nil
+	case CmdTicker:
+		return func(ctx context.Context) (interface{}, error) {
+			return r.client.GetTicker(ctx, p.Market)
+		}, decode(&p)
+	case CmdDepth:
+		return func(ctx context.Context) (interface{}, error) {
+			return r.client.GetDepth(ctx, p.Market, p.Limit, p.Interval)
+		}, decode(&p)
+	case CmdHistory:
+		return func(ctx context.Context) (interface{}, error) {
+			return r.client.GetHistory(ctx, p.Market, p.LastID, p.Limit)
+		}, decode(&p)
+	case CmdKlines:
+		return func(ctx context.Context) (interface{}, error) {
+			return r.client.GetKlines(ctx, p.Market, gop2b.KlineInterval(p.Interval), p.Offset, p.Limit)
+		}, decode(&p)
+	}
+	return nil, fmt.Errorf("%w %q", ErrUnknownCommand, step.Command)
+}
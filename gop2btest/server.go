@@ -0,0 +1,224 @@
+package gop2btest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sutapurachina/gop2b"
+)
+
+const (
+	// ServerAPIKey and ServerAPISecret are the credentials a Server accepts by default
+	ServerAPIKey    = "test-api-key"
+	ServerAPISecret = "test-api-secret"
+
+	// apiPrefix is the path of the API on the exchange host
+	apiPrefix = "/api/v2"
+)
+
+// Server is a fake exchange serving canned responses for every endpoint supported by gop2b.
+// Signed requests are checked like the exchange does: the API key, the base64 payload
+// against the body, the HMAC signature, the request path and an increasing nonce.
+// Rejected requests get a 401 and are listed by Failures. SetChaos injects random
+// latency, rate limiting, server errors and broken bodies, to test retries and error paths.
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	apiKey    string
+	apiSecret string
+	responses map[string]string
+	errors    map[string]cannedError
+	latency   map[string]time.Duration
+	chaos     map[string]Chaos
+	rand      *rand.Rand
+	requests  map[string]int
+	nonce     int64
+	failures  []error
+}
+
+type cannedError struct {
+	status int
+	body   string
+}
+
+// NewServer starts a fake exchange, Close it when done
+func NewServer() *Server {
+	s := &Server{
+		apiKey:    ServerAPIKey,
+		apiSecret: ServerAPISecret,
+		responses: make(map[string]string, len(endpointFixtures)),
+		errors:    make(map[string]cannedError),
+		latency:   make(map[string]time.Duration),
+		chaos:     make(map[string]Chaos),
+		rand:      rand.New(rand.NewSource(1)),
+		requests:  make(map[string]int),
+	}
+	for path, name := range endpointFixtures {
+		body, err := Fixture(name)
+		if err != nil {
+			panic(err)
+		}
+		s.responses[path] = string(body)
+	}
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+// Client creates a client of the server with its credentials
+func (s *Server) Client(opts ...gop2b.Option) (gop2b.Client, error) {
+	s.mu.Lock()
+	key, secret := s.apiKey, s.apiSecret
+	s.mu.Unlock()
+	return gop2b.NewClient(key, secret, append([]gop2b.Option{gop2b.WithBaseURL(s.URL + apiPrefix)}, opts...)...)
+}
+
+// SetCredentials changes the accepted API key and secret
+func (s *Server) SetCredentials(apiKey, apiSecret string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.apiKey, s.apiSecret = apiKey, apiSecret
+}
+
+// SetResponse replaces the canned body of path, such as "/public/markets", served with status 200
+func (s *Server) SetResponse(path string, body string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.responses[path] = body
+}
+
+// SetError makes path answer with status and body until ClearError
+func (s *Server) SetError(path string, status int, body string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errors[path] = cannedError{status: status, body: body}
+}
+
+// ClearError restores the canned response of path
+func (s *Server) ClearError(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.errors, path)
+}
+
+// SetLatency delays every response of path by d
+func (s *Server) SetLatency(path string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency[path] = d
+}
+
+// Requests returns the amount of requests received for path
+func (s *Server) Requests(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[path]
+}
+
+// Failures returns the reasons signed requests were rejected for, in order
+func (s *Server) Failures() []error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]error(nil), s.failures...)
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, apiPrefix)
+	// reading the body up front lets the request context end when the client gives up
+	// during an injected delay
+	payload, err := io.ReadAll(r.Body)
+	if err != nil {
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(payload))
+	s.mu.Lock()
+	s.requests[path]++
+	delay := s.latency[path]
+	injected, failing := s.errors[path]
+	body, known := s.responses[path]
+	fault := s.drawFault(path)
+	delay += fault.delay
+	s.mu.Unlock()
+
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if failing {
+		w.WriteHeader(injected.status)
+		_, _ = io.WriteString(w, injected.body)
+		return
+	}
+	if fault.write(w) {
+		return
+	}
+	if !strings.HasPrefix(path, "/public/") {
+		if err := s.authenticate(r, path); err != nil {
+			s.mu.Lock()
+			s.failures = append(s.failures, err)
+			s.mu.Unlock()
+			writeError(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+	}
+	if !known {
+		writeError(w, http.StatusNotFound, "unknown endpoint "+path)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = io.WriteString(w, fault.mangle(body))
+}
+
+// authenticate checks a signed request the way the exchange does
+func (s *Server) authenticate(r *http.Request, path string) error {
+	if r.Method != http.MethodPost {
+		return fmt.Errorf("%s %s: private endpoints require POST", r.Method, path)
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if key := r.Header.Get(gop2b.HeaderXTxcAPIKey); key != s.apiKey {
+		return fmt.Errorf("%s: unknown API key %q", path, key)
+	}
+	payload := r.Header.Get(gop2b.HeaderXTxcPayload)
+	if expected, _ := gop2b.SignPayload(s.apiSecret, body); payload != expected {
+		return fmt.Errorf("%s: payload header doesn't match the body", path)
+	}
+	if !gop2b.VerifySignature(s.apiSecret, payload, r.Header.Get(gop2b.HeaderXTxcSignature)) {
+		return fmt.Errorf("%s: invalid signature", path)
+	}
+	var request gop2b.Request
+	if err := json.NewDecoder(bytes.NewReader(body)).Decode(&request); err != nil {
+		return fmt.Errorf("%s: invalid body: %v", path, err)
+	}
+	if request.Request != apiPrefix+path {
+		return fmt.Errorf("%s: request field is %q", path, request.Request)
+	}
+	nonce := int64(request.Nonce)
+	if nonce <= s.nonce {
+		return fmt.Errorf("%s: nonce %d not greater than %d", path, nonce, s.nonce)
+	}
+	s.nonce = nonce
+	return nil
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"success": false, "message": message, "result": []interface{}{}})
+}
@@ -0,0 +1,276 @@
+package gop2btest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// WsRequest is a request received by a WsServer
+type WsRequest struct {
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+	ID     int64             `json:"id"`
+}
+
+type wsAck struct {
+	result json.RawMessage
+	err    *wsAckError
+}
+
+type wsAckError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type wsServerConn struct {
+	conn    *websocket.Conn
+	writeMu sync.Mutex
+}
+
+// WsServer is a fake of the exchange websocket on httptest, for tests of WSClient and code
+// built on it. Requests are acknowledged like the exchange does: server.ping with "pong",
+// subscribe and unsubscribe requests with a success status and anything else with a null
+// result, unless set otherwise with SetAck or SetAckError. Notifications are only sent when
+// the test asks for them, right after a subscribe with OnSubscribe, by Send or on a schedule
+// with Every, so runs are deterministic.
+type WsServer struct {
+	// URL is the ws:// address to pass to gop2b.WithWSURL
+	URL string
+
+	server   *httptest.Server
+	upgrader websocket.Upgrader
+	stop     chan struct{}
+	stopOnce sync.Once
+	tickers  sync.WaitGroup
+
+	mu          sync.Mutex
+	conns       map[*wsServerConn]bool
+	connections int
+	requests    []WsRequest
+	acks        map[string]wsAck
+	onSubscribe map[string][]string
+	delay       time.Duration
+}
+
+// NewWsServer starts a websocket server, stop it with Close
+func NewWsServer() *WsServer {
+	s := &WsServer{
+		stop:        make(chan struct{}),
+		conns:       make(map[*wsServerConn]bool),
+		acks:        make(map[string]wsAck),
+		onSubscribe: make(map[string][]string),
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serve))
+	s.URL = "ws" + strings.TrimPrefix(s.server.URL, "http")
+	return s
+}
+
+// Close drops all connections and stops the server
+func (s *WsServer) Close() {
+	s.stopOnce.Do(func() { close(s.stop) })
+	s.tickers.Wait()
+	s.Disconnect()
+	s.server.Close()
+}
+
+// SetAck sets the result, raw JSON, replied to requests of method such as "depth.subscribe"
+func (s *WsServer) SetAck(method string, result string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.acks[method] = wsAck{result: json.RawMessage(result)}
+}
+
+// SetAckError makes the server reply to requests of method with an error
+func (s *WsServer) SetAckError(method string, code int, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.acks[method] = wsAck{err: &wsAckError{Code: code, Message: message}}
+}
+
+// OnSubscribe sets the raw frames sent after acknowledging every subscribe to channel, multi
+// market ones such as depth.subscribe_multi included, like the snapshot the exchange sends
+// first. Use Notification to build them.
+func (s *WsServer) OnSubscribe(channel string, frames ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onSubscribe[channel] = frames
+}
+
+// SetFrameDelay delays every frame the server writes by d, to simulate a slow connection
+func (s *WsServer) SetFrameDelay(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delay = d
+}
+
+// Notification builds the frame of a channel notification with params, as sent by the exchange
+func Notification(channel string, params ...interface{}) string {
+	if params == nil {
+		params = []interface{}{}
+	}
+	data, err := json.Marshal(map[string]interface{}{"method": channel + ".update", "params": params, "id": nil})
+	if err != nil {
+		panic(fmt.Sprintf("gop2btest: notification of %s: %v", channel, err))
+	}
+	return string(data)
+}
+
+// Send writes the raw frame to every connection. It doesn't have to be valid JSON, to test malformed messages.
+func (s *WsServer) Send(frame string) {
+	for _, c := range s.connsSnapshot() {
+		s.write(c, frame)
+	}
+}
+
+// Every sends frame(n) to every connection each interval, n counting from 0, until Close
+func (s *WsServer) Every(interval time.Duration, frame func(n int) string) {
+	s.tickers.Add(1)
+	go func() {
+		defer s.tickers.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for n := 0; ; n++ {
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+				s.Send(frame(n))
+			}
+		}
+	}()
+}
+
+// Disconnect drops all connections without a close frame, as a network failure would
+func (s *WsServer) Disconnect() {
+	for _, c := range s.connsSnapshot() {
+		c.conn.Close()
+	}
+}
+
+// Connections returns the amount of connections accepted so far, reconnects included
+func (s *WsServer) Connections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.connections
+}
+
+// Requests returns the requests of method received so far, of every method when empty
+func (s *WsServer) Requests(method string) []WsRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var result []WsRequest
+	for _, r := range s.requests {
+		if method == "" || r.Method == method {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+func (s *WsServer) connsSnapshot() []*wsServerConn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	conns := make([]*wsServerConn, 0, len(s.conns))
+	for c := range s.conns {
+		conns = append(conns, c)
+	}
+	return conns
+}
+
+func (s *WsServer) serve(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	c := &wsServerConn{conn: conn}
+	s.mu.Lock()
+	s.conns[c] = true
+	s.connections++
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, c)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var req WsRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			continue
+		}
+		s.mu.Lock()
+		s.requests = append(s.requests, req)
+		ack, ok := s.acks[req.Method]
+		var frames []string
+		if channel, found := subscribeChannel(req.Method); found {
+			frames = s.onSubscribe[channel]
+		}
+		s.mu.Unlock()
+		if !ok {
+			ack = defaultAck(req.Method)
+		}
+		reply, _ := json.Marshal(struct {
+			Error  *wsAckError     `json:"error"`
+			Result json.RawMessage `json:"result"`
+			ID     int64           `json:"id"`
+		}{ack.err, ack.result, req.ID})
+		if !s.write(c, string(reply)) {
+			return
+		}
+		if ack.err != nil {
+			continue
+		}
+		for _, frame := range frames {
+			if !s.write(c, frame) {
+				return
+			}
+		}
+	}
+}
+
+// subscribeChannel returns the channel subscribed to by method, depth for depth.subscribe
+// as well as depth.subscribe_multi
+func subscribeChannel(method string) (string, bool) {
+	if channel, found := strings.CutSuffix(method, ".subscribe"); found {
+		return channel, true
+	}
+	return strings.CutSuffix(method, ".subscribe_multi")
+}
+
+// defaultAck is the reply of the exchange to method
+func defaultAck(method string) wsAck {
+	switch {
+	case method == "server.ping":
+		return wsAck{result: json.RawMessage(`"pong"`)}
+	case strings.HasSuffix(method, ".subscribe"), strings.HasSuffix(method, ".subscribe_multi"), strings.HasSuffix(method, ".unsubscribe"):
+		return wsAck{result: json.RawMessage(`{"status":"success"}`)}
+	}
+	return wsAck{result: json.RawMessage(`null`)}
+}
+
+func (s *WsServer) write(c *wsServerConn, frame string) bool {
+	s.mu.Lock()
+	delay := s.delay
+	s.mu.Unlock()
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-s.stop:
+			return false
+		}
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.conn.WriteMessage(websocket.TextMessage, []byte(frame)) == nil
+}
@@ -0,0 +1,115 @@
+package gop2b
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// healthCheckTimeout bounds each component check of HealthCheck
+const healthCheckTimeout = 5 * time.Second
+
+// Health check component names
+const (
+	HealthREST      = "rest"
+	HealthWebsocket = "websocket"
+)
+
+// ComponentHealth is the result of checking a single component
+type ComponentHealth struct {
+	Name    string
+	Healthy bool
+	// Latency is the round trip of the check, also set when it failed
+	Latency time.Duration
+	Err     error
+}
+
+// HealthReport is the combined result of HealthCheck
+type HealthReport struct {
+	Healthy    bool
+	At         time.Time
+	Components []ComponentHealth
+}
+
+// Component returns the result of the component called name
+func (r HealthReport) Component(name string) (ComponentHealth, bool) {
+	for _, c := range r.Components {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return ComponentHealth{}, false
+}
+
+// WithHealthWebsocket makes HealthCheck check ws as well
+func WithHealthWebsocket(ws *WSClient) Option {
+	return func(c *client) {
+		c.healthWS = ws
+	}
+}
+
+// HealthCheck checks the REST API with an uncached request and, when a websocket was set with
+// WithHealthWebsocket, that it is connected and answers a ping. The checks run concurrently,
+// each bounded by five seconds and ctx. The error joins the errors of the failed components.
+func (c *client) HealthCheck(ctx context.Context) (HealthReport, error) {
+	checks := []healthCheck{{HealthREST, c.checkREST}}
+	if c.healthWS != nil {
+		checks = append(checks, healthCheck{HealthWebsocket, checkWebsocket(c.healthWS)})
+	}
+
+	report := HealthReport{At: time.Now(), Components: make([]ComponentHealth, len(checks))}
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+			defer cancel()
+			start := time.Now()
+			err := check.check(checkCtx)
+			report.Components[i] = ComponentHealth{Name: check.name, Healthy: err == nil, Latency: time.Since(start), Err: err}
+		}()
+	}
+	wg.Wait()
+
+	report.Healthy = true
+	var errs []error
+	for _, component := range report.Components {
+		if component.Err != nil {
+			report.Healthy = false
+			errs = append(errs, fmt.Errorf("%s: %w", component.Name, component.Err))
+		}
+	}
+	return report, errors.Join(errs...)
+}
+
+type healthCheck struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+// checkREST sends a single public request, bypassing the cache and retries
+func (c *client) checkREST(ctx context.Context) (err error) {
+	const path = "/public/markets"
+	spanCtx, span := c.tracing.start(ctx, path)
+	defer func() { c.tracing.end(spanCtx, span, err) }()
+	ctx, done := c.requestContext(spanCtx)
+	defer done()
+	defer func() { err = contextError(ctx, err) }()
+	var status Response
+	if err := c.getOnce(ctx, path, c.url+path, &status); err != nil {
+		return err
+	}
+	return status.Err()
+}
+
+func checkWebsocket(ws *WSClient) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		if state := ws.State(); state != ConnConnected {
+			return fmt.Errorf("connection %s", state)
+		}
+		return ws.Ping(ctx)
+	}
+}
@@ -0,0 +1,96 @@
+package gop2b_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/sutapurachina/gop2b"
+)
+
+// checkComponent fails the test when the component name of report isn't in the healthy state
+func checkComponent(t *testing.T, report gop2b.HealthReport, name string, healthy bool) {
+	t.Helper()
+	component, ok := report.Component(name)
+	if !ok {
+		t.Fatalf("no %s component in %+v", name, report)
+	}
+	if component.Healthy != healthy || (component.Err == nil) != healthy {
+		t.Errorf("%s healthy %v with error %v, want healthy %v", name, component.Healthy, component.Err, healthy)
+	}
+	if component.Latency <= 0 {
+		t.Errorf("%s latency %s", name, component.Latency)
+	}
+}
+
+func TestHealthCheckHealthy(t *testing.T) {
+	ws, _ := newTestWS(t)
+	client, _ := newTestClient(t, gop2b.WithHealthWebsocket(ws))
+	report, err := client.HealthCheck(context.Background())
+	if err != nil || !report.Healthy {
+		t.Fatalf("healthy %v, %v", report.Healthy, err)
+	}
+	if len(report.Components) != 2 {
+		t.Errorf("components %+v, want rest and websocket", report.Components)
+	}
+	checkComponent(t, report, gop2b.HealthREST, true)
+	checkComponent(t, report, gop2b.HealthWebsocket, true)
+}
+
+func TestHealthCheckRESTOnly(t *testing.T) {
+	client, server := newTestClient(t)
+	report, err := client.HealthCheck(context.Background())
+	if err != nil || !report.Healthy || len(report.Components) != 1 {
+		t.Fatalf("report %+v, %v, want only a healthy rest component", report, err)
+	}
+	if _, ok := report.Component(gop2b.HealthWebsocket); ok {
+		t.Error("websocket checked without WithHealthWebsocket")
+	}
+	// the check is never answered from the cache
+	if _, err := client.HealthCheck(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := server.Requests("/public/markets"); n != 2 {
+		t.Errorf("%d requests for two checks, want 2", n)
+	}
+}
+
+func TestHealthCheckRESTFailure(t *testing.T) {
+	ws, _ := newTestWS(t)
+	client, server := newTestClient(t, gop2b.WithHealthWebsocket(ws))
+	server.SetError("/public/markets", http.StatusServiceUnavailable, fixture(t, "error_maintenance.json"))
+	report, err := client.HealthCheck(context.Background())
+	if report.Healthy || !errors.Is(err, gop2b.ErrMaintenance) {
+		t.Fatalf("healthy %v, %v, want the maintenance of the rest component", report.Healthy, err)
+	}
+	checkComponent(t, report, gop2b.HealthREST, false)
+	checkComponent(t, report, gop2b.HealthWebsocket, true)
+}
+
+func TestHealthCheckWebsocketFailure(t *testing.T) {
+	t.Run("closed", func(t *testing.T) {
+		ws, _ := newTestWS(t)
+		client, _ := newTestClient(t, gop2b.WithHealthWebsocket(ws))
+		if err := ws.Close(); err != nil {
+			t.Fatal(err)
+		}
+		report, err := client.HealthCheck(context.Background())
+		if report.Healthy || err == nil {
+			t.Fatalf("healthy %v, %v, want the websocket down", report.Healthy, err)
+		}
+		checkComponent(t, report, gop2b.HealthREST, true)
+		checkComponent(t, report, gop2b.HealthWebsocket, false)
+	})
+	t.Run("ping", func(t *testing.T) {
+		ws, wsServer := newTestWS(t)
+		client, _ := newTestClient(t, gop2b.WithHealthWebsocket(ws))
+		wsServer.SetAckError("server.ping", 1, "overloaded")
+		report, err := client.HealthCheck(context.Background())
+		if report.Healthy || err == nil {
+			t.Fatalf("healthy %v, %v, want the failed ping", report.Healthy, err)
+		}
+		checkComponent(t, report, gop2b.HealthREST, true)
+		checkComponent(t, report, gop2b.HealthWebsocket, false)
+	})
+}
@@ -2,13 +2,20 @@ package gop2b
 
 import (
 	"bytes"
+	"context"
 	"crypto/hmac"
 	"crypto/sha512"
 	"encoding/base64"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -21,16 +28,72 @@ const (
 	HeaderXTxcSignature = "X-TXC-SIGNATURE"
 )
 
+// Signature returns the hex encoded HMAC-SHA512 of the base64 payload, as sent in HeaderXTxcSignature
+func Signature(apiSecret string, payloadBase64 string) string {
+	h := hmac.New(sha512.New, []byte(apiSecret))
+	h.Write([]byte(payloadBase64))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// VerifySignature reports whether signature matches the base64 payload signed with apiSecret,
+// comparing in constant time
+func VerifySignature(apiSecret string, payloadBase64 string, signature string) bool {
+	return hmac.Equal([]byte(Signature(apiSecret, payloadBase64)), []byte(signature))
+}
+
+// SignPayload returns the payload and signature headers the client attaches to a signed
+// request with body, for proxies and test servers checking requests
+func SignPayload(apiSecret string, body []byte) (payloadBase64, signature string) {
+	payloadBase64 = encodePayload(body)
+	return payloadBase64, Signature(apiSecret, payloadBase64)
+}
+
+// encodePayload returns body as sent in HeaderXTxcPayload
+func encodePayload(body []byte) string {
+	return base64.StdEncoding.EncodeToString(body)
+}
+
 type auth struct {
 	APIKey    string
 	APISecret string
 }
 
 type client struct {
-	http  *http.Client
-	auth  *auth
-	url   string
-	wsUrl string
+	http    *http.Client
+	auth    *auth
+	url     string
+	wsUrl   string
+	limiter *rateLimiter
+	cache   *responseCache
+	markets marketsCache
+	quotes  quoteCache
+	retry   retryPolicy
+	signer  Signer
+	metrics MetricsCollector
+	tracing SpanHooks
+	// onRequest and onResponse are the observers of WithRequestObserver and WithResponseObserver
+	onRequest  func(RequestInfo)
+	onResponse func(ResponseInfo)
+	stats      requestStats
+	capture    *exchangeCapture
+	// bannedUntil is the end of an IP ban in unix nanoseconds, zero when not banned
+	bannedUntil atomic.Int64
+
+	defaultQuote string
+	// maxOrderValue is the cap of WithMaxOrderValue, nil when not set
+	maxOrderValue *orderValueCap
+	// healthWS is the websocket checked by HealthCheck, nil when not set
+	healthWS *WSClient
+	// onPollError is the hook of WithPollErrorHook, nil when not set
+	onPollError func(market string, err error)
+	// strictEnums is set by WithStrictEnums
+	strictEnums bool
+
+	// ctx is cancelled by Shutdown, background tracks the goroutines it waits for
+	ctx          context.Context
+	cancel       context.CancelFunc
+	backgroundMu sync.Mutex
+	background   sync.WaitGroup
 }
 
 type response struct {
@@ -41,15 +104,15 @@ type response struct {
 }
 
 type wsRequest struct {
-	Method string   `json:"method"`
-	Params []string `json:"params"`
-	Id     int64    `json:"id"`
+	Method string        `json:"method"`
+	Params []interface{} `json:"params"`
+	Id     int64         `json:"id"`
 }
 
-func newWsRequest(method string, params ...string) *wsRequest {
+func newWsRequest(method string, params ...interface{}) *wsRequest {
 	req := &wsRequest{
 		Method: method,
-		Params: []string{},
+		Params: []interface{}{},
 	}
 	for _, p := range params {
 		req.Params = append(req.Params, p)
@@ -75,27 +138,26 @@ func checkHTTPStatus(resp response, expected ...int) error {
 	return fmt.Errorf("http response status != %+v, got %d", expected, resp.StatusCode)
 }
 
-func mergeHeaders(firstHeaders map[string]string, secondHeaders map[string]string) map[string]string {
-	if secondHeaders == nil {
-		return firstHeaders
-	}
-	if firstHeaders == nil {
-		return secondHeaders
-	}
-	for k, v := range secondHeaders {
-		if firstHeaders[k] == "" {
-			firstHeaders[k] = v
+// mergeHeaders merges the headers by canonical name, so each is sent once whatever the case
+// of its name. A non-empty header of firstHeaders wins over the same one of secondHeaders.
+func mergeHeaders(firstHeaders map[string]string, secondHeaders map[string]string) http.Header {
+	headers := make(http.Header, len(firstHeaders)+len(secondHeaders))
+	for _, source := range []map[string]string{secondHeaders, firstHeaders} {
+		for k, v := range source {
+			if v != "" || headers.Get(k) == "" {
+				headers.Set(k, v)
+			}
 		}
 	}
-	return firstHeaders
+	return headers
 }
 
-func (c *client) sendPost(url string, additionalHeaders map[string]string, body io.Reader) (*response, error) {
+func (c *client) sendPost(ctx context.Context, url string, additionalHeaders map[string]string, body io.Reader) (*response, error) {
 	bodyBytes, err := io.ReadAll(body)
 	if err != nil {
 		return nil, err
 	}
-	req, err := http.NewRequest("POST", url, bytes.NewReader(bodyBytes))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(bodyBytes))
 	if err != nil {
 		return &response{}, fmt.Errorf("error creating POST request, %v", err)
 	}
@@ -103,20 +165,19 @@ func (c *client) sendPost(url string, additionalHeaders map[string]string, body
 	if additionalHeaders == nil {
 		additionalHeaders = make(map[string]string)
 	}
-	additionalHeaders[HeaderXTxcPayload] = base64.StdEncoding.EncodeToString(bodyBytes)
+	payload := encodePayload(bodyBytes)
+	additionalHeaders[c.signer.PayloadHeader()] = payload
 
 	if c.auth != nil {
-		h := hmac.New(sha512.New, []byte(c.auth.APISecret))
-		h.Write([]byte(additionalHeaders[HeaderXTxcPayload]))
-		signature := hex.EncodeToString(h.Sum(nil))
-		additionalHeaders[HeaderXTxcSignature] = signature
+		additionalHeaders[HeaderXTxcAPIKey] = c.auth.APIKey
+		additionalHeaders[c.signer.SignatureHeader()] = c.signer.Sign(c.auth.APISecret, []byte(payload))
 	}
 
 	return c.sendRequest(req, additionalHeaders)
 }
 
-func (c *client) sendGet(url string, additionalHeaders map[string]string) (*response, error) {
-	req, err := http.NewRequest("GET", url, nil)
+func (c *client) sendGet(ctx context.Context, url string, additionalHeaders map[string]string) (*response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 
 	if err != nil {
 		return &response{}, fmt.Errorf("error creating GET request, %v", err)
@@ -125,24 +186,63 @@ func (c *client) sendGet(url string, additionalHeaders map[string]string) (*resp
 	return c.sendRequest(req, additionalHeaders)
 }
 
-func (c *client) sendRequest(request *http.Request, additionalHeaders map[string]string) (*response, error) {
-
-	for k, v := range additionalHeaders {
-		request.Header.Add(k, v)
-	}
-
+func (c *client) sendRequest(request *http.Request, additionalHeaders map[string]string) (result *response, err error) {
 	thisHeaders := map[string]string{}
 	thisHeaders["Content-type"] = "application/json"
-	if c.auth != nil {
-		thisHeaders[HeaderXTxcAPIKey] = c.auth.APIKey
+	for k, v := range mergeHeaders(additionalHeaders, thisHeaders) {
+		request.Header[k] = v
+	}
+
+	endpoint := c.endpoint(request)
+	attempt := attemptFromContext(request.Context())
+	c.observeRequest(request, endpoint, attempt)
+	start := time.Now()
+	status := 0
+	var respHeader http.Header
+	var respSize int64
+	defer func() {
+		c.observeResponse(ResponseInfo{
+			Method:   request.Method,
+			Endpoint: endpoint,
+			Header:   respHeader.Clone(),
+			BodySize: respSize,
+			Status:   status,
+			Duration: time.Since(start),
+			Attempt:  attempt,
+			Err:      err,
+		})
+	}()
+
+	if c.ctx.Err() != nil {
+		return nil, ErrClientShutdown
+	}
+	if err := c.banErr(); err != nil {
+		return nil, err
 	}
-	headers := mergeHeaders(additionalHeaders, thisHeaders)
-	for k, v := range headers {
-		request.Header.Add(k, v)
+	if c.limiter != nil {
+		start := time.Now()
+		err := c.limiter.wait(request.Context())
+		c.metrics.ObserveRateLimitWait(time.Since(start))
+		c.metrics.ObserveRateLimitRemaining(c.limiter.remaining())
+		spanFromContext(request.Context()).observeRateLimitWait(time.Since(start))
+		if err != nil {
+			return nil, err
+		}
 	}
+
+	sent := time.Now()
 	resp, err := c.http.Do(request)
+	d := time.Since(sent)
+	if resp != nil {
+		status = resp.StatusCode
+		respHeader = resp.Header
+		respSize = resp.ContentLength
+	}
+	c.metrics.ObserveRequest(endpoint, status, d)
+	c.stats.observe(endpoint, status, d)
+	spanFromContext(request.Context()).observeStatus(status)
+	c.capture.record(request, endpoint, resp, err, sent, d)
 	if err != nil {
-		fmt.Println(fmt.Sprintf("erro: %v", err))
 		return nil, err
 	}
 	return &response{
@@ -152,3 +252,214 @@ func (c *client) sendRequest(request *http.Request, additionalHeaders map[string
 		Body:       resp.Body,
 	}, nil
 }
+
+// maxResponseSize bounds the size of a response body, whether it has a Content-Length or is chunked
+const maxResponseSize = 32 << 20
+
+// readBody reads the whole body, failing past maxResponseSize, and closes it
+func readBody(resp *response) ([]byte, error) {
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxResponseSize {
+		return nil, fmt.Errorf("response body larger than %d bytes", maxResponseSize)
+	}
+	return body, nil
+}
+
+// signedRequest is implemented by every request struct embedding Request
+type signedRequest interface {
+	prepare(path string)
+}
+
+// endpointer is implemented by the request structs of the endpoint methods, returning their
+// path below /api/v2, so a request can't be sent to the path of another endpoint
+type endpointer interface {
+	endpointPath() string
+}
+
+// endpointRequest is a signed request knowing its endpoint
+type endpointRequest interface {
+	signedRequest
+	endpointer
+}
+
+// postEndpoint sends request signed to its own endpoint path
+func (c *client) postEndpoint(ctx context.Context, request endpointRequest, out interface{}) error {
+	return c.postSigned(ctx, request.endpointPath(), request, out)
+}
+
+func (c *client) postSigned(ctx context.Context, path string, request signedRequest, out interface{}) (err error) {
+	spanCtx, span := c.tracing.start(ctx, path)
+	defer func() { c.tracing.end(spanCtx, span, err) }()
+	ctx, done := c.requestContext(spanCtx)
+	defer done()
+	defer func() { err = contextError(ctx, err) }()
+	request.prepare(path)
+	asJSON, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	resp, err := c.sendPost(ctx, c.url+path, nil, bytes.NewReader(asJSON))
+	if err != nil {
+		return err
+	}
+	bodyBytes, err := readBody(resp)
+	if err != nil {
+		return err
+	}
+	if err := c.checkResponse(resp, bodyBytes); err != nil {
+		return err
+	}
+	if err := c.decodeResponse(bodyBytes, out); err != nil {
+		c.stats.decodeError(path)
+		return err
+	}
+	return nil
+}
+
+// signedBody is a caller-built body of PostSigned, as a JSON object
+type signedBody map[string]json.RawMessage
+
+func (b signedBody) prepare(path string) {
+	var r Request
+	r.prepare(path)
+	b["request"], _ = json.Marshal(r.Request)
+	b["nonce"], _ = json.Marshal(r.Nonce)
+}
+
+// PostSigned sends body, which must encode to a JSON object, signed to path below /api/v2,
+// for endpoints without a method of their own. The request and nonce fields are set like
+// for every signed request. A response with success false returns its message as error,
+// otherwise the response is decoded into out unless it is nil.
+func (c *client) PostSigned(ctx context.Context, path string, body interface{}, out interface{}) error {
+	if !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("%w: path %q must start with /", ErrInvalidRequest, path)
+	}
+	request := signedBody{}
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, &request); err != nil || request == nil {
+			return fmt.Errorf("%w: body must encode to a JSON object", ErrInvalidRequest)
+		}
+	}
+	var raw json.RawMessage
+	if err := c.postSigned(ctx, path, request, &raw); err != nil {
+		return err
+	}
+	var status Response
+	if err := json.Unmarshal(raw, &status); err != nil {
+		return err
+	}
+	if err := status.Err(); err != nil {
+		return err
+	}
+	if out == nil {
+		return nil
+	}
+	return c.decodeResponse(raw, out)
+}
+
+// resultResponse is implemented by every response struct embedding Response
+type resultResponse interface {
+	setResultPresent(present bool)
+}
+
+// decodeResponse unmarshals body into out and records whether it carried a non-null result
+func decodeResponse(body []byte, out interface{}) error {
+	if err := json.Unmarshal(body, out); err != nil {
+		return &decodeError{err: err}
+	}
+	if r, ok := out.(resultResponse); ok {
+		var raw struct {
+			Result json.RawMessage `json:"result"`
+		}
+		if json.Unmarshal(body, &raw) == nil {
+			r.setResultPresent(len(raw.Result) > 0 && string(raw.Result) != "null")
+		}
+	}
+	return nil
+}
+
+// decodeResponse is decodeResponse checking the enums of out with WithStrictEnums
+func (c *client) decodeResponse(body []byte, out interface{}) error {
+	if err := decodeResponse(body, out); err != nil {
+		return err
+	}
+	if c.strictEnums {
+		if err := checkEnums(reflect.ValueOf(out)); err != nil {
+			return &decodeError{err: err}
+		}
+	}
+	return nil
+}
+
+func (c *client) getPublic(ctx context.Context, path string, params url.Values, out interface{}) (err error) {
+	u := c.url + path
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+	if body, ok := c.cache.get(u); ok {
+		return c.decodeResponse(body, out)
+	}
+	spanCtx, span := c.tracing.start(ctx, path)
+	defer func() { c.tracing.end(spanCtx, span, err) }()
+	ctx, done := c.requestContext(spanCtx)
+	defer done()
+	return c.withRetry(ctx, path, func(ctx context.Context) error {
+		return c.getOnce(ctx, path, u, out)
+	})
+}
+
+// GetPublic sends an unsigned GET to path below /api/v2 with params, for public endpoints
+// without a method of their own. It is cached and retried like the other public requests.
+// A response with success false returns its message as error, otherwise the response is
+// decoded into out unless it is nil.
+func (c *client) GetPublic(ctx context.Context, path string, params url.Values, out interface{}) error {
+	if !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("%w: path %q must start with /", ErrInvalidRequest, path)
+	}
+	var raw json.RawMessage
+	if err := c.getPublic(ctx, path, params, &raw); err != nil {
+		return err
+	}
+	var status Response
+	if err := json.Unmarshal(raw, &status); err != nil {
+		return err
+	}
+	if err := status.Err(); err != nil {
+		return err
+	}
+	if out == nil {
+		return nil
+	}
+	return c.decodeResponse(raw, out)
+}
+
+func (c *client) getOnce(ctx context.Context, path string, u string, out interface{}) error {
+	resp, err := c.sendGet(ctx, u, nil)
+	if err != nil {
+		return err
+	}
+	bodyBytes, err := readBody(resp)
+	if err != nil {
+		return err
+	}
+	if err := c.checkResponse(resp, bodyBytes); err != nil {
+		return err
+	}
+	if err := c.decodeResponse(bodyBytes, out); err != nil {
+		c.stats.decodeError(path)
+		return err
+	}
+	var status Response
+	if json.Unmarshal(bodyBytes, &status) == nil && status.Success {
+		c.cache.put(u, bodyBytes)
+	}
+	return nil
+}
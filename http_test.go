@@ -0,0 +1,278 @@
+package gop2b_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/sutapurachina/gop2b"
+)
+
+func TestSignature(t *testing.T) {
+	tests := []struct {
+		secret, payload, signature string
+	}{
+		// RFC 4231 test case 2
+		{
+			"Jefe", "what do ya want for nothing?",
+			"164b7a7bfcf819e2e395fbe73b56e0a387bd64222e831fd610270cd7ea2505549758bf75c05a994a6d034f65f8f0e6fdcaeab1a34d4a6b4b636e070a38bce737",
+		},
+		// {"request":"/api/v2/account/balances","nonce":"1700000000000"}, signed with openssl dgst -sha512 -hmac
+		{
+			"test-api-secret", "eyJyZXF1ZXN0IjoiL2FwaS92Mi9hY2NvdW50L2JhbGFuY2VzIiwibm9uY2UiOiIxNzAwMDAwMDAwMDAwIn0=",
+			"1be5522a8957b0d240818a8765d9f170582c074ed3901d953e17a7abc1c54d8828b90d8ae8831e5c60bd7b22977a69ba2025b93938bd7b00a81d124a7625c8ca",
+		},
+		{
+			"key", "",
+			"84fa5aa0279bbc473267d05a53ea03310a987cecc4c1535ff29b6d76b8f1444a728df3aadb89d4a9a6709e1998f373566e8f824a8ca93b1821f0b69bc2a2f65e",
+		},
+	}
+	for _, tt := range tests {
+		if got := gop2b.Signature(tt.secret, tt.payload); got != tt.signature {
+			t.Errorf("Signature(%q, %q) = %s, want %s", tt.secret, tt.payload, got, tt.signature)
+		}
+		if !gop2b.VerifySignature(tt.secret, tt.payload, tt.signature) {
+			t.Errorf("VerifySignature(%q, %q) rejects its signature", tt.secret, tt.payload)
+		}
+		if gop2b.VerifySignature(tt.secret+"x", tt.payload, tt.signature) {
+			t.Errorf("VerifySignature accepts the signature of %q with another secret", tt.payload)
+		}
+		if gop2b.VerifySignature(tt.secret, tt.payload, tt.signature[:len(tt.signature)-1]) {
+			t.Errorf("VerifySignature accepts a truncated signature of %q", tt.payload)
+		}
+	}
+}
+
+// capturedRequest is a request received by a captureServer
+type capturedRequest struct {
+	method string
+	path   string
+	header http.Header
+	body   []byte
+}
+
+// newCaptureServer starts a server answering every request with body, recording the requests
+func newCaptureServer(t *testing.T, body string) (*httptest.Server, func() []capturedRequest) {
+	t.Helper()
+	var mu sync.Mutex
+	var requests []capturedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, capturedRequest{r.Method, r.URL.Path, r.Header.Clone(), data})
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []capturedRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]capturedRequest(nil), requests...)
+	}
+}
+
+func TestSignedRequestHeaders(t *testing.T) {
+	server, requests := newCaptureServer(t, `{"success":true,"message":"","result":{}}`)
+	client, err := gop2b.NewClient("key", "secret", gop2b.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.PostBalances(&gop2b.AccountBalancesRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	r := requests()[0]
+	payload := r.header.Get(gop2b.HeaderXTxcPayload)
+	if payload != base64.StdEncoding.EncodeToString(r.body) {
+		t.Errorf("payload header %s isn't the base64 body %s", payload, r.body)
+	}
+	if expected, signature := gop2b.SignPayload("secret", r.body); payload != expected || !gop2b.VerifySignature("secret", payload, signature) {
+		t.Errorf("SignPayload disagrees with the sent payload")
+	}
+	if signature := r.header.Get(gop2b.HeaderXTxcSignature); signature != gop2b.Signature("secret", payload) {
+		t.Errorf("signature header %s, want Signature of the payload", signature)
+	}
+	if key := r.header.Get(gop2b.HeaderXTxcAPIKey); key != "key" {
+		t.Errorf("API key header %q", key)
+	}
+}
+
+func TestPostSigned(t *testing.T) {
+	client, server := newTestClient(t)
+	server.SetResponse("/account/custom", `{"success":true,"message":"","result":{"id":42,"name":"custom"}}`)
+	var out struct {
+		Result struct {
+			ID   int    `json:"id"`
+			Name string `json:"name"`
+		} `json:"result"`
+	}
+	body := struct {
+		Market string `json:"market"`
+	}{"ETH_BTC"}
+	if err := client.PostSigned(context.Background(), "/account/custom", body, &out); err != nil {
+		t.Fatalf("%v, server failures %v", err, server.Failures())
+	}
+	if out.Result.ID != 42 || out.Result.Name != "custom" {
+		t.Errorf("decoded %+v", out.Result)
+	}
+	// the fake exchange checks the signature, the request field and the nonce
+	if err := client.PostSigned(context.Background(), "/account/custom", nil, nil); err != nil {
+		t.Fatalf("second call: %v, server failures %v", err, server.Failures())
+	}
+	if n := server.Requests("/account/custom"); n != 2 {
+		t.Errorf("%d requests, want 2", n)
+	}
+}
+
+func TestPostSignedBody(t *testing.T) {
+	server, requests := newCaptureServer(t, `{"success":true,"message":"","result":{}}`)
+	client, err := gop2b.NewClient("key", "secret", gop2b.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := map[string]interface{}{"market": "ETH_BTC", "limit": 10}
+	if err := client.PostSigned(context.Background(), "/account/custom", body, nil); err != nil {
+		t.Fatal(err)
+	}
+	r := requests()[0]
+	if r.method != http.MethodPost || r.path != "/account/custom" {
+		t.Errorf("sent %s %s", r.method, r.path)
+	}
+	var sent map[string]interface{}
+	if err := json.Unmarshal(r.body, &sent); err != nil {
+		t.Fatal(err)
+	}
+	if sent["market"] != "ETH_BTC" || sent["limit"] != float64(10) || sent["request"] != "/api/v2/account/custom" {
+		t.Errorf("sent body %s", r.body)
+	}
+	if _, ok := sent["nonce"]; !ok {
+		t.Errorf("sent body %s without nonce", r.body)
+	}
+	if signature := r.header.Get(gop2b.HeaderXTxcSignature); signature != gop2b.Signature("secret", r.header.Get(gop2b.HeaderXTxcPayload)) {
+		t.Errorf("signature header %s doesn't sign the payload", signature)
+	}
+}
+
+func TestPostSignedErrors(t *testing.T) {
+	client, server := newTestClient(t)
+	server.SetResponse("/account/custom", fixture(t, "error_invalid_market.json"))
+	var apiErr *gop2b.APIError
+	if err := client.PostSigned(context.Background(), "/account/custom", nil, nil); !errors.As(err, &apiErr) {
+		t.Errorf("error %v, want the *APIError of the response", err)
+	}
+	if err := client.PostSigned(context.Background(), "account/custom", nil, nil); !errors.Is(err, gop2b.ErrInvalidRequest) {
+		t.Errorf("error %v for a relative path, want ErrInvalidRequest", err)
+	}
+	if err := client.PostSigned(context.Background(), "/account/custom", []int{1}, nil); !errors.Is(err, gop2b.ErrInvalidRequest) {
+		t.Errorf("error %v for an array body, want ErrInvalidRequest", err)
+	}
+}
+
+func TestGetPublic(t *testing.T) {
+	client, _ := newTestClient(t)
+	var ticker gop2b.TickerResp
+	if err := client.GetPublic(context.Background(), "/public/ticker", url.Values{"market": {"ETH_BTC"}}, &ticker); err != nil {
+		t.Fatal(err)
+	}
+	if !ticker.Success || ticker.Result.Last.IsZero() {
+		t.Errorf("decoded %+v", ticker)
+	}
+	if err := client.GetPublic(context.Background(), "public/ticker", nil, nil); !errors.Is(err, gop2b.ErrInvalidRequest) {
+		t.Errorf("error %v for a relative path, want ErrInvalidRequest", err)
+	}
+}
+
+func TestGetPublicIsUnsigned(t *testing.T) {
+	server, requests := newCaptureServer(t, `{"success":true,"message":"","result":[]}`)
+	client, err := gop2b.NewClient("key", "secret", gop2b.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.GetPublic(context.Background(), "/public/custom", url.Values{"market": {"ETH_BTC"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	r := requests()[0]
+	if r.method != http.MethodGet || r.path != "/public/custom" || len(r.body) != 0 {
+		t.Errorf("sent %s %s with body %q", r.method, r.path, r.body)
+	}
+	for _, name := range []string{gop2b.HeaderXTxcAPIKey, gop2b.HeaderXTxcPayload, gop2b.HeaderXTxcSignature} {
+		if value, ok := r.header[http.CanonicalHeaderKey(name)]; ok {
+			t.Errorf("public request sent %s: %q", name, value)
+		}
+	}
+}
+
+// lowerCaseSigner names its headers in lower case, which must still be sent once each
+type lowerCaseSigner struct{ gop2b.HMACSHA512Signer }
+
+func (lowerCaseSigner) PayloadHeader() string   { return "x-txc-payload" }
+func (lowerCaseSigner) SignatureHeader() string { return "x-txc-signature" }
+
+func TestRequestHeadersSentOnce(t *testing.T) {
+	for _, signer := range []gop2b.Signer{gop2b.HMACSHA512Signer{}, lowerCaseSigner{}} {
+		server, requests := newCaptureServer(t, `{"success":true,"message":"","result":{}}`)
+		client, err := gop2b.NewClient("key", "secret", gop2b.WithBaseURL(server.URL), gop2b.WithSigner(signer))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.PostBalances(&gop2b.AccountBalancesRequest{}); err != nil {
+			t.Fatal(err)
+		}
+		_, _ = client.GetMarkets(context.Background())
+		for _, r := range requests() {
+			for name, values := range r.header {
+				if len(values) != 1 {
+					t.Errorf("%T: %s %s sent %s %d times: %q", signer, r.method, r.path, name, len(values), values)
+				}
+			}
+			if r.header.Get("Content-Type") != "application/json" {
+				t.Errorf("%T: %s %s Content-Type %q", signer, r.method, r.path, r.header.Get("Content-Type"))
+			}
+		}
+	}
+}
+
+func TestChunkedResponse(t *testing.T) {
+	body := fixture(t, "public_markets.json")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher := w.(http.Flusher)
+		// flushing before the end leaves the length unknown, so the body is sent chunked
+		for i := 0; i < len(body); i += 64 {
+			_, _ = io.WriteString(w, body[i:min(i+64, len(body))])
+			flusher.Flush()
+		}
+	}))
+	defer server.Close()
+	var mu sync.Mutex
+	var infos []gop2b.ResponseInfo
+	client, err := gop2b.NewClient("", "", gop2b.WithBaseURL(server.URL), gop2b.WithResponseObserver(func(info gop2b.ResponseInfo) {
+		mu.Lock()
+		defer mu.Unlock()
+		infos = append(infos, info)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.GetMarkets(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Success || len(resp.Result) == 0 {
+		t.Fatalf("response %+v, want the markets", resp)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(infos) != 1 {
+		t.Fatalf("%d responses observed, want 1", len(infos))
+	}
+	if infos[0].BodySize != -1 || infos[0].Status != http.StatusOK {
+		t.Errorf("observed status %d, body size %d, want 200 and -1", infos[0].Status, infos[0].BodySize)
+	}
+}
@@ -0,0 +1,49 @@
+package gop2b
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// OrderID is the id of an order. The exchange sends ids as numbers or strings depending on
+// the endpoint and the websocket, both decode, and they are always encoded as numbers.
+type OrderID int64
+
+func (id OrderID) String() string {
+	return strconv.FormatInt(int64(id), 10)
+}
+
+// UnmarshalJSON accepts the id as number or string, null being zero
+func (id *OrderID) UnmarshalJSON(data []byte) error {
+	v, err := unmarshalID(data)
+	if err != nil {
+		return fmt.Errorf("order id: %w", err)
+	}
+	*id = OrderID(v)
+	return nil
+}
+
+// DealID is the id of a deal, decoded and encoded like OrderID
+type DealID int64
+
+func (id DealID) String() string {
+	return strconv.FormatInt(int64(id), 10)
+}
+
+// UnmarshalJSON accepts the id as number or string, null being zero
+func (id *DealID) UnmarshalJSON(data []byte) error {
+	v, err := unmarshalID(data)
+	if err != nil {
+		return fmt.Errorf("deal id: %w", err)
+	}
+	*id = DealID(v)
+	return nil
+}
+
+func unmarshalID(data []byte) (int64, error) {
+	var n flexNumber
+	if err := n.UnmarshalJSON(data); err != nil {
+		return 0, err
+	}
+	return n.Int64()
+}
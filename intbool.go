@@ -0,0 +1,27 @@
+package gop2b
+
+import (
+	"fmt"
+	"strings"
+)
+
+// IntBool is a flag the exchange may send as true/false, 1/0 or "1"/"0", for example in
+// the result structs of endpoints read with GetPublic or PostSigned. It encodes as a JSON bool.
+type IntBool bool
+
+// UnmarshalJSON accepts booleans, the numbers 0 and 1 and the same as strings, null being false
+func (b *IntBool) UnmarshalJSON(data []byte) error {
+	s := strings.TrimSpace(string(data))
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		s = strings.TrimSpace(s[1 : len(s)-1])
+	}
+	switch strings.ToLower(s) {
+	case "true", "1":
+		*b = true
+	case "false", "0", "null", "":
+		*b = false
+	default:
+		return fmt.Errorf("invalid flag %s", data)
+	}
+	return nil
+}
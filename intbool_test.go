@@ -0,0 +1,57 @@
+package gop2b_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/sutapurachina/gop2b"
+)
+
+func TestIntBoolUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    gop2b.IntBool
+		wantErr bool
+	}{
+		{`true`, true, false},
+		{`false`, false, false},
+		{`1`, true, false},
+		{`0`, false, false},
+		{`"1"`, true, false},
+		{`"0"`, false, false},
+		{`"true"`, true, false},
+		{`"False"`, false, false},
+		{`" 1 "`, true, false},
+		{`""`, false, false},
+		{`null`, false, false},
+		{`2`, false, true},
+		{`-1`, false, true},
+		{`1.0`, false, true},
+		{`"yes"`, false, true},
+		{`"2"`, false, true},
+		{`[]`, false, true},
+		{`{}`, false, true},
+	}
+	for _, tt := range tests {
+		var got struct {
+			Flag gop2b.IntBool `json:"flag"`
+		}
+		err := json.Unmarshal([]byte(`{"flag":`+tt.input+`}`), &got)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s decoded to %v, want an error", tt.input, got.Flag)
+			}
+			continue
+		}
+		if err != nil || got.Flag != tt.want {
+			t.Errorf("%s decoded to %v, %v, want %v", tt.input, got.Flag, err, tt.want)
+		}
+	}
+}
+
+func TestIntBoolMarshalJSON(t *testing.T) {
+	data, err := json.Marshal(map[string]gop2b.IntBool{"on": true, "off": false})
+	if err != nil || string(data) != `{"off":false,"on":true}` {
+		t.Errorf("encoded as %s, %v, want JSON bools", data, err)
+	}
+}
@@ -0,0 +1,249 @@
+package gop2b
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Kline is a single candle. The exchange sends it as
+// [time, open, close, high, low, volume, deal, market] where volume is in stock and deal in money.
+type Kline struct {
+	Time   time.Time
+	Open   decimal.Decimal
+	Close  decimal.Decimal
+	High   decimal.Decimal
+	Low    decimal.Decimal
+	Volume decimal.Decimal
+	Deal   decimal.Decimal
+	Market string
+	// Interval is the candle length, empty when it isn't a native interval
+	Interval KlineInterval
+	// Closed is set once the period of the candle has ended, so its values are final.
+	// It is always false for a candle without Interval.
+	Closed bool
+	// Incomplete is set by AggregateKlines when source candles are missing from the bucket
+	Incomplete bool
+}
+
+// End returns the end of the candle period, Time for a candle without Interval
+func (k Kline) End() time.Time {
+	return k.Time.Add(k.Interval.Duration())
+}
+
+// Range returns high minus low
+func (k Kline) Range() decimal.Decimal {
+	return k.High.Sub(k.Low)
+}
+
+// Body returns close minus open, negative for a falling candle
+func (k Kline) Body() decimal.Decimal {
+	return k.Close.Sub(k.Open)
+}
+
+// IsBullish reports whether the candle closed above its open
+func (k Kline) IsBullish() bool {
+	return k.Close.GreaterThan(k.Open)
+}
+
+// setInterval sets Interval and derives Closed from the end of the period and now
+func (k *Kline) setInterval(interval KlineInterval, now time.Time) {
+	k.Interval = interval
+	k.Closed = interval.Duration() > 0 && !now.Before(k.End())
+}
+
+// UnmarshalJSON decodes the array representation of a candle
+func (k *Kline) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if len(raw) < 7 {
+		return fmt.Errorf("kline: expected at least 7 values, got %d", len(raw))
+	}
+	var ts ExchangeTimeSeconds
+	if err := json.Unmarshal(raw[0], &ts); err != nil {
+		return fmt.Errorf("kline time: %v", err)
+	}
+	k.Time = ts.Time
+	fields := []*decimal.Decimal{&k.Open, &k.Close, &k.High, &k.Low, &k.Volume, &k.Deal}
+	for i, f := range fields {
+		if err := f.UnmarshalJSON(raw[i+1]); err != nil {
+			return fmt.Errorf("kline value %d: %v", i+1, err)
+		}
+	}
+	if len(raw) > 7 {
+		if err := json.Unmarshal(raw[7], &k.Market); err != nil {
+			return fmt.Errorf("kline market: %v", err)
+		}
+	}
+	return nil
+}
+
+// MarshalJSON encodes the candle in the exchange array representation
+func (k Kline) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{k.Time.Unix(), k.Open, k.Close, k.High, k.Low, k.Volume, k.Deal, k.Market})
+}
+
+// GapPolicy defines how AggregateKlines treats source candles missing from a bucket
+type GapPolicy int
+
+const (
+	// GapMarkIncomplete keeps buckets with missing candles and sets their Incomplete flag
+	GapMarkIncomplete GapPolicy = iota
+	// GapFillPrevious fills missing candles between two known candles with flat candles at the
+	// previous close and zero volume. Missing candles before the first or after the last known
+	// candle can't be filled and still mark their bucket incomplete.
+	GapFillPrevious
+	// GapDrop leaves out buckets with missing candles
+	GapDrop
+)
+
+// KlineAggregator merges candles into larger buckets aligned to the unix epoch
+type KlineAggregator struct {
+	// Source is the interval of the input candles, inferred from the smallest
+	// distance between consecutive candles when zero
+	Source time.Duration
+	Policy GapPolicy
+}
+
+// AggregateKlines merges candles into buckets of target length, marking buckets with missing candles as incomplete
+func AggregateKlines(in []Kline, target time.Duration) ([]Kline, error) {
+	return KlineAggregator{}.Aggregate(in, target)
+}
+
+// Aggregate merges candles into buckets of target length aligned to the unix epoch.
+// target must be a multiple of the source interval.
+func (a KlineAggregator) Aggregate(in []Kline, target time.Duration) ([]Kline, error) {
+	if target <= 0 {
+		return nil, errors.New("target interval must be positive")
+	}
+	if len(in) == 0 {
+		return nil, nil
+	}
+	candles := make([]Kline, len(in))
+	copy(candles, in)
+	sort.Slice(candles, func(i, j int) bool { return candles[i].Time.Before(candles[j].Time) })
+
+	source := a.Source
+	for i := 1; i < len(candles); i++ {
+		d := candles[i].Time.Sub(candles[i-1].Time)
+		if d == 0 {
+			return nil, fmt.Errorf("duplicate candle at %s", candles[i].Time)
+		}
+		if a.Source == 0 && (source == 0 || d < source) {
+			source = d
+		}
+	}
+	if source <= 0 {
+		return nil, errors.New("source interval can't be inferred from a single candle")
+	}
+	if target%source != 0 {
+		return nil, fmt.Errorf("target interval %s is not a multiple of source interval %s", target, source)
+	}
+	for _, k := range candles {
+		if k.Time.Sub(alignToEpoch(k.Time, source)) != 0 {
+			return nil, fmt.Errorf("candle at %s is not aligned to the source interval %s", k.Time, source)
+		}
+	}
+
+	first, last := candles[0].Time, candles[len(candles)-1].Time
+	perBucket := int(target / source)
+	var out []Kline
+	next := 0
+	var prevClose decimal.Decimal
+	for start := alignToEpoch(first, target); !start.After(last); start = start.Add(target) {
+		var bucket Kline
+		started, incomplete := false, false
+		for slot := 0; slot < perBucket; slot++ {
+			at := start.Add(time.Duration(slot) * source)
+			var k Kline
+			switch {
+			case next < len(candles) && candles[next].Time.Equal(at):
+				k = candles[next]
+				next++
+			case a.Policy == GapFillPrevious && next > 0 && at.Before(last):
+				prev := candles[next-1]
+				k = Kline{Time: at, Open: prevClose, Close: prevClose, High: prevClose, Low: prevClose, Market: prev.Market,
+					Interval: prev.Interval, Closed: true}
+			default:
+				incomplete = true
+				continue
+			}
+			prevClose = k.Close
+			if !started {
+				bucket = k
+				bucket.Time = start
+				started = true
+				continue
+			}
+			bucket.Close = k.Close
+			bucket.High = decimal.Max(bucket.High, k.High)
+			bucket.Low = decimal.Min(bucket.Low, k.Low)
+			bucket.Volume = bucket.Volume.Add(k.Volume)
+			bucket.Deal = bucket.Deal.Add(k.Deal)
+			bucket.Incomplete = bucket.Incomplete || k.Incomplete
+			bucket.Closed = bucket.Closed && k.Closed
+		}
+		if !started {
+			continue
+		}
+		bucket.Incomplete = bucket.Incomplete || incomplete
+		bucket.Interval = nativeInterval(target)
+		// a bucket is only final once all of its source candles are
+		bucket.Closed = bucket.Closed && !bucket.Incomplete
+		if bucket.Incomplete && a.Policy == GapDrop {
+			continue
+		}
+		out = append(out, bucket)
+	}
+	return out, nil
+}
+
+// alignToEpoch rounds t down to a multiple of d since the unix epoch
+func alignToEpoch(t time.Time, d time.Duration) time.Time {
+	ns := t.UnixNano()
+	rem := ns % int64(d)
+	if rem < 0 {
+		rem += int64(d)
+	}
+	return time.Unix(0, ns-rem).In(t.Location())
+}
+
+// KlineInterval is a native candle interval of the exchange
+type KlineInterval string
+
+const (
+	Interval1m KlineInterval = "1m"
+	Interval1h KlineInterval = "1h"
+	Interval1d KlineInterval = "1d"
+)
+
+// nativeInterval returns the native interval of length d, empty when there is none
+func nativeInterval(d time.Duration) KlineInterval {
+	for _, i := range []KlineInterval{Interval1m, Interval1h, Interval1d} {
+		if i.Duration() == d {
+			return i
+		}
+	}
+	return ""
+}
+
+// Duration returns the length of the interval, zero for unknown intervals
+func (i KlineInterval) Duration() time.Duration {
+	switch i {
+	case Interval1m:
+		return time.Minute
+	case Interval1h:
+		return time.Hour
+	case Interval1d:
+		return 24 * time.Hour
+	}
+	return 0
+}
+
+type KlinesResp = Envelope[[]Kline]
@@ -0,0 +1,144 @@
+package gop2b_test
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sutapurachina/gop2b"
+)
+
+// TestGetKlinesFixture checks the golden candles are consistent with the 1h interval they are served for
+func TestGetKlinesFixture(t *testing.T) {
+	client, _ := newTestClient(t)
+	resp, err := client.GetKlines(context.Background(), "ETH_BTC", gop2b.Interval1h, 0, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	klines := resp.Result
+	if len(klines) != 3 {
+		t.Fatalf("%d candles, want 3", len(klines))
+	}
+	for i, k := range klines {
+		if !k.Time.Equal(k.Time.Truncate(time.Hour)) {
+			t.Errorf("candle %d starts at %s, not on the hour", i, k.Time.UTC())
+		}
+		if i > 0 && k.Time.Sub(klines[i-1].Time) != time.Hour {
+			t.Errorf("candle %d starts %s after the previous one, want 1h", i, k.Time.Sub(klines[i-1].Time))
+		}
+		if k.Interval != gop2b.Interval1h || !k.End().Equal(k.Time.Add(time.Hour)) {
+			t.Errorf("candle %d interval %q, end %s", i, k.Interval, k.End().UTC())
+		}
+		if k.Market != "ETH_BTC" {
+			t.Errorf("candle %d market %q", i, k.Market)
+		}
+	}
+	checkDecimal(t, "last close", klines[2].Close, "0.055")
+}
+
+// randomKlines returns up to n consecutive 1m candles from start with random prices, each left
+// out with probability gap
+func randomKlines(r *rand.Rand, start time.Time, n int, gap float64) []gop2b.Kline {
+	var out []gop2b.Kline
+	price := decimal.NewFromInt(1000)
+	for i := 0; i < n; i++ {
+		open := price
+		price = price.Add(decimal.NewFromInt(int64(r.Intn(21) - 10)))
+		high := decimal.Max(open, price).Add(decimal.NewFromInt(int64(r.Intn(5))))
+		low := decimal.Min(open, price).Sub(decimal.NewFromInt(int64(r.Intn(5))))
+		if r.Float64() < gap {
+			continue
+		}
+		out = append(out, gop2b.Kline{
+			Time:   start.Add(time.Duration(i) * time.Minute),
+			Open:   open,
+			Close:  price,
+			High:   high,
+			Low:    low,
+			Volume: decimal.New(int64(r.Intn(100000)), -4),
+			Deal:   decimal.New(int64(r.Intn(100000)), -2),
+			Market: "ETH_BTC",
+		})
+	}
+	return out
+}
+
+// TestAggregateKlinesProperties checks every bucket against the source candles it covers:
+// open of the first, close of the last, the extremes as high and low and the summed volumes
+func TestAggregateKlinesProperties(t *testing.T) {
+	start := time.Unix(1700000000, 0).Truncate(time.Hour)
+	for _, target := range []time.Duration{5 * time.Minute, 15 * time.Minute, time.Hour} {
+		for seed := int64(1); seed <= 20; seed++ {
+			r := rand.New(rand.NewSource(seed))
+			in := randomKlines(r, start.Add(time.Duration(r.Intn(60))*time.Minute), 300, 0.1)
+			// shuffled input must give the same result
+			shuffled := append([]gop2b.Kline(nil), in...)
+			r.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+			out, err := gop2b.AggregateKlines(shuffled, target)
+			if err != nil {
+				t.Fatalf("%s seed %d: %v", target, seed, err)
+			}
+
+			totalVolume, totalDeal := decimal.Zero, decimal.Zero
+			covered := 0
+			for _, b := range out {
+				var src []gop2b.Kline
+				for _, k := range in {
+					if !k.Time.Before(b.Time) && k.Time.Before(b.Time.Add(target)) {
+						src = append(src, k)
+					}
+				}
+				if len(src) == 0 {
+					t.Fatalf("%s seed %d: bucket %s has no source candles", target, seed, b.Time.UTC())
+				}
+				covered += len(src)
+				name := fmt.Sprintf("%s seed %d bucket %s", target, seed, b.Time.UTC())
+				if !b.Time.Equal(b.Time.Truncate(target)) {
+					t.Errorf("%s: not aligned to %s", name, target)
+				}
+				high, low, volume, deal := src[0].High, src[0].Low, decimal.Zero, decimal.Zero
+				for _, k := range src {
+					high = decimal.Max(high, k.High)
+					low = decimal.Min(low, k.Low)
+					volume = volume.Add(k.Volume)
+					deal = deal.Add(k.Deal)
+				}
+				checks := []struct {
+					field     string
+					got, want decimal.Decimal
+				}{
+					{"open", b.Open, src[0].Open},
+					{"close", b.Close, src[len(src)-1].Close},
+					{"high", b.High, high},
+					{"low", b.Low, low},
+					{"volume", b.Volume, volume},
+					{"deal", b.Deal, deal},
+				}
+				for _, c := range checks {
+					if !c.got.Equal(c.want) {
+						t.Errorf("%s: %s %s, want %s", name, c.field, c.got, c.want)
+					}
+				}
+				if want := len(src) < int(target/time.Minute); b.Incomplete != want {
+					t.Errorf("%s: incomplete %v with %d source candles", name, b.Incomplete, len(src))
+				}
+				totalVolume = totalVolume.Add(b.Volume)
+				totalDeal = totalDeal.Add(b.Deal)
+			}
+			if covered != len(in) {
+				t.Errorf("%s seed %d: buckets cover %d of %d candles", target, seed, covered, len(in))
+			}
+			inVolume, inDeal := decimal.Zero, decimal.Zero
+			for _, k := range in {
+				inVolume = inVolume.Add(k.Volume)
+				inDeal = inDeal.Add(k.Deal)
+			}
+			if !totalVolume.Equal(inVolume) || !totalDeal.Equal(inDeal) {
+				t.Errorf("%s seed %d: total volume %s deal %s, want %s and %s", target, seed, totalVolume, totalDeal, inVolume, inDeal)
+			}
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package gop2b
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// marketsCacheTTL is how long the listed markets are reused before being fetched again
+const marketsCacheTTL = time.Hour
+
+// ErrUnknownMarket is returned when a market isn't listed on the exchange
+var ErrUnknownMarket = errors.New("unknown market")
+
+// ErrAmbiguousMarket is returned by Market.Split for an unknown market name with more than
+// one underscore, whose currencies can't be told apart
+var ErrAmbiguousMarket = errors.New("ambiguous market name")
+
+// Market is a market name such as "ETH_BTC"
+type Market string
+
+func (m Market) String() string {
+	return string(m)
+}
+
+// Split returns the base (stock) and quote (money) currencies of the market name, split at
+// its underscore. It only looks at the name: the market isn't checked against the listed ones,
+// so an unlisted market splits all the same. A name with more than one underscore fails with
+// ErrAmbiguousMarket, use SplitWith and the listed markets to resolve it.
+func (m Market) Split() (base, quote Currency, err error) {
+	switch strings.Count(string(m), "_") {
+	case 0:
+		return "", "", fmt.Errorf("%w %q: no underscore", ErrUnknownMarket, m)
+	case 1:
+		i := strings.IndexByte(string(m), '_')
+		base, quote = Currency(strings.ToUpper(string(m[:i]))), Currency(strings.ToUpper(string(m[i+1:])))
+		if base == "" || quote == "" {
+			return "", "", fmt.Errorf("%w %q", ErrUnknownMarket, m)
+		}
+		return base, quote, nil
+	}
+	return "", "", fmt.Errorf("%w %q: more than one underscore", ErrAmbiguousMarket, m)
+}
+
+// SplitWith returns the stock and money currencies of the market among markets, such as the
+// Result of GetMarkets, as sent by the exchange. A market not among them is split like Split does.
+func (m Market) SplitWith(markets []MarketInfo) (base, quote Currency, err error) {
+	for _, info := range markets {
+		if info.Name == m {
+			return info.Stock, info.Money, nil
+		}
+	}
+	return m.Split()
+}
+
+// Base returns the base (stock) currency of the market name, see Split. Like Split it
+// doesn't consult the listed markets, use SplitWith for names with more than one underscore.
+func (m Market) Base() (Currency, error) {
+	base, _, err := m.Split()
+	return base, err
+}
+
+// Quote returns the quote (money) currency of the market name, see Split. Like Split it
+// doesn't consult the listed markets, use SplitWith for names with more than one underscore.
+func (m Market) Quote() (Currency, error) {
+	_, quote, err := m.Split()
+	return quote, err
+}
+
+type marketsCache struct {
+	mu      sync.Mutex
+	markets map[Market]MarketInfo
+	loaded  time.Time
+}
+
+// cachedMarkets returns the listed markets keyed by name, fetching them when missing or stale
+func (c *client) cachedMarkets(ctx context.Context) (map[Market]MarketInfo, error) {
+	c.markets.mu.Lock()
+	defer c.markets.mu.Unlock()
+	if c.markets.markets != nil && time.Since(c.markets.loaded) < marketsCacheTTL {
+		return c.markets.markets, nil
+	}
+	resp, err := c.GetMarkets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Success {
+		return nil, resp.Err()
+	}
+	markets := make(map[Market]MarketInfo, len(resp.Result))
+	for _, m := range resp.Result {
+		markets[m.Name] = m
+	}
+	c.markets.markets = markets
+	c.markets.loaded = time.Now()
+	return markets, nil
+}
+
+// ResolveMarket expands a base currency shorthand such as "BTC" to a market name using
+// the default quote currency, and checks the market is listed.
+// Full market names are returned unchanged without any check.
+func (c *client) ResolveMarket(ctx context.Context, market string) (string, error) {
+	if strings.Contains(market, "_") {
+		return market, nil
+	}
+	if c.defaultQuote == "" {
+		return "", fmt.Errorf("%w %q: no default quote currency configured", ErrUnknownMarket, market)
+	}
+	name := strings.ToUpper(market) + "_" + c.defaultQuote
+	markets, err := c.cachedMarkets(ctx)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := markets[Market(name)]; !ok {
+		return "", fmt.Errorf("%w %q", ErrUnknownMarket, name)
+	}
+	return name, nil
+}
@@ -0,0 +1,77 @@
+package gop2b_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sutapurachina/gop2b"
+)
+
+func TestMarketSplit(t *testing.T) {
+	tests := []struct {
+		market      gop2b.Market
+		base, quote gop2b.Currency
+		err         error
+	}{
+		{"ETH_BTC", "ETH", "BTC", nil},
+		{"eth_btc", "ETH", "BTC", nil},
+		{"ETHBTC", "", "", gop2b.ErrUnknownMarket},
+		{"_BTC", "", "", gop2b.ErrUnknownMarket},
+		{"SHIB_X_USDT", "", "", gop2b.ErrAmbiguousMarket},
+	}
+	for _, tt := range tests {
+		base, quote, err := tt.market.Split()
+		if base != tt.base || quote != tt.quote || !errors.Is(err, tt.err) || (tt.err == nil) != (err == nil) {
+			t.Errorf("%s split into %q, %q, %v, want %q, %q, %v", tt.market, base, quote, err, tt.base, tt.quote, tt.err)
+		}
+	}
+}
+
+func TestMarketSplitWith(t *testing.T) {
+	markets := []gop2b.MarketInfo{
+		{Name: "SHIB_X_USDT", Stock: "SHIB_X", Money: "USDT"},
+		// a renamed ticker, whose name doesn't match its currencies
+		{Name: "XBT_USDT", Stock: "BTC", Money: "USDT"},
+	}
+	tests := []struct {
+		market      gop2b.Market
+		base, quote gop2b.Currency
+	}{
+		{"SHIB_X_USDT", "SHIB_X", "USDT"},
+		{"XBT_USDT", "BTC", "USDT"},
+		// not listed, split at the underscore
+		{"ETH_BTC", "ETH", "BTC"},
+	}
+	for _, tt := range tests {
+		base, quote, err := tt.market.SplitWith(markets)
+		if base != tt.base || quote != tt.quote || err != nil {
+			t.Errorf("%s split into %q, %q, %v, want %q, %q", tt.market, base, quote, err, tt.base, tt.quote)
+		}
+	}
+	if _, _, err := gop2b.Market("A_B_C").SplitWith(markets); !errors.Is(err, gop2b.ErrAmbiguousMarket) {
+		t.Errorf("error %v for an unlisted ambiguous market, want ErrAmbiguousMarket", err)
+	}
+}
+
+// TestGetMarketsKeepsNoGlobalState checks the markets listed by one client don't change how
+// market names are split elsewhere
+func TestGetMarketsKeepsNoGlobalState(t *testing.T) {
+	client, server := newTestClient(t)
+	server.SetResponse("/public/markets", `{"success":true,"message":"","result":[
+		{"name":"SHIB_X_USDT","stock":"shib_x","money":"usdt","precision":{"money":"6","stock":"0","fee":"4"},"limits":{}}]}`)
+	resp, err := client.GetMarkets(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := resp.Result[0]
+	if info.Name != "SHIB_X_USDT" || info.Stock != "SHIB_X" || info.Money != "USDT" {
+		t.Errorf("decoded %q, %q, %q, want the currencies upper cased", info.Name, info.Stock, info.Money)
+	}
+	if _, _, err := gop2b.Market("SHIB_X_USDT").Split(); !errors.Is(err, gop2b.ErrAmbiguousMarket) {
+		t.Errorf("error %v after GetMarkets, want ErrAmbiguousMarket", err)
+	}
+	if base, _, err := info.Name.SplitWith(resp.Result); base != "SHIB_X" || err != nil {
+		t.Errorf("base %q, %v from the listed markets", base, err)
+	}
+}
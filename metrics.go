@@ -0,0 +1,66 @@
+package gop2b
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// MetricsCollector receives the client metrics, see WithMetrics.
+// Implementations must be safe for concurrent use.
+type MetricsCollector interface {
+	// ObserveRequest is called once per HTTP attempt with the endpoint path, such as
+	// "/public/ticker", the response status, zero when no response arrived, and the duration
+	ObserveRequest(endpoint string, status int, d time.Duration)
+	// ObserveRateLimitWait is called with the time a request waited for its rate limiter slot.
+	// The limiter spaces requests evenly rather than counting down a quota, so the wait
+	// is what shows how close the client runs to its limit.
+	ObserveRateLimitWait(d time.Duration)
+	// ObserveRateLimitRemaining is called after every rate limiter wait with the request slots
+	// of the coming period not yet taken, from the configured amount down to zero
+	ObserveRateLimitRemaining(remaining int)
+	// IncRetry is called before a failed request to endpoint is sent again
+	IncRetry(endpoint string)
+	// ObserveWsEvent is called for every websocket notification with its channel, such as "depth"
+	ObserveWsEvent(channel string)
+}
+
+// WithMetrics reports request metrics to m
+func WithMetrics(m MetricsCollector) Option {
+	return func(c *client) {
+		if m == nil {
+			m = NopMetrics{}
+		}
+		c.metrics = m
+	}
+}
+
+// WithWSMetrics reports websocket notifications to m
+func WithWSMetrics(m MetricsCollector) WSOption {
+	return func(w *WSClient) {
+		if m == nil {
+			m = NopMetrics{}
+		}
+		w.metrics = m
+	}
+}
+
+// NopMetrics is the default MetricsCollector, discarding everything.
+// Embed it to implement only some of the methods.
+type NopMetrics struct{}
+
+func (NopMetrics) ObserveRequest(string, int, time.Duration) {}
+func (NopMetrics) ObserveRateLimitWait(time.Duration)        {}
+func (NopMetrics) ObserveRateLimitRemaining(int)             {}
+func (NopMetrics) IncRetry(string)                           {}
+func (NopMetrics) ObserveWsEvent(string)                     {}
+
+// endpoint returns the path of request relative to the API base URL
+func (c *client) endpoint(request *http.Request) string {
+	base, err := url.Parse(c.url)
+	if err != nil {
+		return request.URL.Path
+	}
+	return strings.TrimPrefix(request.URL.Path, strings.TrimSuffix(base.Path, "/"))
+}
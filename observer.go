@@ -0,0 +1,104 @@
+package gop2b
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+)
+
+// redacted replaces the credentials in the headers passed to observers
+const redacted = "[REDACTED]"
+
+// RequestInfo describes an HTTP attempt about to be made, see WithRequestObserver
+type RequestInfo struct {
+	Method string
+	// Endpoint is the path relative to the API base URL, such as "/public/ticker"
+	Endpoint string
+	// Header is a copy of the request headers with the API key and signature redacted
+	Header   http.Header
+	BodySize int64
+	// PayloadSHA256 is the hex SHA-256 of the request body, empty without a body. It tells
+	// whether a body matches the one sent without revealing it.
+	PayloadSHA256 string
+	// Attempt counts from 1, retries of public GET requests increase it
+	Attempt int
+}
+
+// ResponseInfo describes the outcome of an HTTP attempt, see WithResponseObserver
+type ResponseInfo struct {
+	Method   string
+	Endpoint string
+	// Header is a copy of the response headers, nil when no response arrived
+	Header http.Header
+	// BodySize is the Content-Length of the response, -1 when unknown
+	BodySize int64
+	// Status is zero when no response arrived
+	Status   int
+	Duration time.Duration
+	Attempt  int
+	// Err is the error of the attempt, such as a network error. Error statuses are
+	// reported by Status only, as the response is checked after the attempt.
+	Err error
+}
+
+// WithRequestObserver calls fn once per HTTP attempt before it is made, including attempts
+// that fail before reaching the network, such as on shutdown or a rate limiter timeout.
+// fn is called from the requesting goroutine and gets copies, it cannot change the request.
+func WithRequestObserver(fn func(RequestInfo)) Option {
+	return func(c *client) {
+		c.onRequest = fn
+	}
+}
+
+// WithResponseObserver calls fn once per HTTP attempt once it is done, successful or not.
+// Every call of a WithRequestObserver observer is followed by exactly one call of fn.
+func WithResponseObserver(fn func(ResponseInfo)) Option {
+	return func(c *client) {
+		c.onResponse = fn
+	}
+}
+
+func (c *client) observeRequest(request *http.Request, endpoint string, attempt int) {
+	if c.onRequest == nil {
+		return
+	}
+	header := request.Header.Clone()
+	for _, key := range []string{HeaderXTxcAPIKey, c.signer.SignatureHeader()} {
+		if header.Get(key) != "" {
+			header.Set(key, redacted)
+		}
+	}
+	c.onRequest(RequestInfo{
+		Method:        request.Method,
+		Endpoint:      endpoint,
+		Header:        header,
+		BodySize:      request.ContentLength,
+		PayloadSHA256: payloadHash(request),
+		Attempt:       attempt,
+	})
+}
+
+// payloadHash returns the hex SHA-256 of the body of request, empty without a body
+func payloadHash(request *http.Request) string {
+	if request.GetBody == nil || request.ContentLength == 0 {
+		return ""
+	}
+	body, err := request.GetBody()
+	if err != nil {
+		return ""
+	}
+	defer body.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, body); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *client) observeResponse(info ResponseInfo) {
+	if c.onResponse != nil {
+		c.onResponse(info)
+	}
+}
@@ -0,0 +1,547 @@
+package gop2b
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// WithStrictEnums makes responses fail to decode when a Side or OrderType in them isn't one of
+// the constants. By default unknown values are kept as sent, so a new value from the exchange
+// doesn't fail the whole response.
+func WithStrictEnums() Option {
+	return func(c *client) {
+		c.strictEnums = true
+	}
+}
+
+// WithWSStrictEnums drops websocket updates carrying a Side that isn't one of the constants,
+// like WithStrictEnums does for responses
+func WithWSStrictEnums() WSOption {
+	return func(w *WSClient) {
+		w.strictEnums = true
+	}
+}
+
+// Side is the side of an order or trade
+type Side string
+
+const (
+	SideBuy  Side = "buy"
+	SideSell Side = "sell"
+)
+
+// ParseSide parses a side case-insensitively, failing on anything but buy and sell
+func ParseSide(s string) (Side, error) {
+	switch side := Side(normalizeEnum(s)); side {
+	case SideBuy, SideSell:
+		return side, nil
+	}
+	return "", fmt.Errorf("invalid side %q", s)
+}
+
+// Opposite returns sell for buy and buy for sell, any other side unchanged
+func (s Side) Opposite() Side {
+	switch s {
+	case SideBuy:
+		return SideSell
+	case SideSell:
+		return SideBuy
+	}
+	return s
+}
+
+// MarshalJSON writes a known side lower cased and any other value as is
+func (s Side) MarshalJSON() ([]byte, error) {
+	if side, err := ParseSide(string(s)); err == nil {
+		s = side
+	}
+	return json.Marshal(string(s))
+}
+
+// UnmarshalJSON normalizes the case of a known side, the exchange sends both "BUY" and "buy".
+// Any other value is kept as sent, see WithStrictEnums.
+func (s *Side) UnmarshalJSON(data []byte) error {
+	v, err := unmarshalEnum(data)
+	if err != nil {
+		*s = ""
+		return err
+	}
+	if side, err := ParseSide(v); err == nil {
+		*s = side
+	} else {
+		*s = Side(v)
+	}
+	return nil
+}
+
+// OrderType is the type of an order
+type OrderType string
+
+const (
+	OrderTypeLimit  OrderType = "limit"
+	OrderTypeMarket OrderType = "market"
+)
+
+// ParseOrderType parses an order type case-insensitively, failing on anything but limit and market
+func ParseOrderType(s string) (OrderType, error) {
+	switch t := OrderType(normalizeEnum(s)); t {
+	case OrderTypeLimit, OrderTypeMarket:
+		return t, nil
+	}
+	return "", fmt.Errorf("invalid order type %q", s)
+}
+
+// MarshalJSON writes a known order type lower cased and any other value as is
+func (t OrderType) MarshalJSON() ([]byte, error) {
+	if parsed, err := ParseOrderType(string(t)); err == nil {
+		t = parsed
+	}
+	return json.Marshal(string(t))
+}
+
+// UnmarshalJSON normalizes the case of a known order type and keeps any other value like Side
+func (t *OrderType) UnmarshalJSON(data []byte) error {
+	v, err := unmarshalEnum(data)
+	if err != nil {
+		*t = ""
+		return err
+	}
+	if parsed, err := ParseOrderType(v); err == nil {
+		*t = parsed
+	} else {
+		*t = OrderType(v)
+	}
+	return nil
+}
+
+func normalizeEnum(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
+
+var (
+	sideType      = reflect.TypeOf(Side(""))
+	orderTypeType = reflect.TypeOf(OrderType(""))
+)
+
+// checkEnums returns an error for the first non-empty Side or OrderType in v that isn't one
+// of the constants, looking through pointers, structs, slices and maps
+func checkEnums(v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			return checkEnums(v.Elem())
+		}
+	case reflect.String:
+		if v.String() == "" {
+			return nil
+		}
+		switch v.Type() {
+		case sideType:
+			_, err := ParseSide(v.String())
+			return err
+		case orderTypeType:
+			_, err := ParseOrderType(v.String())
+			return err
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				if err := checkEnums(v.Field(i)); err != nil {
+					return err
+				}
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := checkEnums(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		for iter := v.MapRange(); iter.Next(); {
+			if err := checkEnums(iter.Value()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// unmarshalEnum decodes a JSON string, empty for null
+func unmarshalEnum(data []byte) (string, error) {
+	var v *string
+	if err := json.Unmarshal(data, &v); err != nil || v == nil {
+		return "", err
+	}
+	return *v, nil
+}
+
+// Order is an order as returned by every order endpoint: placement, open orders and the
+// order history. Each endpoint sends a subset of the fields, the missing ones are zero.
+type Order struct {
+	ID        OrderID
+	Market    string
+	Side      Side
+	Type      OrderType
+	Price     decimal.Decimal
+	Amount    decimal.Decimal
+	Left      decimal.Decimal
+	DealStock decimal.Decimal
+	DealMoney decimal.Decimal
+	DealFee   decimal.Decimal
+	TakerFee  decimal.Decimal
+	MakerFee  decimal.Decimal
+	CreatedAt time.Time
+	// FinishedAt is only set by the order history, zero while the order is open
+	FinishedAt time.Time
+	// Source is the endpoint the order was returned by, it isn't part of the JSON
+	Source OrderSource
+}
+
+// OrderSource is the endpoint an Order was returned by, which tells how to read its amounts
+type OrderSource int
+
+const (
+	// OrderSourceUnknown is an order decoded outside of the client methods
+	OrderSourceUnknown OrderSource = iota
+	// OrderSourceNew is an order returned by PostNewOrder
+	OrderSourceNew
+	// OrderSourceOpenOrders is an order returned by PostOpenOrders, still open
+	OrderSourceOpenOrders
+	// OrderSourceHistory is an order returned by PostOrderHistory, finished
+	OrderSourceHistory
+)
+
+// orderJSON is an order as sent by the exchange
+type orderJSON struct {
+	OrderID   OrderID         `json:"orderId,omitempty"`
+	ID        OrderID         `json:"id"`
+	Market    string          `json:"market"`
+	Side      Side            `json:"side"`
+	Type      OrderType       `json:"type"`
+	Price     decimal.Decimal `json:"price"`
+	Amount    decimal.Decimal `json:"amount"`
+	Left      decimal.Decimal `json:"left"`
+	DealStock decimal.Decimal `json:"dealStock"`
+	DealMoney decimal.Decimal `json:"dealMoney"`
+	DealFee   decimal.Decimal `json:"dealFee"`
+	TakerFee  decimal.Decimal `json:"takerFee"`
+	MakerFee  decimal.Decimal `json:"makerFee"`
+	// Timestamp of the trading endpoints has no documented unit, it is read by magnitude
+	Timestamp *ExchangeTime        `json:"timestamp,omitempty"`
+	CTime     ExchangeTimeSeconds  `json:"ctime"`
+	FTime     *ExchangeTimeSeconds `json:"ftime,omitempty"`
+}
+
+// UnmarshalJSON accepts the variations between endpoints: the id as "orderId" (trading
+// endpoints) or "id" (order history), the creation time as "timestamp" or "ctime", and
+// ids and times as numbers or strings
+func (o *Order) UnmarshalJSON(data []byte) error {
+	var v orderJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	id := v.OrderID
+	if id == 0 {
+		id = v.ID
+	}
+	createdAt := v.CTime.Time
+	if createdAt.IsZero() && v.Timestamp != nil {
+		createdAt = v.Timestamp.Time
+	}
+	var finishedAt time.Time
+	if v.FTime != nil {
+		finishedAt = v.FTime.Time
+	}
+	*o = Order{
+		ID:         id,
+		Market:     v.Market,
+		Side:       v.Side,
+		Type:       v.Type,
+		Price:      v.Price,
+		Amount:     v.Amount,
+		Left:       v.Left,
+		DealStock:  v.DealStock,
+		DealMoney:  v.DealMoney,
+		DealFee:    v.DealFee,
+		TakerFee:   v.TakerFee,
+		MakerFee:   v.MakerFee,
+		CreatedAt:  createdAt,
+		FinishedAt: finishedAt,
+	}
+	return nil
+}
+
+// MarshalJSON writes the order as the order history sends it, so it decodes back the same
+func (o Order) MarshalJSON() ([]byte, error) {
+	return json.Marshal(orderJSON{
+		ID:        o.ID,
+		Market:    o.Market,
+		Side:      o.Side,
+		Type:      o.Type,
+		Price:     o.Price,
+		Amount:    o.Amount,
+		Left:      o.Left,
+		DealStock: o.DealStock,
+		DealMoney: o.DealMoney,
+		DealFee:   o.DealFee,
+		TakerFee:  o.TakerFee,
+		MakerFee:  o.MakerFee,
+		CTime:     ExchangeTimeSeconds{o.CreatedAt},
+		FTime:     optionalTime(o.FinishedAt),
+	})
+}
+
+// Filled returns the executed amount in stock
+func (o Order) Filled() decimal.Decimal {
+	return o.DealStock
+}
+
+// IsOpen reports whether the order can still fill: not finished and with an amount left
+func (o Order) IsOpen() bool {
+	return o.FinishedAt.IsZero() && o.Left.IsPositive()
+}
+
+// AvgFillPrice returns dealMoney / dealStock, zero when nothing was filled
+func (o Order) AvgFillPrice() decimal.Decimal {
+	if o.DealStock.IsZero() {
+		return decimal.Zero
+	}
+	return o.DealMoney.Div(o.DealStock)
+}
+
+// flexNumber is a JSON number the exchange sends either bare or quoted, empty when missing or null
+type flexNumber string
+
+func (n *flexNumber) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		*n = ""
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = strings.TrimSpace(unquoted)
+	}
+	if s != "" {
+		if _, err := strconv.ParseFloat(s, 64); err != nil {
+			return fmt.Errorf("invalid number %q", s)
+		}
+	}
+	*n = flexNumber(s)
+	return nil
+}
+
+func (n flexNumber) MarshalJSON() ([]byte, error) {
+	if n == "" {
+		return []byte("null"), nil
+	}
+	return []byte(n), nil
+}
+
+// Int64 returns the number as an integer, zero when empty
+func (n flexNumber) Int64() (int64, error) {
+	if n == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(string(n), 10, 64)
+}
+
+// optionalTime returns nil for the zero time, so that it is left out of the JSON
+func optionalTime(t time.Time) *ExchangeTimeSeconds {
+	if t.IsZero() {
+		return nil
+	}
+	return &ExchangeTimeSeconds{t}
+}
+
+// OrderStatus is the state of an order derived from its fill and finish time
+type OrderStatus string
+
+const (
+	OrderStatusOpen            OrderStatus = "open"
+	OrderStatusPartiallyFilled OrderStatus = "partially_filled"
+	OrderStatusFilled          OrderStatus = "filled"
+	// OrderStatusCancelled is an order finished without any fill
+	OrderStatusCancelled OrderStatus = "cancelled"
+	// OrderStatusPartiallyCancelled is an order finished with a partial fill
+	OrderStatusPartiallyCancelled OrderStatus = "partially_cancelled"
+)
+
+// Status derives the order status from the filled stock and the endpoint the order came from.
+// Orders of the order history are finished, open orders are not. Otherwise an order with
+// nothing left or a finish time is finished.
+func (o Order) Status() OrderStatus {
+	return o.StatusWithStep(decimal.Zero)
+}
+
+// StatusWithStep is Status counting a finished order as filled when the unfilled amount is
+// below step, such as the amount step of MarketInfo, since the exchange can't fill such a dust remainder
+func (o Order) StatusWithStep(step decimal.Decimal) OrderStatus {
+	var finished bool
+	switch o.Source {
+	case OrderSourceHistory:
+		finished = true
+	case OrderSourceOpenOrders:
+		finished = false
+	default:
+		finished = !o.FinishedAt.IsZero() || o.Left.IsZero()
+	}
+	// the order history doesn't send left, so the remainder is computed from the filled stock
+	remainder := o.Amount.Sub(o.DealStock)
+	filled := o.DealStock.IsPositive() && (remainder.Sign() <= 0 || finished && remainder.LessThan(step))
+	switch {
+	case filled:
+		return OrderStatusFilled
+	case finished && o.DealStock.IsZero():
+		return OrderStatusCancelled
+	case finished:
+		return OrderStatusPartiallyCancelled
+	case o.DealStock.IsZero():
+		return OrderStatusOpen
+	}
+	return OrderStatusPartiallyFilled
+}
+
+type NewOrderRequest struct {
+	Request
+	Market string          `json:"market"`
+	Side   Side            `json:"side"`
+	Amount decimal.Decimal `json:"amount"`
+	Price  decimal.Decimal `json:"price"`
+	// ConfirmBalances fetches the balances of the stock and money currencies of the market
+	// into NewOrderResp.Balances when the order filled on placement, costing an extra request
+	ConfirmBalances bool `json:"-"`
+	// PostOnly refuses the order with ErrPostOnlyWouldTake when its price crosses the book.
+	// The exchange has no post-only orders, the check is made against the book right before
+	// sending, so an order can still take liquidity when the book moves in between.
+	PostOnly bool `json:"-"`
+}
+
+func (*NewOrderRequest) endpointPath() string { return "/order/new" }
+
+type NewOrderResp struct {
+	Response
+	Result Order `json:"result"`
+	// Balances holds the stock and money balances after the order with ConfirmBalances set,
+	// nil when the order didn't fill on placement or they couldn't be fetched
+	Balances map[Currency]AccountBalance `json:"-"`
+	// BalancesErr is the error fetching Balances, the order was placed regardless
+	BalancesErr error `json:"-"`
+}
+
+// FilledAmount returns the executed amount in stock, see Order.Filled
+func (r *NewOrderResp) FilledAmount() decimal.Decimal {
+	return r.Result.Filled()
+}
+
+// AverageFillPrice returns dealMoney / dealStock, zero when nothing was filled
+func (r *NewOrderResp) AverageFillPrice() decimal.Decimal {
+	return r.Result.AvgFillPrice()
+}
+
+// FilledRatio returns the filled share of the order amount between 0 and 1
+func (r *NewOrderResp) FilledRatio() decimal.Decimal {
+	if r.Result.Amount.IsZero() {
+		return decimal.Zero
+	}
+	return r.FilledAmount().Div(r.Result.Amount)
+}
+
+// PostNewOrder places a limit order. With ConfirmBalances set, an order filled on placement
+// is followed by a balance request for the currencies of its market. An order above the
+// WithMaxOrderValue cap fails with ErrOrderValueExceedsCap without being sent, as does a
+// PostOnly order crossing the book with ErrPostOnlyWouldTake.
+func (c *client) PostNewOrder(ctx context.Context, request *NewOrderRequest) (*NewOrderResp, error) {
+	if err := c.checkOrderValue(ctx, request); err != nil {
+		return nil, err
+	}
+	if request.PostOnly {
+		if err := c.checkPostOnly(ctx, request); err != nil {
+			return nil, err
+		}
+	}
+	var result NewOrderResp
+	if err := c.postEndpoint(ctx, request, &result); err != nil {
+		return nil, err
+	}
+	result.Result.Source = OrderSourceNew
+	if request.ConfirmBalances && result.Success && result.Result.DealStock.IsPositive() {
+		result.Balances, result.BalancesErr = c.marketBalances(ctx, request.Market)
+	}
+	return &result, nil
+}
+
+// openOrdersPageLimit is the largest page size accepted by /orders
+const openOrdersPageLimit = 100
+
+type OpenOrdersRequest struct {
+	Request
+	Market string `json:"market"`
+	Offset int    `json:"offset"`
+	Limit  int    `json:"limit"`
+}
+
+func (*OpenOrdersRequest) endpointPath() string { return "/orders" }
+
+type OpenOrdersResp = Envelope[PaginatedResult[Order]]
+
+// PostOpenOrders returns a page of the open orders of market
+func (c *client) PostOpenOrders(ctx context.Context, request *OpenOrdersRequest) (*OpenOrdersResp, error) {
+	var result OpenOrdersResp
+	if err := c.postEndpoint(ctx, request, &result); err != nil {
+		return nil, err
+	}
+	for i := range result.Result.Records {
+		result.Result.Records[i].Source = OrderSourceOpenOrders
+	}
+	return &result, nil
+}
+
+type OrderHistoryRequest struct {
+	Request
+	// StartTime and EndTime are unix seconds
+	StartTime int64 `json:"startTime"`
+	EndTime   int64 `json:"endTime"`
+	Offset    int   `json:"offset"`
+	Limit     int   `json:"limit"`
+	// ExcludeUnfilledCancelled drops the orders cancelled without any fill from the result.
+	// It is applied on the client, the exchange doesn't document how long it keeps them.
+	ExcludeUnfilledCancelled bool `json:"-"`
+}
+
+func (*OrderHistoryRequest) endpointPath() string { return "/account/order_history" }
+
+// OrderHistoryResp holds the finished orders by market
+type OrderHistoryResp = Envelope[map[string][]Order]
+
+// PostOrderHistory returns a page of the finished orders of the account within the request time range
+func (c *client) PostOrderHistory(ctx context.Context, request *OrderHistoryRequest) (*OrderHistoryResp, error) {
+	var result OrderHistoryResp
+	if err := c.postEndpoint(ctx, request, &result); err != nil {
+		return nil, err
+	}
+	for _, orders := range result.Result {
+		for i := range orders {
+			orders[i].Source = OrderSourceHistory
+		}
+	}
+	if request.ExcludeUnfilledCancelled {
+		for market, orders := range result.Result {
+			kept := orders[:0]
+			for _, o := range orders {
+				if o.Status() != OrderStatusCancelled {
+					kept = append(kept, o)
+				}
+			}
+			result.Result[market] = kept
+		}
+	}
+	return &result, nil
+}
@@ -0,0 +1,437 @@
+package gop2b_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sutapurachina/gop2b"
+	"github.com/sutapurachina/gop2b/gop2btest"
+)
+
+// orderNewBody is the /order/new response of an order of 2 ETH at 0.05 BTC
+func orderNewBody(left, dealStock, dealMoney string) string {
+	return fmt.Sprintf(`{"success":true,"message":"","result":{"orderId":25749,"market":"ETH_BTC",
+		"price":"0.05","side":"buy","type":"limit","timestamp":1700000000.3,"amount":"2","left":%q,
+		"dealStock":%q,"dealMoney":%q,"dealFee":"0","takerFee":"0.002","makerFee":"0.002"}}`, left, dealStock, dealMoney)
+}
+
+func TestNewOrderRespFills(t *testing.T) {
+	tests := []struct {
+		name                        string
+		body                        string
+		filled, averagePrice, ratio string
+	}{
+		{"unfilled", orderNewBody("2", "0", "0"), "0", "0", "0"},
+		{"partial", orderNewBody("1.5", "0.5", "0.0249"), "0.5", "0.0498", "0.25"},
+		{"filled", orderNewBody("0", "2", "0.099"), "2", "0.0495", "1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := newTestClient(t)
+			server.SetResponse("/order/new", tt.body)
+			resp, err := client.PostNewOrder(context.Background(), &gop2b.NewOrderRequest{
+				Market: "ETH_BTC",
+				Side:   gop2b.SideBuy,
+				Amount: decimal.RequireFromString("2"),
+				Price:  decimal.RequireFromString("0.05"),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkDecimal(t, "filled amount", resp.FilledAmount(), tt.filled)
+			checkDecimal(t, "average fill price", resp.AverageFillPrice(), tt.averagePrice)
+			checkDecimal(t, "filled ratio", resp.FilledRatio(), tt.ratio)
+		})
+	}
+}
+
+func TestNewOrderRespZeroAmount(t *testing.T) {
+	var resp gop2b.NewOrderResp
+	checkDecimal(t, "average fill price", resp.AverageFillPrice(), "0")
+	checkDecimal(t, "filled ratio", resp.FilledRatio(), "0")
+}
+
+func TestPostNewOrderConfirmBalances(t *testing.T) {
+	tests := []struct {
+		name     string
+		confirm  bool
+		body     string
+		requests int
+	}{
+		{"filled without the option", false, orderNewBody("0", "2", "0.099"), 0},
+		{"filled with the option", true, orderNewBody("0", "2", "0.099"), 1},
+		{"partially filled with the option", true, orderNewBody("1.5", "0.5", "0.0249"), 1},
+		{"unfilled with the option", true, orderNewBody("2", "0", "0"), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := newTestClient(t)
+			server.SetResponse("/order/new", tt.body)
+			resp, err := client.PostNewOrder(context.Background(), &gop2b.NewOrderRequest{
+				Market:          "ETH_BTC",
+				Side:            gop2b.SideBuy,
+				Amount:          decimal.RequireFromString("2"),
+				Price:           decimal.RequireFromString("0.05"),
+				ConfirmBalances: tt.confirm,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n := server.Requests("/account/balances"); n != tt.requests {
+				t.Errorf("%d balance requests, want %d", n, tt.requests)
+			}
+			if resp.BalancesErr != nil {
+				t.Errorf("balances error %v", resp.BalancesErr)
+			}
+			if tt.requests == 0 {
+				if resp.Balances != nil {
+					t.Errorf("balances %v, want nil", resp.Balances)
+				}
+				return
+			}
+			// only the currencies of the market out of the balances fixture
+			if len(resp.Balances) != 2 {
+				t.Errorf("balances %v, want ETH and BTC", resp.Balances)
+			}
+			checkDecimal(t, "ETH available", resp.Balances["ETH"].Available, "3.2")
+			checkDecimal(t, "BTC frozen", resp.Balances["BTC"].Freeze, "0.04125")
+		})
+	}
+}
+
+func TestPostNewOrderConfirmBalancesError(t *testing.T) {
+	client, server := newTestClient(t)
+	server.SetResponse("/order/new", orderNewBody("0", "2", "0.099"))
+	server.SetResponse("/account/balances", `{"success":false,"message":"balances unavailable","result":{}}`)
+	resp, err := client.PostNewOrder(context.Background(), &gop2b.NewOrderRequest{
+		Market:          "ETH_BTC",
+		Side:            gop2b.SideBuy,
+		Amount:          decimal.RequireFromString("2"),
+		Price:           decimal.RequireFromString("0.05"),
+		ConfirmBalances: true,
+	})
+	if err != nil {
+		t.Fatalf("placed order failed with %v", err)
+	}
+	var apiErr *gop2b.APIError
+	if !errors.As(resp.BalancesErr, &apiErr) || resp.Balances != nil {
+		t.Errorf("balances %v, error %v, want the *APIError", resp.Balances, resp.BalancesErr)
+	}
+}
+
+func TestPostNewOrderMaxOrderValue(t *testing.T) {
+	// 2 ETH at 0.05 BTC is worth 0.1 BTC, 3700 USDT at the last BTC_USDT price of the tickers
+	// fixture; without a price it is valued at the best ask of 0.0551, 0.1102 BTC
+	tests := []struct {
+		name    string
+		quote   string
+		max     string
+		price   string
+		wantErr error
+	}{
+		{"below the cap", "BTC", "0.11", "0.05", nil},
+		{"at the cap", "BTC", "0.1", "0.05", nil},
+		{"above the cap", "BTC", "0.099", "0.05", gop2b.ErrOrderValueExceedsCap},
+		{"market order below the cap", "BTC", "0.2", "0", nil},
+		{"market order above the cap at the ask", "BTC", "0.11", "0", gop2b.ErrOrderValueExceedsCap},
+		{"below a cap in another currency", "usdt", "5000", "0.05", nil},
+		{"above a cap in another currency", "USDT", "3000", "0.05", gop2b.ErrOrderValueExceedsCap},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := newTestClient(t, gop2b.WithMaxOrderValue(tt.quote, decimal.RequireFromString(tt.max)))
+			_, err := client.PostNewOrder(context.Background(), &gop2b.NewOrderRequest{
+				Market: "ETH_BTC",
+				Side:   gop2b.SideBuy,
+				Amount: decimal.RequireFromString("2"),
+				Price:  decimal.RequireFromString(tt.price),
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error %v, want %v", err, tt.wantErr)
+			}
+			sent := 1
+			if tt.wantErr != nil {
+				sent = 0
+			}
+			if n := server.Requests("/order/new"); n != sent {
+				t.Errorf("order sent %d times, want %d", n, sent)
+			}
+		})
+	}
+}
+
+func TestPostOrderHistory(t *testing.T) {
+	tests := []struct {
+		name    string
+		exclude bool
+		want    []gop2b.OrderID
+	}{
+		{"everything", false, []gop2b.OrderID{25700, 25701}},
+		{"exclude unfilled cancelled", true, []gop2b.OrderID{25700}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := newTestClient(t)
+			resp, err := client.PostOrderHistory(context.Background(), &gop2b.OrderHistoryRequest{
+				Limit:                    100,
+				ExcludeUnfilledCancelled: tt.exclude,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			orders := resp.Result["ETH_BTC"]
+			var ids []gop2b.OrderID
+			for _, o := range orders {
+				ids = append(ids, o.ID)
+				if o.Source != gop2b.OrderSourceHistory {
+					t.Errorf("order %d source %v", o.ID, o.Source)
+				}
+			}
+			if fmt.Sprint(ids) != fmt.Sprint(tt.want) {
+				t.Errorf("orders %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestPostNewOrderPostOnly(t *testing.T) {
+	// the book of the depth fixture has its best bid at 0.0549 and best ask at 0.0551
+	tests := []struct {
+		name     string
+		side     gop2b.Side
+		price    string
+		postOnly bool
+		wantErr  error
+	}{
+		{"maker buy", gop2b.SideBuy, "0.055", true, nil},
+		{"maker sell", gop2b.SideSell, "0.0552", true, nil},
+		{"taker buy", gop2b.SideBuy, "0.0551", true, gop2b.ErrPostOnlyWouldTake},
+		{"taker sell", gop2b.SideSell, "0.0549", true, gop2b.ErrPostOnlyWouldTake},
+		{"no price", gop2b.SideBuy, "0", true, gop2b.ErrInvalidRequest},
+		{"taker buy without PostOnly", gop2b.SideBuy, "0.0551", false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := newTestClient(t)
+			resp, err := client.PostNewOrder(context.Background(), &gop2b.NewOrderRequest{
+				Market:   "ETH_BTC",
+				Side:     tt.side,
+				Amount:   decimal.NewFromInt(1),
+				Price:    decimal.RequireFromString(tt.price),
+				PostOnly: tt.postOnly,
+			})
+			sent := server.Requests("/order/new")
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("error %v, want %v", err, tt.wantErr)
+				}
+				if sent != 0 {
+					t.Errorf("refused order sent %d times", sent)
+				}
+				return
+			}
+			if err != nil || !resp.Success {
+				t.Fatalf("%v, %+v", err, resp)
+			}
+			if sent != 1 {
+				t.Errorf("order sent %d times, want 1", sent)
+			}
+			checked := 0
+			if tt.postOnly {
+				checked = 1
+			}
+			if n := server.Requests("/public/depth/result"); n != checked {
+				t.Errorf("%d book requests, want %d", n, checked)
+			}
+		})
+	}
+}
+
+func TestOrderStatus(t *testing.T) {
+	finished := time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC)
+	tests := []struct {
+		name       string
+		source     gop2b.OrderSource
+		amount     string
+		left       string
+		dealStock  string
+		finishedAt time.Time
+		step       string
+		want       gop2b.OrderStatus
+	}{
+		{"new unfilled", gop2b.OrderSourceNew, "2", "2", "0", time.Time{}, "0", gop2b.OrderStatusOpen},
+		{"new partially filled", gop2b.OrderSourceNew, "2", "0.5", "1.5", time.Time{}, "0", gop2b.OrderStatusPartiallyFilled},
+		{"new filled", gop2b.OrderSourceNew, "2", "0", "2", time.Time{}, "0", gop2b.OrderStatusFilled},
+		{"open orders unfilled", gop2b.OrderSourceOpenOrders, "2", "2", "0", time.Time{}, "0", gop2b.OrderStatusOpen},
+		{"open orders partially filled", gop2b.OrderSourceOpenOrders, "2", "1", "1", time.Time{}, "0", gop2b.OrderStatusPartiallyFilled},
+		// the order history sends no left, its orders are finished
+		{"history filled", gop2b.OrderSourceHistory, "2", "0", "2", time.Time{}, "0", gop2b.OrderStatusFilled},
+		{"history cancelled", gop2b.OrderSourceHistory, "2", "0", "0", time.Time{}, "0", gop2b.OrderStatusCancelled},
+		{"history partially cancelled", gop2b.OrderSourceHistory, "2", "0", "1", time.Time{}, "0", gop2b.OrderStatusPartiallyCancelled},
+		{"history dust remainder", gop2b.OrderSourceHistory, "2", "0", "1.9995", time.Time{}, "0.001", gop2b.OrderStatusFilled},
+		{"history remainder of a step", gop2b.OrderSourceHistory, "2", "0", "1.999", time.Time{}, "0.001", gop2b.OrderStatusPartiallyCancelled},
+		{"unknown with a finish time", gop2b.OrderSourceUnknown, "2", "2", "0", finished, "0", gop2b.OrderStatusCancelled},
+		{"unknown partially filled with a finish time", gop2b.OrderSourceUnknown, "2", "1", "1", finished, "0", gop2b.OrderStatusPartiallyCancelled},
+		{"unknown with nothing left", gop2b.OrderSourceUnknown, "2", "0", "2", time.Time{}, "0", gop2b.OrderStatusFilled},
+		{"unknown open", gop2b.OrderSourceUnknown, "2", "1", "1", time.Time{}, "0", gop2b.OrderStatusPartiallyFilled},
+		// a dust remainder only counts as filled once the order is finished
+		{"open dust remainder", gop2b.OrderSourceOpenOrders, "2", "0.0005", "1.9995", time.Time{}, "0.001", gop2b.OrderStatusPartiallyFilled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := gop2b.Order{
+				Source:     tt.source,
+				Amount:     decimal.RequireFromString(tt.amount),
+				Left:       decimal.RequireFromString(tt.left),
+				DealStock:  decimal.RequireFromString(tt.dealStock),
+				FinishedAt: tt.finishedAt,
+			}
+			step := decimal.RequireFromString(tt.step)
+			if got := o.StatusWithStep(step); got != tt.want {
+				t.Errorf("StatusWithStep(%s) = %s, want %s", step, got, tt.want)
+			}
+			if step.IsZero() {
+				if got := o.Status(); got != tt.want {
+					t.Errorf("Status() = %s, want %s", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseSide(t *testing.T) {
+	tests := []struct {
+		in   string
+		want gop2b.Side
+		ok   bool
+	}{
+		{"buy", gop2b.SideBuy, true},
+		{"sell", gop2b.SideSell, true},
+		{"BUY", gop2b.SideBuy, true},
+		{" Sell ", gop2b.SideSell, true},
+		{"", "", false},
+		{"hold", "", false},
+		{"buyer", "", false},
+	}
+	for _, tt := range tests {
+		got, err := gop2b.ParseSide(tt.in)
+		if got != tt.want || (err == nil) != tt.ok {
+			t.Errorf("ParseSide(%q) = %q, %v, want %q", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseOrderType(t *testing.T) {
+	tests := []struct {
+		in   string
+		want gop2b.OrderType
+		ok   bool
+	}{
+		{"limit", gop2b.OrderTypeLimit, true},
+		{"market", gop2b.OrderTypeMarket, true},
+		{"LIMIT", gop2b.OrderTypeLimit, true},
+		{" Market", gop2b.OrderTypeMarket, true},
+		{"", "", false},
+		{"stop_limit", "", false},
+	}
+	for _, tt := range tests {
+		got, err := gop2b.ParseOrderType(tt.in)
+		if got != tt.want || (err == nil) != tt.ok {
+			t.Errorf("ParseOrderType(%q) = %q, %v, want %q", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestSideOpposite(t *testing.T) {
+	for side, want := range map[gop2b.Side]gop2b.Side{
+		gop2b.SideBuy:  gop2b.SideSell,
+		gop2b.SideSell: gop2b.SideBuy,
+		"":             "",
+		"hold":         "hold",
+	} {
+		if got := side.Opposite(); got != want {
+			t.Errorf("%q opposite %q, want %q", side, got, want)
+		}
+	}
+}
+
+func TestEnumJSONLenientRoundTrip(t *testing.T) {
+	var order struct {
+		Sides []gop2b.Side      `json:"sides"`
+		Types []gop2b.OrderType `json:"types"`
+	}
+	in := `{"sides":["BUY","sell","Hold",""," buy"],"types":["Limit","market","STOP_limit",null]}`
+	if err := json.Unmarshal([]byte(in), &order); err != nil {
+		t.Fatal(err)
+	}
+	// known values are normalized, unknown ones kept as sent
+	wantSides := []gop2b.Side{gop2b.SideBuy, gop2b.SideSell, "Hold", "", gop2b.SideBuy}
+	wantTypes := []gop2b.OrderType{gop2b.OrderTypeLimit, gop2b.OrderTypeMarket, "STOP_limit", ""}
+	if !reflect.DeepEqual(order.Sides, wantSides) || !reflect.DeepEqual(order.Types, wantTypes) {
+		t.Fatalf("decoded %q %q, want %q %q", order.Sides, order.Types, wantSides, wantTypes)
+	}
+	out, err := json.Marshal(order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"sides":["buy","sell","Hold","","buy"],"types":["limit","market","STOP_limit",""]}`; string(out) != want {
+		t.Errorf("encoded %s, want %s", out, want)
+	}
+}
+
+func TestStrictEnums(t *testing.T) {
+	history := `{"success":true,"message":"","result":[
+		{"id":1,"time":1700000000,"price":"0.055","amount":"1","type":"SELL"},
+		{"id":2,"time":1700000001,"price":"0.055","amount":"1","type":"hold"}]}`
+
+	lenient, server := newTestClient(t)
+	server.SetResponse("/public/history", history)
+	resp, err := lenient.GetHistory(context.Background(), "ETH_BTC", 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Result[0].Type != gop2b.SideSell || resp.Result[1].Type != "hold" {
+		t.Errorf("trades %+v, want sell and the unknown side kept", resp.Result)
+	}
+
+	strict, server := newTestClient(t, gop2b.WithStrictEnums(), gop2b.WithRetry(3, time.Millisecond))
+	server.SetResponse("/public/history", history)
+	if _, err := strict.GetHistory(context.Background(), "ETH_BTC", 0, 10); err == nil {
+		t.Error("unknown side accepted with WithStrictEnums")
+	}
+	if n := server.Requests("/public/history"); n != 1 {
+		t.Errorf("%d requests, the rejected response must not be retried", n)
+	}
+	// upper case known values pass
+	server.SetResponse("/order/new", `{"success":true,"message":"","result":{"orderId":1,"market":"ETH_BTC",
+		"price":"0.05","side":"BUY","type":"LIMIT","amount":"1"}}`)
+	order, err := strict.PostNewOrder(context.Background(), &gop2b.NewOrderRequest{Market: "ETH_BTC", Side: gop2b.SideBuy,
+		Amount: decimal.NewFromInt(1), Price: decimal.RequireFromString("0.05")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Result.Side != gop2b.SideBuy || order.Result.Type != gop2b.OrderTypeLimit {
+		t.Errorf("order %+v", order.Result)
+	}
+}
+
+func TestWSStrictEnums(t *testing.T) {
+	ws, server := newTestWS(t, gop2b.WithWSStrictEnums())
+	deals, err := ws.SubscribeDeals(context.Background(), "ETH_BTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.Send(gop2btest.Notification("deals", "ETH_BTC", []map[string]interface{}{
+		{"id": 1, "time": 1700000000.5, "price": "0.055", "amount": "1", "type": "hold"},
+	}))
+	server.Send(dealsFrame(2))
+	if update := receive(t, deals); update.Deals[0].ID != 2 {
+		t.Errorf("received trade %d, want the update with an unknown side dropped", update.Deals[0].ID)
+	}
+}
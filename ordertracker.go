@@ -0,0 +1,210 @@
+package gop2b
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// OrderEventType is the kind of change reported by an OrderTracker
+type OrderEventType int
+
+const (
+	// OrderOpened is a new open order
+	OrderOpened OrderEventType = iota
+	// OrderChanged is an open order whose fill state changed, usually a partial fill
+	OrderChanged
+	// OrderClosed is an order no longer open. Open orders don't tell whether it was
+	// filled or cancelled, Order holds its last known state.
+	OrderClosed
+)
+
+func (t OrderEventType) String() string {
+	switch t {
+	case OrderOpened:
+		return "opened"
+	case OrderChanged:
+		return "changed"
+	case OrderClosed:
+		return "closed"
+	}
+	return fmt.Sprintf("OrderEventType(%d)", int(t))
+}
+
+// OrderEvent is a change of the tracked open orders
+type OrderEvent struct {
+	Type  OrderEventType
+	Order Order
+	// Previous is the state before an OrderChanged event
+	Previous Order
+}
+
+// OrderTracker keeps an in-memory view of the open orders of a set of markets.
+// The exchange has no private websocket channels, so orders are polled from /orders
+// and every poll is diffed against the known state: orders that disappeared between
+// two polls, for example filled while a poll failed, are reported as OrderClosed.
+type OrderTracker struct {
+	rest     TradingClient
+	markets  []string
+	interval time.Duration
+
+	mu     sync.RWMutex
+	orders map[OrderID]Order
+
+	events  chan OrderEvent
+	refresh chan struct{}
+}
+
+// NewOrderTracker fetches the open orders of markets and keeps them current by polling
+// every interval until ctx is done, then closes the events channel.
+// The initial orders are available through Open and Get and are not reported as events.
+func NewOrderTracker(ctx context.Context, rest TradingClient, interval time.Duration, markets ...string) (*OrderTracker, error) {
+	if len(markets) == 0 {
+		return nil, errors.New("at least one market is required")
+	}
+	if interval <= 0 {
+		return nil, errors.New("poll interval must be positive")
+	}
+	t := &OrderTracker{
+		rest:     rest,
+		markets:  markets,
+		interval: interval,
+		orders:   make(map[OrderID]Order),
+		events:   make(chan OrderEvent, 64),
+		refresh:  make(chan struct{}, 1),
+	}
+	for _, market := range markets {
+		orders, err := t.fetch(ctx, market)
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range orders {
+			t.orders[o.ID] = o
+		}
+	}
+	go t.run(ctx)
+	return t, nil
+}
+
+// Events returns the channel of order changes, closed once the tracker stops.
+// It must be drained, polling waits while the channel is full.
+func (t *OrderTracker) Events() <-chan OrderEvent {
+	return t.events
+}
+
+// Open returns the open orders of market sorted by order id
+func (t *OrderTracker) Open(market string) []Order {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	var result []Order
+	for _, o := range t.orders {
+		if o.Market == market {
+			result = append(result, o)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+// Get returns an open order by id
+func (t *OrderTracker) Get(orderID OrderID) (Order, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	o, ok := t.orders[orderID]
+	return o, ok
+}
+
+// Refresh requests a poll without waiting for the next interval, for example right
+// after placing or cancelling an order
+func (t *OrderTracker) Refresh() {
+	select {
+	case t.refresh <- struct{}{}:
+	default:
+	}
+}
+
+func (t *OrderTracker) run(ctx context.Context) {
+	defer close(t.events)
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-t.refresh:
+		}
+		for _, market := range t.markets {
+			orders, err := t.fetch(ctx, market)
+			if err != nil {
+				// an incomplete listing would report live orders as closed, keep the
+				// known state until the next successful poll
+				continue
+			}
+			for _, e := range t.reconcile(market, orders) {
+				select {
+				case t.events <- e:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}
+}
+
+// fetch returns all open orders of market, paging through /orders. Orders placed between
+// two pages shift the offsets, a repeated order fails the fetch with ErrPaginationInconsistent.
+func (t *OrderTracker) fetch(ctx context.Context, market string) ([]Order, error) {
+	var orders []Order
+	seen := make(map[OrderID]bool)
+	for offset := 0; ; offset += openOrdersPageLimit {
+		resp, err := t.rest.PostOpenOrders(ctx, &OpenOrdersRequest{Market: market, Offset: offset, Limit: openOrdersPageLimit})
+		if err != nil {
+			return nil, err
+		}
+		if !resp.Success {
+			return nil, resp.Err()
+		}
+		page := resp.Result.Records
+		for _, o := range page {
+			if seen[o.ID] {
+				return nil, fmt.Errorf("%w: order %d repeated at offset %d", ErrPaginationInconsistent, o.ID, offset)
+			}
+			seen[o.ID] = true
+		}
+		orders = append(orders, page...)
+		if total := resp.Result.Total; len(page) < openOrdersPageLimit || total > 0 && offset+len(page) >= total {
+			return orders, nil
+		}
+	}
+}
+
+// reconcile replaces the known orders of market with orders and returns the differences
+func (t *OrderTracker) reconcile(market string, orders []Order) []OrderEvent {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var events []OrderEvent
+	seen := make(map[OrderID]bool, len(orders))
+	for _, o := range orders {
+		seen[o.ID] = true
+		prev, ok := t.orders[o.ID]
+		t.orders[o.ID] = o
+		switch {
+		case !ok:
+			events = append(events, OrderEvent{Type: OrderOpened, Order: o})
+		case !prev.Left.Equal(o.Left) || !prev.DealStock.Equal(o.DealStock) || !prev.DealMoney.Equal(o.DealMoney):
+			events = append(events, OrderEvent{Type: OrderChanged, Order: o, Previous: prev})
+		}
+	}
+	for id, o := range t.orders {
+		if o.Market == market && !seen[id] {
+			delete(t.orders, id)
+			events = append(events, OrderEvent{Type: OrderClosed, Order: o})
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Order.ID < events[j].Order.ID })
+	return events
+}
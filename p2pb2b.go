@@ -1,18 +1,93 @@
 package gop2b
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
 	"math"
+	"net"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
 // baseAPI is the p2pb2b API endpoint
 const baseAPI = "https://api.p2pb2b.com/api/v2"
 const websocketApi = "wss://apiws.p2pb2b.com/"
 
+// Option configures optional client behaviour
+type Option func(*client)
+
+// WithRateLimit limits the client to the given amount of requests per period.
+// Requests exceeding the limit wait for a free slot or for their context to be done.
+func WithRateLimit(requests int, per time.Duration) Option {
+	return func(c *client) {
+		c.limiter = newRateLimiter(requests, per)
+	}
+}
+
+// WithResponseCache caches successful responses of public GET endpoints for ttl, keyed by full URL.
+// Signed POST requests are never cached. Keep ttl below the refresh interval of PollDepth
+// or the poller will see cached snapshots.
+func WithResponseCache(ttl time.Duration) Option {
+	return func(c *client) {
+		c.cache = newResponseCache(ttl)
+	}
+}
+
+// WithBaseURL overrides the REST API endpoint, for example to point the client at a test server
+func WithBaseURL(url string) Option {
+	return func(c *client) {
+		c.url = strings.TrimSuffix(url, "/")
+	}
+}
+
+// WithTransport sets the transport of the HTTP client, such as a recording or replaying one in tests
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *client) {
+		c.http.Transport = transport
+	}
+}
+
+// WithDialTimeout bounds the DNS lookup and TCP and TLS connection setup to d, so an unreachable
+// host fails fast while a slow response still has the whole request context to arrive.
+// It applies to the default transport or an *http.Transport set by an earlier WithTransport,
+// other round trippers are left alone.
+func WithDialTimeout(d time.Duration) Option {
+	return func(c *client) {
+		var transport *http.Transport
+		switch t := c.http.Transport.(type) {
+		case nil:
+			transport = http.DefaultTransport.(*http.Transport).Clone()
+		case *http.Transport:
+			transport = t.Clone()
+		default:
+			return
+		}
+		dialer := &net.Dialer{Timeout: d, KeepAlive: 30 * time.Second}
+		transport.DialContext = dialer.DialContext
+		transport.TLSHandshakeTimeout = d
+		c.http.Transport = transport
+	}
+}
+
+// WithDefaultQuote sets the quote currency used to expand base currency shorthands,
+// so that market data methods accept "BTC" for "BTC_USDT" with quote USDT
+func WithDefaultQuote(quote string) Option {
+	return func(c *client) {
+		c.defaultQuote = strings.ToUpper(quote)
+	}
+}
+
 // for testing purposes only
-func newClientWithURL(url string, apiKey string, apiSecret string) (Client, error) {
-	return &client{
+func newClientWithURL(url string, apiKey string, apiSecret string, opts ...Option) (Client, error) {
+	c := &client{
 		http: &http.Client{
 			CheckRedirect: func(req *http.Request, via []*http.Request) error {
 				return http.ErrUseLastResponse
@@ -22,32 +97,212 @@ func newClientWithURL(url string, apiKey string, apiSecret string) (Client, erro
 			APIKey:    apiKey,
 			APISecret: apiSecret,
 		},
-		url:   url,
-		wsUrl: websocketApi,
-	}, nil
+		url:     url,
+		wsUrl:   websocketApi,
+		signer:  HMACSHA512Signer{},
+		metrics: NopMetrics{},
+	}
+	c.ctx, c.cancel = context.WithCancel(context.Background())
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
 }
 
 // NewClient creates a new p2pb2b client with apiKey and apiSecret
-func NewClient(apiKey string, apiSecret string) (Client, error) {
-	return newClientWithURL(baseAPI, apiKey, apiSecret)
+func NewClient(apiKey string, apiSecret string, opts ...Option) (Client, error) {
+	return newClientWithURL(baseAPI, apiKey, apiSecret, opts...)
 }
 
-// Client is the basic p2pb2b client interface
-type Client interface {
+// PublicClient is the part of Client for the public market data endpoints, no credentials needed
+type PublicClient interface {
+	GetPublic(ctx context.Context, path string, params url.Values, out interface{}) error
+	GetMarkets(ctx context.Context) (*MarketsResp, error)
+	GetTickers(ctx context.Context) (*TickersResp, error)
+	GetKlines(ctx context.Context, market string, interval KlineInterval, offset int, limit int) (*KlinesResp, error)
+	BackfillKlines(ctx context.Context, market string, interval KlineInterval, from, to time.Time) (*KlineBackfill, error)
+	GetKlineRange(ctx context.Context, market string, interval KlineInterval, start, end time.Time) ([]Kline, error)
+	FetchKlines(ctx context.Context, request KlineRequest) ([]Kline, error)
+	GetHistory(ctx context.Context, market string, lastID int64, limit int) (*HistoryResp, error)
+	FetchHistory(ctx context.Context, request HistoryRequest) (*HistoryResp, error)
+	HistorySince(ctx context.Context, market string, lastID int64, max int) ([]Trade, error)
+	GetTicker(ctx context.Context, market string) (*TickerResp, error)
+	GetDepth(ctx context.Context, market string, limit int, interval string) (*DepthResp, error)
+	GetBestQuotes(ctx context.Context, market string) (bid, ask decimal.Decimal, err error)
+	PollDepth(ctx context.Context, market string, limit int, interval string, refresh time.Duration) (<-chan DepthResp, error)
+	ConversionRate(ctx context.Context, from, to string) (*Conversion, error)
+	ResolveMarket(ctx context.Context, market string) (string, error)
+}
+
+// AccountClient is the part of Client for the signed account endpoints
+type AccountClient interface {
 	PostCurrencyBalance(request *AccountCurrencyBalanceRequest) (*AccountCurrencyBalanceResp, error)
 	PostBalances(request *AccountBalancesRequest) (*AccountBalancesResp, error)
+	PostSigned(ctx context.Context, path string, body interface{}, out interface{}) error
+	PortfolioValue(ctx context.Context, quote string) (*Portfolio, error)
+}
+
+// TradingClient is the part of Client placing and listing orders
+type TradingClient interface {
+	PostNewOrder(ctx context.Context, request *NewOrderRequest) (*NewOrderResp, error)
+	CheckOrder(ctx context.Context, request *NewOrderRequest) error
+	PostOpenOrders(ctx context.Context, request *OpenOrdersRequest) (*OpenOrdersResp, error)
+	PostOrderHistory(ctx context.Context, request *OrderHistoryRequest) (*OrderHistoryResp, error)
+}
+
+// WsProvider creates websocket clients for the websocket API of the exchange
+type WsProvider interface {
+	NewWS(opts ...WSOption) *WSClient
+}
+
+// Client is the basic p2pb2b client interface. Code needing only a part of it should accept
+// PublicClient, AccountClient, TradingClient or WsProvider instead.
+type Client interface {
+	PublicClient
+	AccountClient
+	TradingClient
+	WsProvider
+	CacheStats() CacheStats
+	PurgeCache()
+	Stats() map[string]EndpointStats
+	ResetStats()
+	RecentExchanges() []Exchange
+	HealthCheck(ctx context.Context) (HealthReport, error)
+	Shutdown(ctx context.Context) error
+}
+
+// NewWS creates a websocket client for the websocket API of the exchange, opts being applied
+// after the URL so WithWSURL still overrides it
+func (c *client) NewWS(opts ...WSOption) *WSClient {
+	return NewWSClient(append([]WSOption{WithWSURL(c.wsUrl)}, opts...)...)
 }
 
 // Response is the basic http response struct
 type Response struct {
 	Success bool   `json:"success"`
 	Message string `json:"message"`
+	// ErrorCode is set along with Success false
+	ErrorCode   ErrorCode `json:"errorCode,omitempty"`
+	CacheTime   float64   `json:"cache_time"`
+	CurrentTime float64   `json:"current_time"`
+
+	resultPresent bool
+}
+
+// ResultPresent reports whether the response carried a result, false when it was null or missing.
+// An empty object or array is present.
+// It tells an empty result apart from zero values, such as a zero balance from an unknown currency.
+func (r *Response) ResultPresent() bool {
+	return r.resultPresent
+}
+
+func (r *Response) setResultPresent(present bool) {
+	r.resultPresent = present
+}
+
+// Err returns the *APIError of a response with success false, nil otherwise
+func (r *Response) Err() error {
+	if r.Success {
+		return nil
+	}
+	return &APIError{Code: r.ErrorCode, Message: r.Message}
+}
+
+// Envelope is a response with its result. The response types of the endpoints are
+// instances of it, named for discoverability.
+type Envelope[T any] struct {
+	Response
+	Result T `json:"result"`
+}
+
+// PaginatedResult is the result of a paginated list endpoint, nested in the response as
+// {"limit":50,"offset":0,"total":120,"records":[...]}. Some endpoints name the records
+// "result", and some answer a bare array, which decodes into Records with the other fields zero.
+type PaginatedResult[T any] struct {
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+	// Total is the amount of records across all pages, zero when the endpoint doesn't tell
+	Total   int `json:"total"`
+	Records []T `json:"records"`
+}
+
+// UnmarshalJSON accepts the nested envelope as well as a bare array of records
+func (p *PaginatedResult[T]) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		*p = PaginatedResult[T]{}
+		return json.Unmarshal(data, &p.Records)
+	}
+	type plain PaginatedResult[T]
+	var v struct {
+		plain
+		Result []T `json:"result"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*p = PaginatedResult[T](v.plain)
+	if p.Records == nil {
+		p.Records = v.Result
+	}
+	return nil
 }
 
 // Request is the basic http request struct
 type Request struct {
 	Request string `json:"request"`
-	Nonce   string `json:"nonce"`
+	Nonce   Nonce  `json:"nonce"`
+}
+
+// prepare sets the endpoint path and a fresh nonce before the request gets signed
+func (r *Request) prepare(path string) {
+	r.Request = "/api/v2" + path
+	r.Nonce = Nonce(nextNonce())
+}
+
+// Nonce is the increasing number of a signed request. It is sent as a decimal string, the
+// signed payload carrying it as the exchange expects.
+type Nonce int64
+
+func (n Nonce) String() string {
+	return strconv.FormatInt(int64(n), 10)
+}
+
+// MarshalJSON writes the nonce as a JSON string
+func (n Nonce) MarshalJSON() ([]byte, error) {
+	return json.Marshal(n.String())
+}
+
+// UnmarshalJSON accepts the nonce as a string or a number
+func (n *Nonce) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid nonce %s", data)
+	}
+	*n = Nonce(v)
+	return nil
+}
+
+// lastNonce is the last nonce handed out
+var lastNonce atomic.Int64
+
+// nextNonce returns the current unix time in milliseconds, or one more than the previous
+// nonce when requests are signed within the same millisecond, as the exchange requires increasing nonces
+func nextNonce() int64 {
+	for {
+		last := lastNonce.Load()
+		nonce := time.Now().UnixMilli()
+		if nonce <= last {
+			nonce = last + 1
+		}
+		if lastNonce.CompareAndSwap(last, nonce) {
+			return nonce
+		}
+	}
 }
 
 // TimestampToTime is a convenience function to convert a float64 timestamp to time.Time
@@ -0,0 +1,124 @@
+package gop2b_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sutapurachina/gop2b"
+	"github.com/sutapurachina/gop2b/gop2btest"
+)
+
+// newTestClient starts a fixture server and returns a client of it, both closed with the test
+func newTestClient(t *testing.T, opts ...gop2b.Option) (gop2b.Client, *gop2btest.Server) {
+	t.Helper()
+	server := gop2btest.NewServer()
+	t.Cleanup(server.Close)
+	client, err := server.Client(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, server
+}
+
+// checkDecimal fails the test when got isn't equal to the decimal want
+func checkDecimal(t *testing.T, name string, got decimal.Decimal, want string) {
+	t.Helper()
+	if !got.Equal(decimal.RequireFromString(want)) {
+		t.Errorf("%s %s, want %s", name, got, want)
+	}
+}
+
+func TestWithDialTimeout(t *testing.T) {
+	// a listener accepting connections but never answering the TLS handshake
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		var conns []net.Conn
+		defer func() {
+			for _, c := range conns {
+				c.Close()
+			}
+		}()
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conns = append(conns, conn)
+		}
+	}()
+
+	client, err := gop2b.NewClient("", "", gop2b.WithBaseURL("https://"+listener.Addr().String()), gop2b.WithDialTimeout(100*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	_, err = client.GetMarkets(context.Background())
+	if err == nil {
+		t.Fatal("no error from the unresponsive host")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("failed after %s, want the 100ms dial timeout", elapsed)
+	}
+}
+
+func TestWithDialTimeoutSlowResponse(t *testing.T) {
+	client, server := newTestClient(t, gop2b.WithDialTimeout(50*time.Millisecond))
+	server.SetLatency("/public/markets", 200*time.Millisecond)
+	if _, err := client.GetMarkets(context.Background()); err != nil {
+		t.Errorf("slow response failed: %v", err)
+	}
+}
+
+func TestWithDialTimeoutKeepsRedirects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/elsewhere", http.StatusFound)
+	}))
+	defer server.Close()
+	client, err := gop2b.NewClient("", "", gop2b.WithBaseURL(server.URL), gop2b.WithDialTimeout(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.GetMarkets(context.Background())
+	var status *gop2b.StatusError
+	if !errors.As(err, &status) || status.StatusCode != http.StatusFound {
+		t.Errorf("error %v, want the redirect status unfollowed", err)
+	}
+}
+
+func TestNonceJSON(t *testing.T) {
+	data, err := json.Marshal(gop2b.Nonce(1700000000123))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `"1700000000123"` {
+		t.Errorf("nonce marshalled to %s, want a JSON string", data)
+	}
+	data, err = json.Marshal(gop2b.Request{Request: "/api/v2/account/balances", Nonce: 1700000000123})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"request":"/api/v2/account/balances","nonce":"1700000000123"}`; string(data) != want {
+		t.Errorf("request marshalled to %s, want %s", data, want)
+	}
+	for _, input := range []string{`"1700000000123"`, `1700000000123`} {
+		var n gop2b.Nonce
+		if err := json.Unmarshal([]byte(input), &n); err != nil || n != 1700000000123 {
+			t.Errorf("%s decoded to %d, %v", input, n, err)
+		}
+	}
+	var n gop2b.Nonce
+	if err := json.Unmarshal([]byte(`"abc"`), &n); err == nil {
+		t.Error(`"abc" decoded as a nonce`)
+	}
+}
@@ -0,0 +1,190 @@
+package gop2b
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/shopspring/decimal"
+)
+
+// pnlPrecision is the amount of decimal places kept when splitting lots
+const pnlPrecision = 28
+
+// LotMethod selects how closing deals are matched against open lots
+type LotMethod int
+
+const (
+	// FIFO closes the oldest open lots first
+	FIFO LotMethod = iota
+	// AverageCost keeps a single lot at the weighted average entry price
+	AverageCost
+)
+
+// PnLOptions configures PnL
+type PnLOptions struct {
+	Method LotMethod
+	// Stock is the base currency of the market. Fees charged in it are valued at the deal
+	// price, any other fee currency is taken as the quote currency.
+	Stock Currency
+	// Dust is the position size below which the remaining position is written off as closed,
+	// usually the smallest amount step of the market
+	Dust decimal.Decimal
+}
+
+// LotMatch is the part of an open lot closed by a later deal
+type LotMatch struct {
+	// OpenDealID is the deal which opened the lot, zero with AverageCost
+	OpenDealID  DealID
+	CloseDealID DealID
+	// Side is the side of the closed position, buy for long and sell for short
+	Side       Side
+	Amount     decimal.Decimal
+	EntryPrice decimal.Decimal
+	ExitPrice  decimal.Decimal
+	// Fees are the entry and exit fees allocated to this match, in the quote currency
+	Fees     decimal.Decimal
+	Realized decimal.Decimal
+}
+
+// PnLReport is the realized profit and loss of a sequence of deals, money values in the quote currency
+type PnLReport struct {
+	Method   LotMethod
+	Realized decimal.Decimal
+	// Fees are all fees paid, including those of the still open position
+	Fees decimal.Decimal
+	// Position is the open amount, negative when short
+	Position          decimal.Decimal
+	AverageEntryPrice decimal.Decimal
+	Matches           []LotMatch
+	// Dust is the total amount written off below PnLOptions.Dust
+	Dust decimal.Decimal
+}
+
+type pnlLot struct {
+	dealID DealID
+	side   Side
+	amount decimal.Decimal
+	// cost is the money paid, or received for short lots, for amount
+	cost decimal.Decimal
+	// fee is the entry fee not yet allocated to a match
+	fee decimal.Decimal
+}
+
+// take splits amount off the lot, returning its share of cost and fee.
+// Taking the whole lot returns the exact remainders so no rounding is lost.
+func (l *pnlLot) take(amount decimal.Decimal) (cost, fee decimal.Decimal) {
+	if amount.GreaterThanOrEqual(l.amount) {
+		cost, fee = l.cost, l.fee
+		l.amount, l.cost, l.fee = decimal.Zero, decimal.Zero, decimal.Zero
+		return cost, fee
+	}
+	cost = l.cost.Mul(amount).DivRound(l.amount, pnlPrecision)
+	fee = l.fee.Mul(amount).DivRound(l.amount, pnlPrecision)
+	l.amount, l.cost, l.fee = l.amount.Sub(amount), l.cost.Sub(cost), l.fee.Sub(fee)
+	return cost, fee
+}
+
+// PnL computes the realized profit and loss of the deals of a single market, fees
+// included. Deals are processed in time order; a deal larger than the open position
+// closes it and opens the opposite one with the remainder.
+func PnL(deals []Deal, opts PnLOptions) (*PnLReport, error) {
+	sorted := make([]Deal, len(deals))
+	copy(sorted, deals)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if !sorted[i].Time.Equal(sorted[j].Time.Time) {
+			return sorted[i].Time.Before(sorted[j].Time.Time)
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	report := &PnLReport{Method: opts.Method}
+	var lots []*pnlLot
+	var market Market
+	for _, d := range sorted {
+		side := d.Side
+		if side != SideBuy && side != SideSell {
+			return nil, fmt.Errorf("deal %d: invalid side %q", d.ID, d.Side)
+		}
+		if !d.Amount.IsPositive() {
+			return nil, fmt.Errorf("deal %d: amount must be positive", d.ID)
+		}
+		if d.Market != "" {
+			if market != "" && d.Market != market {
+				return nil, errors.New("deals of more than one market")
+			}
+			market = d.Market
+		}
+		fee := d.Fee
+		if opts.Stock != "" && d.FeeCurrency == opts.Stock {
+			fee = fee.Mul(d.Price)
+		}
+		report.Fees = report.Fees.Add(fee)
+		incoming := &pnlLot{dealID: d.ID, side: side, amount: d.Amount, cost: d.Amount.Mul(d.Price), fee: fee}
+
+		for len(lots) > 0 && lots[0].side != side && incoming.amount.IsPositive() {
+			open := lots[0]
+			matched := decimal.Min(open.amount, incoming.amount)
+			entryCost, entryFee := open.take(matched)
+			exitValue, exitFee := incoming.take(matched)
+			realized := exitValue.Sub(entryCost)
+			if open.side == SideSell {
+				realized = realized.Neg()
+			}
+			fees := entryFee.Add(exitFee)
+			realized = realized.Sub(fees)
+			match := LotMatch{
+				CloseDealID: d.ID,
+				Side:        open.side,
+				Amount:      matched,
+				EntryPrice:  entryCost.DivRound(matched, pnlPrecision),
+				ExitPrice:   d.Price,
+				Fees:        fees,
+				Realized:    realized,
+			}
+			if opts.Method == FIFO {
+				match.OpenDealID = open.dealID
+			}
+			report.Matches = append(report.Matches, match)
+			report.Realized = report.Realized.Add(realized)
+			if !open.amount.IsPositive() {
+				lots = lots[1:]
+			}
+		}
+
+		if incoming.amount.IsPositive() {
+			if opts.Method == AverageCost && len(lots) > 0 {
+				lots[0].amount = lots[0].amount.Add(incoming.amount)
+				lots[0].cost = lots[0].cost.Add(incoming.cost)
+				lots[0].fee = lots[0].fee.Add(incoming.fee)
+				lots[0].dealID = 0
+			} else {
+				lots = append(lots, incoming)
+			}
+		}
+
+		if opts.Dust.IsPositive() {
+			open := decimal.Zero
+			for _, l := range lots {
+				open = open.Add(l.amount)
+			}
+			if open.IsPositive() && open.LessThan(opts.Dust) {
+				report.Dust = report.Dust.Add(open)
+				lots = nil
+			}
+		}
+	}
+
+	cost := decimal.Zero
+	for _, l := range lots {
+		report.Position = report.Position.Add(l.amount)
+		cost = cost.Add(l.cost)
+	}
+	if report.Position.IsPositive() {
+		report.AverageEntryPrice = cost.DivRound(report.Position, pnlPrecision)
+		if lots[0].side == SideSell {
+			report.Position = report.Position.Neg()
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,133 @@
+package gop2b_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sutapurachina/gop2b"
+)
+
+// pnlDeal returns a deal of ETH_BTC at the minute n of a fixed day, fees in the quote currency
+func pnlDeal(id int64, minute int, side gop2b.Side, price, amount, fee string) gop2b.Deal {
+	return gop2b.Deal{
+		ID:     gop2b.DealID(id),
+		Time:   gop2b.ExchangeTimeSeconds{Time: time.Date(2023, 11, 14, 0, minute, 0, 0, time.UTC)},
+		Side:   side,
+		Price:  decimal.RequireFromString(price),
+		Amount: decimal.RequireFromString(amount),
+		Fee:    decimal.RequireFromString(fee),
+		Market: "ETH_BTC",
+	}
+}
+
+func TestPnLFlipLongToShort(t *testing.T) {
+	deals := []gop2b.Deal{
+		pnlDeal(1, 0, gop2b.SideBuy, "100", "1", "0.1"),
+		// closes the long and opens a short of 2, two thirds of the fee go to the short
+		pnlDeal(2, 1, gop2b.SideSell, "110", "3", "0.3"),
+	}
+	for _, method := range []gop2b.LotMethod{gop2b.FIFO, gop2b.AverageCost} {
+		report, err := gop2b.PnL(deals, gop2b.PnLOptions{Method: method})
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkDecimal(t, "realized", report.Realized, "9.8")
+		checkDecimal(t, "position", report.Position, "-2")
+		checkDecimal(t, "average entry price", report.AverageEntryPrice, "110")
+		checkDecimal(t, "fees", report.Fees, "0.4")
+		if len(report.Matches) != 1 || report.Matches[0].Side != gop2b.SideBuy {
+			t.Fatalf("matches %+v, want the long closed", report.Matches)
+		}
+		checkDecimal(t, "long amount", report.Matches[0].Amount, "1")
+		checkDecimal(t, "long fees", report.Matches[0].Fees, "0.2")
+
+		// covering the short at 105 gains 5 per unit, less the carried and the new fee
+		report, err = gop2b.PnL(append(deals, pnlDeal(3, 2, gop2b.SideBuy, "105", "2", "0.1")), gop2b.PnLOptions{Method: method})
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkDecimal(t, "realized", report.Realized, "19.5")
+		checkDecimal(t, "position", report.Position, "0")
+		if len(report.Matches) != 2 || report.Matches[1].Side != gop2b.SideSell {
+			t.Fatalf("matches %+v, want the long and then the short closed", report.Matches)
+		}
+		short := report.Matches[1]
+		checkDecimal(t, "short entry price", short.EntryPrice, "110")
+		checkDecimal(t, "short exit price", short.ExitPrice, "105")
+		checkDecimal(t, "short fees", short.Fees, "0.3")
+		checkDecimal(t, "short realized", short.Realized, "9.7")
+		if method == gop2b.FIFO && short.OpenDealID != 2 {
+			t.Errorf("short opened by deal %d, want 2", short.OpenDealID)
+		}
+	}
+}
+
+func TestPnLDust(t *testing.T) {
+	tests := []struct {
+		name     string
+		deals    []gop2b.Deal
+		dust     string
+		position string
+		written  string
+	}{
+		{
+			name: "long remainder below dust",
+			deals: []gop2b.Deal{
+				pnlDeal(1, 0, gop2b.SideBuy, "100", "1", "0"),
+				pnlDeal(2, 1, gop2b.SideSell, "110", "0.99999999", "0"),
+			},
+			dust: "0.0001", position: "0", written: "0.00000001",
+		},
+		{
+			name: "short remainder below dust",
+			deals: []gop2b.Deal{
+				pnlDeal(1, 0, gop2b.SideSell, "100", "1", "0"),
+				pnlDeal(2, 1, gop2b.SideBuy, "90", "0.99995", "0"),
+			},
+			dust: "0.0001", position: "0", written: "0.00005",
+		},
+		{
+			name: "remainder at dust kept",
+			deals: []gop2b.Deal{
+				pnlDeal(1, 0, gop2b.SideBuy, "100", "1", "0"),
+				pnlDeal(2, 1, gop2b.SideSell, "110", "0.9999", "0"),
+			},
+			dust: "0.0001", position: "0.0001", written: "0",
+		},
+		{
+			name: "no dust threshold",
+			deals: []gop2b.Deal{
+				pnlDeal(1, 0, gop2b.SideBuy, "100", "1", "0"),
+				pnlDeal(2, 1, gop2b.SideSell, "110", "0.99999999", "0"),
+			},
+			dust: "0", position: "0.00000001", written: "0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, method := range []gop2b.LotMethod{gop2b.FIFO, gop2b.AverageCost} {
+				report, err := gop2b.PnL(tt.deals, gop2b.PnLOptions{Method: method, Dust: decimal.RequireFromString(tt.dust)})
+				if err != nil {
+					t.Fatal(err)
+				}
+				checkDecimal(t, "position", report.Position, tt.position)
+				checkDecimal(t, "dust", report.Dust, tt.written)
+				if report.Position.IsZero() && !report.AverageEntryPrice.IsZero() {
+					t.Errorf("average entry price %s of a closed position", report.AverageEntryPrice)
+				}
+				if tt.written == "0" {
+					continue
+				}
+				// a later deal opens a fresh position without the written off dust
+				deals := append(tt.deals[:len(tt.deals):len(tt.deals)], pnlDeal(3, 2, gop2b.SideBuy, "120", "1", "0"))
+				report, err = gop2b.PnL(deals, gop2b.PnLOptions{Method: method, Dust: decimal.RequireFromString(tt.dust)})
+				if err != nil {
+					t.Fatal(err)
+				}
+				checkDecimal(t, "reopened position", report.Position, "1")
+				checkDecimal(t, "reopened average entry price", report.AverageEntryPrice, "120")
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package gop2b
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrPollStopped is passed to the WithPollErrorHook hook, wrapping the last error, when
+// PollDepth gives up after pollMaxFailures consecutive failed fetches
+var ErrPollStopped = errors.New("polling stopped")
+
+// pollMaxFailures is the amount of consecutive failed fetches after which PollDepth stops
+const pollMaxFailures = 5
+
+// WithPollErrorHook calls fn with the market and error of every failed PollDepth fetch, so a
+// dead endpoint can be told apart from a quiet book
+func WithPollErrorHook(fn func(market string, err error)) Option {
+	return func(c *client) {
+		c.onPollError = fn
+	}
+}
+
+// PollDepth fetches the order book of market every refresh interval and pushes each
+// snapshot to the returned channel. It is a REST alternative to the depth websocket.
+// A tick is skipped while the previous fetch is still in flight. Failed fetches are reported
+// to the WithPollErrorHook hook and retried on the next tick; after five failures in a row
+// polling stops and the channel is closed, the last error wrapped in ErrPollStopped.
+// Requests go through the client rate limiter. The channel is closed once ctx is done or
+// the client is shut down.
+func (c *client) PollDepth(ctx context.Context, market string, limit int, interval string, refresh time.Duration) (<-chan DepthResp, error) {
+	if market == "" {
+		return nil, errors.New("market is required")
+	}
+	if refresh <= 0 {
+		return nil, errors.New("refresh interval must be positive")
+	}
+
+	out := make(chan DepthResp, 1)
+	ctx, stop := context.WithCancel(ctx)
+	var (
+		wg       sync.WaitGroup
+		inFlight atomic.Bool
+		failures atomic.Int32
+	)
+	fetch := func() {
+		defer wg.Done()
+		defer inFlight.Store(false)
+		resp, err := c.GetDepth(ctx, market, limit, interval)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			if n := failures.Add(1); n >= pollMaxFailures {
+				err = fmt.Errorf("%w after %d failed fetches: %w", ErrPollStopped, n, err)
+				stop()
+			}
+			if c.onPollError != nil {
+				c.onPollError(market, err)
+			}
+			return
+		}
+		failures.Store(0)
+		select {
+		case out <- *resp:
+		case <-ctx.Done():
+		case <-c.ctx.Done():
+		}
+	}
+	poll := func() {
+		if !inFlight.CompareAndSwap(false, true) {
+			return
+		}
+		wg.Add(1)
+		go fetch()
+	}
+
+	err := c.goBackground(func() {
+		ticker := time.NewTicker(refresh)
+		defer func() {
+			ticker.Stop()
+			wg.Wait()
+			stop()
+			close(out)
+		}()
+		poll()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-c.ctx.Done():
+				return
+			case <-ticker.C:
+				poll()
+			}
+		}
+	})
+	if err != nil {
+		stop()
+		return nil, err
+	}
+	return out, nil
+}
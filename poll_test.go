@@ -0,0 +1,90 @@
+package gop2b_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sutapurachina/gop2b"
+)
+
+// pollErrors collects the errors passed to a WithPollErrorHook hook
+type pollErrors struct {
+	mu   sync.Mutex
+	errs []error
+}
+
+func (p *pollErrors) hook(market string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.errs = append(p.errs, err)
+}
+
+func (p *pollErrors) get() []error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]error(nil), p.errs...)
+}
+
+func TestPollDepthStopsAfterFailures(t *testing.T) {
+	var errs pollErrors
+	client, server := newTestClient(t, gop2b.WithPollErrorHook(errs.hook))
+	server.SetError("/public/depth/result", http.StatusInternalServerError, "down")
+	updates, err := client.PollDepth(context.Background(), "ETH_BTC", 10, "", 5*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case update, ok := <-updates:
+		if ok {
+			t.Fatalf("update %+v from a failing endpoint", update)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("polling a dead endpoint didn't stop")
+	}
+	got := errs.get()
+	if len(got) != 5 {
+		t.Fatalf("%d errors reported, want 5: %v", len(got), got)
+	}
+	var status *gop2b.StatusError
+	for i, err := range got {
+		if !errors.As(err, &status) || status.StatusCode != http.StatusInternalServerError {
+			t.Errorf("error %d %v, want the 500", i, err)
+		}
+		if stopped := errors.Is(err, gop2b.ErrPollStopped); stopped != (i == len(got)-1) {
+			t.Errorf("error %d %v, only the last one is ErrPollStopped", i, err)
+		}
+	}
+}
+
+func TestPollDepthRecovers(t *testing.T) {
+	var errs pollErrors
+	client, server := newTestClient(t, gop2b.WithPollErrorHook(errs.hook))
+	server.SetError("/public/depth/result", http.StatusInternalServerError, "down")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	updates, err := client.PollDepth(ctx, "ETH_BTC", 10, "", 5*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "a reported error", func() bool { return len(errs.get()) > 0 })
+	server.ClearError("/public/depth/result")
+	if update := receive(t, updates); len(update.Result.Asks) == 0 {
+		t.Errorf("update %+v, want the book", update)
+	}
+	for _, err := range errs.get() {
+		if errors.Is(err, gop2b.ErrPollStopped) {
+			t.Errorf("stopped after %d errors", len(errs.get()))
+		}
+	}
+	reported := len(errs.get())
+	cancel()
+	for range updates {
+	}
+	if n := len(errs.get()); n != reported {
+		t.Errorf("%d errors after the cancellation, want %d: it must not be reported", n, reported)
+	}
+}
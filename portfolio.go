@@ -0,0 +1,210 @@
+package gop2b
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// conversionPrecision is the amount of decimal places kept when a conversion rate involves division
+const conversionPrecision = 18
+
+// hubCurrencies are tried in order as intermediate currency when no direct market exists
+var hubCurrencies = []Currency{"BTC", "USDT"}
+
+// HoldingValue is a single currency holding valued in the portfolio quote currency
+type HoldingValue struct {
+	Currency Currency
+	// Amount is the total holding, available plus frozen
+	Amount decimal.Decimal
+	// Price is the value of one unit of Currency in the quote currency
+	Price decimal.Decimal
+	Value decimal.Decimal
+	// Path lists the markets used for the conversion, empty when Currency is the quote currency
+	Path []Market
+}
+
+// Portfolio is the valuation of all non-zero balances in a quote currency
+type Portfolio struct {
+	Quote    Currency
+	Holdings []HoldingValue
+	// Unvalued holds the currencies without any price path to Quote, only Currency and Amount are set
+	Unvalued []HoldingValue
+	Total    decimal.Decimal
+}
+
+// PortfolioValue values every non-zero balance of the account in quote using the last
+// traded prices. Direct markets are preferred, otherwise the price is routed through BTC or USDT.
+func (c *client) PortfolioValue(ctx context.Context, quote string) (*Portfolio, error) {
+	quoteCurrency := Currency(normalizeCurrency(quote))
+	if quoteCurrency == "" {
+		return nil, errors.New("quote currency is required")
+	}
+	prices, err := c.priceTable(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var balances AccountBalancesResp
+	if err := c.postEndpoint(ctx, &AccountBalancesRequest{}, &balances); err != nil {
+		return nil, err
+	}
+	if !balances.Success {
+		return nil, balances.Err()
+	}
+
+	portfolio := &Portfolio{Quote: quoteCurrency}
+	for currency, balance := range balances.Result {
+		amount := balance.Available.Add(balance.Freeze)
+		if amount.Sign() <= 0 {
+			continue
+		}
+		holding := HoldingValue{Currency: currency, Amount: amount}
+		price, path, ok := prices.rate(currency, quoteCurrency)
+		if !ok {
+			portfolio.Unvalued = append(portfolio.Unvalued, holding)
+			continue
+		}
+		holding.Price = price
+		holding.Value = amount.Mul(price)
+		holding.Path = path
+		portfolio.Holdings = append(portfolio.Holdings, holding)
+		portfolio.Total = portfolio.Total.Add(holding.Value)
+	}
+	sort.Slice(portfolio.Holdings, func(i, j int) bool {
+		return portfolio.Holdings[i].Currency < portfolio.Holdings[j].Currency
+	})
+	sort.Slice(portfolio.Unvalued, func(i, j int) bool {
+		return portfolio.Unvalued[i].Currency < portfolio.Unvalued[j].Currency
+	})
+	return portfolio, nil
+}
+
+// ErrNoConversionPath is returned when two currencies can't be converted through the listed markets
+var ErrNoConversionPath = errors.New("no conversion path")
+
+// Conversion is the rate between two currencies and how it was found
+type Conversion struct {
+	From Currency
+	To   Currency
+	// Rate is the value of one unit of From in To
+	Rate decimal.Decimal
+	// Path lists the markets used, empty when From and To are the same currency
+	Path []Market
+	// At is the time of the oldest ticker used, zero when Path is empty
+	At time.Time
+}
+
+// ConversionRate converts one unit of from into to using the last traded prices of the cached
+// markets. A direct market is preferred, listed either way round, otherwise the rate is routed through BTC or USDT.
+func (c *client) ConversionRate(ctx context.Context, from, to string) (*Conversion, error) {
+	fromCurrency, toCurrency := Currency(normalizeCurrency(from)), Currency(normalizeCurrency(to))
+	prices, err := c.priceTable(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rate, path, ok := prices.rate(fromCurrency, toCurrency)
+	if !ok {
+		return nil, fmt.Errorf("%w from %s to %s", ErrNoConversionPath, fromCurrency, toCurrency)
+	}
+	return &Conversion{From: fromCurrency, To: toCurrency, Rate: rate, Path: path, At: prices.oldest(path)}, nil
+}
+
+// priceTable builds a priceTable from the cached markets and the current tickers
+func (c *client) priceTable(ctx context.Context) (*priceTable, error) {
+	markets, err := c.cachedMarkets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tickers, err := c.GetTickers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !tickers.Success {
+		return nil, tickers.Err()
+	}
+	return newPriceTable(markets, tickers.Result), nil
+}
+
+// priceTable resolves conversion rates between currencies from last market prices
+type priceTable struct {
+	// markets maps a {stock, money} pair to the market name
+	markets map[[2]Currency]Market
+	prices  map[Market]decimal.Decimal
+	// times holds the ticker time of every priced market
+	times map[Market]time.Time
+}
+
+func newPriceTable(markets map[Market]MarketInfo, tickers map[string]TickerEntry) *priceTable {
+	t := &priceTable{
+		markets: make(map[[2]Currency]Market, len(markets)),
+		prices:  make(map[Market]decimal.Decimal, len(tickers)),
+		times:   make(map[Market]time.Time, len(tickers)),
+	}
+	for _, m := range markets {
+		t.markets[[2]Currency{m.Stock, m.Money}] = m.Name
+	}
+	for name, entry := range tickers {
+		if entry.Ticker.Last.IsPositive() {
+			t.prices[Market(name)] = entry.Ticker.Last
+			t.times[Market(name)] = entry.At.Time
+		}
+	}
+	return t
+}
+
+// oldest returns the earliest ticker time of the markets in path
+func (t *priceTable) oldest(path []Market) time.Time {
+	var at time.Time
+	for _, m := range path {
+		if ts := t.times[m]; at.IsZero() || ts.Before(at) {
+			at = ts
+		}
+	}
+	return at
+}
+
+// rate returns the value of one unit of from in to and the markets used to compute it
+func (t *priceTable) rate(from, to Currency) (decimal.Decimal, []Market, bool) {
+	if from == to {
+		return decimal.NewFromInt(1), nil, true
+	}
+	if num, den, m, ok := t.direct(from, to); ok {
+		return num.DivRound(den, conversionPrecision), []Market{m}, true
+	}
+	for _, hub := range hubCurrencies {
+		if hub == from || hub == to {
+			continue
+		}
+		num1, den1, m1, ok := t.direct(from, hub)
+		if !ok {
+			continue
+		}
+		num2, den2, m2, ok := t.direct(hub, to)
+		if !ok {
+			continue
+		}
+		// divide once at the end so inverted legs don't compound rounding
+		return num1.Mul(num2).DivRound(den1.Mul(den2), conversionPrecision), []Market{m1, m2}, true
+	}
+	return decimal.Zero, nil, false
+}
+
+// direct converts using a single market listed in either direction, the rate is num / den
+func (t *priceTable) direct(from, to Currency) (num, den decimal.Decimal, market Market, ok bool) {
+	one := decimal.NewFromInt(1)
+	if m, ok := t.markets[[2]Currency{from, to}]; ok {
+		if p, ok := t.prices[m]; ok {
+			return p, one, m, true
+		}
+	}
+	if m, ok := t.markets[[2]Currency{to, from}]; ok {
+		if p, ok := t.prices[m]; ok {
+			return one, p, m, true
+		}
+	}
+	return decimal.Zero, decimal.Zero, "", false
+}
@@ -0,0 +1,42 @@
+package gop2b_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestPortfolioValue(t *testing.T) {
+	for _, quote := range []string{"USDT", "usdt"} {
+		t.Run(quote, func(t *testing.T) {
+			client, _ := newTestClient(t)
+			portfolio, err := client.PortfolioValue(context.Background(), quote)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if portfolio.Quote != "USDT" {
+				t.Errorf("quote %q, want USDT", portfolio.Quote)
+			}
+			if len(portfolio.Unvalued) != 0 {
+				t.Errorf("unvalued %v, want none", portfolio.Unvalued)
+			}
+			// 0.54125 BTC at 37000 + 3.2 ETH at 2035 + 1500.25 USDT
+			if want := decimal.RequireFromString("28038.5"); !portfolio.Total.Equal(want) {
+				t.Errorf("total %s, want %s", portfolio.Total, want)
+			}
+		})
+	}
+}
+
+func TestPortfolioValueContext(t *testing.T) {
+	client, server := newTestClient(t)
+	server.SetLatency("/account/balances", 300*time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.PortfolioValue(ctx, "USDT"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error %v, want the deadline of ctx", err)
+	}
+}
@@ -0,0 +1,112 @@
+package gop2b
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// PriceStep returns one unit of the money precision, 10^-precision
+func (m MarketInfo) PriceStep() decimal.Decimal {
+	return decimal.New(1, -int32(m.Precision.Money))
+}
+
+// AmountStep returns one unit of the stock precision, 10^-precision
+func (m MarketInfo) AmountStep() decimal.Decimal {
+	return decimal.New(1, -int32(m.Precision.Stock))
+}
+
+// Validate checks an order of amount at price against the limits of the market: the amount
+// bounds and step, and when price is set, the price bounds and tick and the minimum total.
+// It returns ErrInvalidRequest, or ErrBelowMinTotal for a total below the minimum.
+func (m MarketInfo) Validate(price, amount decimal.Decimal) error {
+	limits := m.Limits
+	step := m.StepSize()
+	switch {
+	case !amount.IsPositive():
+		return fmt.Errorf("%w: amount must be positive", ErrInvalidRequest)
+	case limits.MinAmount.IsPositive() && amount.LessThan(limits.MinAmount):
+		return fmt.Errorf("%w: amount %s below the minimum %s", ErrInvalidRequest, amount, limits.MinAmount)
+	case limits.MaxAmount.IsPositive() && amount.GreaterThan(limits.MaxAmount):
+		return fmt.Errorf("%w: amount %s above the maximum %s", ErrInvalidRequest, amount, limits.MaxAmount)
+	case !amount.Mod(step).IsZero():
+		return fmt.Errorf("%w: amount %s not a multiple of %s", ErrInvalidRequest, amount, step)
+	}
+	if !price.IsPositive() {
+		return nil
+	}
+	switch tick := m.TickSize(); {
+	case limits.MinPrice.IsPositive() && price.LessThan(limits.MinPrice):
+		return fmt.Errorf("%w: price %s below the minimum %s", ErrInvalidRequest, price, limits.MinPrice)
+	case limits.MaxPrice.IsPositive() && price.GreaterThan(limits.MaxPrice):
+		return fmt.Errorf("%w: price %s above the maximum %s", ErrInvalidRequest, price, limits.MaxPrice)
+	case !price.Mod(tick).IsZero():
+		return fmt.Errorf("%w: price %s not a multiple of %s", ErrInvalidRequest, price, tick)
+	}
+	if total := amount.Mul(price); limits.MinTotal.IsPositive() && total.LessThan(limits.MinTotal) {
+		return fmt.Errorf("%w: total %s %s below the minimum %s", ErrBelowMinTotal, total, m.Money, limits.MinTotal)
+	}
+	return nil
+}
+
+// TickSize returns the price increment of the market, the tick_size limit when the exchange
+// sets one and one unit of the money precision otherwise, 1 for integer-priced markets
+func (m MarketInfo) TickSize() decimal.Decimal {
+	if m.Limits.TickSize.IsPositive() {
+		return m.Limits.TickSize
+	}
+	return m.PriceStep()
+}
+
+// StepSize returns the amount increment of the market, the step_size limit when the exchange
+// sets one and one unit of the stock precision otherwise
+func (m MarketInfo) StepSize() decimal.Decimal {
+	if m.Limits.StepSize.IsPositive() {
+		return m.Limits.StepSize
+	}
+	return m.AmountStep()
+}
+
+// RoundToTick snaps price to a multiple of the tick size, rounding up or down.
+// Orders priced off tick are rejected by the exchange.
+func (m MarketInfo) RoundToTick(price decimal.Decimal, up bool) decimal.Decimal {
+	return roundToMultiple(price, m.TickSize(), up)
+}
+
+// roundToMultiple snaps v to a multiple of step, rounding up or down
+func roundToMultiple(v, step decimal.Decimal, up bool) decimal.Decimal {
+	q, r := v.QuoRem(step, 0)
+	switch {
+	case up && r.IsPositive():
+		q = q.Add(decimal.NewFromInt(1))
+	case !up && r.IsNegative():
+		q = q.Sub(decimal.NewFromInt(1))
+	}
+	return q.Mul(step)
+}
+
+// QuantizePrice rounds p down to the tick size of the market
+func QuantizePrice(info MarketInfo, p decimal.Decimal) decimal.Decimal {
+	return info.RoundToTick(p, false)
+}
+
+// RoundUpPrice rounds p up to the tick size of the market, for quotes on the passive sell side
+func RoundUpPrice(info MarketInfo, p decimal.Decimal) decimal.Decimal {
+	return info.RoundToTick(p, true)
+}
+
+// QuantizeAmount rounds a down to the step size of the market, so it passes the step check of Validate
+func QuantizeAmount(info MarketInfo, a decimal.Decimal) decimal.Decimal {
+	return roundToMultiple(a, info.StepSize(), false)
+}
+
+// RoundUpAmount rounds a up to the step size of the market
+func RoundUpAmount(info MarketInfo, a decimal.Decimal) decimal.Decimal {
+	return roundToMultiple(a, info.StepSize(), true)
+}
+
+// Quantize rounds the price and amount of the order down to the tick and step sizes of the market
+func (r *NewOrderRequest) Quantize(info MarketInfo) {
+	r.Price = QuantizePrice(info, r.Price)
+	r.Amount = QuantizeAmount(info, r.Amount)
+}
@@ -0,0 +1,107 @@
+package gop2b_test
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/sutapurachina/gop2b"
+)
+
+func TestQuantizeAmount(t *testing.T) {
+	// the step size is coarser than the stock precision, as on markets with lot sizes
+	info := gop2b.MarketInfo{
+		Precision: gop2b.MarketPrecision{Money: 2, Stock: 4},
+		Limits: gop2b.MarketLimits{
+			StepSize: decimal.RequireFromString("0.05"),
+			TickSize: decimal.RequireFromString("0.01"),
+		},
+	}
+	noStep := gop2b.MarketInfo{Precision: gop2b.MarketPrecision{Money: 2, Stock: 4}}
+	tests := []struct {
+		name     string
+		info     gop2b.MarketInfo
+		amount   string
+		down, up string
+	}{
+		{"step", info, "1.2345", "1.2", "1.25"},
+		{"on step", info, "1.25", "1.25", "1.25"},
+		{"below step", info, "0.01", "0", "0.05"},
+		{"precision", noStep, "1.23456", "1.2345", "1.2346"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			amount := decimal.RequireFromString(tt.amount)
+			down := gop2b.QuantizeAmount(tt.info, amount)
+			checkDecimal(t, "quantized", down, tt.down)
+			checkDecimal(t, "rounded up", gop2b.RoundUpAmount(tt.info, amount), tt.up)
+			if down.IsPositive() {
+				if err := tt.info.Validate(decimal.Zero, down); err != nil {
+					t.Errorf("quantized amount fails validation: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestRoundToTick(t *testing.T) {
+	tick := gop2b.MarketInfo{
+		Precision: gop2b.MarketPrecision{Money: 6},
+		Limits:    gop2b.MarketLimits{TickSize: decimal.RequireFromString("0.0005")},
+	}
+	precision := gop2b.MarketInfo{Precision: gop2b.MarketPrecision{Money: 2}}
+	// precision 0 is an integer-priced market, ticking by 1
+	integer := gop2b.MarketInfo{Precision: gop2b.MarketPrecision{Money: 0}}
+	tests := []struct {
+		name     string
+		info     gop2b.MarketInfo
+		price    string
+		down, up string
+	}{
+		{"tick size", tick, "0.05512", "0.055", "0.0555"},
+		{"on tick", tick, "0.0555", "0.0555", "0.0555"},
+		{"just above tick", tick, "0.05500001", "0.055", "0.0555"},
+		{"just below tick", tick, "0.05549999", "0.055", "0.0555"},
+		{"below one tick", tick, "0.0001", "0", "0.0005"},
+		{"precision", precision, "37000.129", "37000.12", "37000.13"},
+		{"precision on tick", precision, "37000.1", "37000.1", "37000.1"},
+		{"precision 0", integer, "37000.5", "37000", "37001"},
+		{"precision 0 fraction", integer, "0.999", "0", "1"},
+		{"precision 0 on tick", integer, "37000", "37000", "37000"},
+		{"zero", tick, "0", "0", "0"},
+		{"negative", precision, "-1.234", "-1.24", "-1.23"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			price := decimal.RequireFromString(tt.price)
+			down := tt.info.RoundToTick(price, false)
+			up := tt.info.RoundToTick(price, true)
+			checkDecimal(t, "rounded down", down, tt.down)
+			checkDecimal(t, "rounded up", up, tt.up)
+			checkDecimal(t, "quantized", gop2b.QuantizePrice(tt.info, price), tt.down)
+			checkDecimal(t, "rounded up price", gop2b.RoundUpPrice(tt.info, price), tt.up)
+			if down.GreaterThan(price) || up.LessThan(price) {
+				t.Errorf("%s rounded to %s and %s, on the wrong side", price, down, up)
+			}
+		})
+	}
+}
+
+func TestNewOrderRequestQuantize(t *testing.T) {
+	info := gop2b.MarketInfo{
+		Precision: gop2b.MarketPrecision{Money: 6, Stock: 3},
+		Limits: gop2b.MarketLimits{
+			StepSize: decimal.RequireFromString("0.01"),
+			TickSize: decimal.RequireFromString("0.000005"),
+		},
+	}
+	request := gop2b.NewOrderRequest{
+		Price:  decimal.RequireFromString("0.0551234"),
+		Amount: decimal.RequireFromString("1.239"),
+	}
+	request.Quantize(info)
+	checkDecimal(t, "price", request.Price, "0.055120")
+	checkDecimal(t, "amount", request.Amount, "1.23")
+	if err := info.Validate(request.Price, request.Amount); err != nil {
+		t.Error(err)
+	}
+}
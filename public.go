@@ -0,0 +1,614 @@
+package gop2b
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// PriceLevel is a single aggregated order book level
+type PriceLevel struct {
+	Price  decimal.Decimal
+	Amount decimal.Decimal
+}
+
+// UnmarshalJSON decodes a level sent as a [price, amount] pair
+func (l *PriceLevel) UnmarshalJSON(data []byte) error {
+	var pair []decimal.Decimal
+	if err := json.Unmarshal(data, &pair); err != nil {
+		return err
+	}
+	if len(pair) != 2 {
+		return fmt.Errorf("price level: expected [price, amount], got %d values", len(pair))
+	}
+	l.Price, l.Amount = pair[0], pair[1]
+	return nil
+}
+
+// MarshalJSON encodes the level as a [price, amount] pair
+func (l PriceLevel) MarshalJSON() ([]byte, error) {
+	return json.Marshal([2]decimal.Decimal{l.Price, l.Amount})
+}
+
+// DepthSnapshot is an order book, asks ascending and bids descending by price
+type DepthSnapshot struct {
+	Market Market `json:"-"`
+	// At is the server time of the response, or the time it was received when missing
+	At   time.Time    `json:"-"`
+	Asks []PriceLevel `json:"asks"`
+	Bids []PriceLevel `json:"bids"`
+}
+
+// DepthSide selects a side of a DepthSnapshot
+type DepthSide string
+
+const (
+	DepthAsks DepthSide = "asks"
+	DepthBids DepthSide = "bids"
+)
+
+// Levels returns the levels of side, nil for an unknown side
+func (s DepthSnapshot) Levels(side DepthSide) []PriceLevel {
+	switch side {
+	case DepthAsks:
+		return s.Asks
+	case DepthBids:
+		return s.Bids
+	}
+	return nil
+}
+
+// TotalVolume sums the amounts of the levels of side priced within withinPct percent of its
+// best price, above it for asks and below it for bids. A zero withinPct sums the best level only.
+func (s DepthSnapshot) TotalVolume(side DepthSide, withinPct decimal.Decimal) decimal.Decimal {
+	levels := s.Levels(side)
+	if len(levels) == 0 {
+		return decimal.Zero
+	}
+	offset := levels[0].Price.Mul(withinPct).Div(decimal.NewFromInt(100))
+	limit := levels[0].Price.Add(offset)
+	if side == DepthBids {
+		limit = levels[0].Price.Sub(offset)
+	}
+	total := decimal.Zero
+	for _, l := range levels {
+		if (side == DepthAsks && l.Price.GreaterThan(limit)) || (side == DepthBids && l.Price.LessThan(limit)) {
+			break
+		}
+		total = total.Add(l.Amount)
+	}
+	return total
+}
+
+// PriceForVolume returns the price of the level of side at which the cumulative amount from
+// the best price reaches volume, the worst price paid taking volume from the book. When the
+// side is too thin it returns the last price together with an *InsufficientDepthError.
+func (s DepthSnapshot) PriceForVolume(side DepthSide, volume decimal.Decimal) (decimal.Decimal, error) {
+	if !volume.IsPositive() {
+		return decimal.Zero, errors.New("volume must be positive")
+	}
+	if side != DepthAsks && side != DepthBids {
+		return decimal.Zero, fmt.Errorf("invalid depth side %q", side)
+	}
+	total, price := decimal.Zero, decimal.Zero
+	for _, l := range s.Levels(side) {
+		if !l.Amount.IsPositive() {
+			continue
+		}
+		total = total.Add(l.Amount)
+		price = l.Price
+		if total.GreaterThanOrEqual(volume) {
+			return price, nil
+		}
+	}
+	return price, &InsufficientDepthError{Requested: volume, Available: total}
+}
+
+// AggregateBy merges the levels into price buckets of size tick, summing their amounts.
+// Bids are rounded down and asks up to a multiple of tick, so no bucket crosses the spread.
+// Levels must be sorted as in a DepthSnapshot. A non-positive tick returns s unchanged.
+func (s DepthSnapshot) AggregateBy(tick decimal.Decimal) DepthSnapshot {
+	if tick.Sign() <= 0 {
+		return s
+	}
+	s.Asks = aggregateLevels(s.Asks, tick, true)
+	s.Bids = aggregateLevels(s.Bids, tick, false)
+	return s
+}
+
+// aggregateLevels buckets sorted levels in a single pass, rounding prices up or down to a multiple of tick
+func aggregateLevels(levels []PriceLevel, tick decimal.Decimal, up bool) []PriceLevel {
+	if len(levels) == 0 {
+		return nil
+	}
+	result := make([]PriceLevel, 0, len(levels))
+	for _, l := range levels {
+		q, r := l.Price.QuoRem(tick, 0)
+		if up && r.Sign() > 0 {
+			q = q.Add(decimal.NewFromInt(1))
+		}
+		bucket := q.Mul(tick)
+		if n := len(result); n > 0 && result[n-1].Price.Equal(bucket) {
+			result[n-1].Amount = result[n-1].Amount.Add(l.Amount)
+			continue
+		}
+		result = append(result, PriceLevel{Price: bucket, Amount: l.Amount})
+	}
+	return result
+}
+
+type DepthResp = Envelope[DepthSnapshot]
+
+// GetDepth returns the aggregated order book of market.
+// limit and interval are optional and left to the server defaults when zero/empty.
+func (c *client) GetDepth(ctx context.Context, market string, limit int, interval string) (*DepthResp, error) {
+	market, err := c.ResolveMarket(ctx, market)
+	if err != nil {
+		return nil, err
+	}
+	params := url.Values{}
+	params.Set("market", market)
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+	if interval != "" {
+		params.Set("interval", interval)
+	}
+	var result DepthResp
+	if err := c.getPublic(ctx, "/public/depth/result", params, &result); err != nil {
+		return nil, err
+	}
+	result.Result.Market = Market(market)
+	result.Result.At = time.Now()
+	if result.CurrentTime > 0 {
+		result.Result.At = TimestampToTime(result.CurrentTime)
+	}
+	return &result, nil
+}
+
+// bestQuotesDrain bounds the rest of a best quotes response read to reuse the connection
+const bestQuotesDrain = 4 << 10
+
+// GetBestQuotes returns the best bid and ask price of market, zero for an empty side.
+// It asks for a single level per side and decodes the response as a stream, stopping at
+// the first level of each side instead of decoding the whole response. Responses are
+// never cached.
+func (c *client) GetBestQuotes(ctx context.Context, market string) (bid, ask decimal.Decimal, err error) {
+	market, err = c.ResolveMarket(ctx, market)
+	if err != nil {
+		return decimal.Zero, decimal.Zero, err
+	}
+	const path = "/public/depth/result"
+	u := c.url + path + "?" + url.Values{"market": {market}, "limit": {"1"}}.Encode()
+	spanCtx, span := c.tracing.start(ctx, path)
+	defer func() { c.tracing.end(spanCtx, span, err) }()
+	ctx, done := c.requestContext(spanCtx)
+	defer done()
+	err = c.withRetry(ctx, path, func(ctx context.Context) error {
+		var err error
+		bid, ask, err = c.getBestQuotesOnce(ctx, path, u)
+		return err
+	})
+	return bid, ask, err
+}
+
+func (c *client) getBestQuotesOnce(ctx context.Context, path string, u string) (bid, ask decimal.Decimal, err error) {
+	resp, err := c.sendGet(ctx, u, nil)
+	if err != nil {
+		return bid, ask, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, err := readBody(resp)
+		if err != nil {
+			return bid, ask, err
+		}
+		return bid, ask, c.checkResponse(resp, body)
+	}
+	defer func() {
+		_, _ = io.CopyN(io.Discard, resp.Body, bestQuotesDrain)
+		resp.Body.Close()
+	}()
+	bid, ask, err = decodeBestQuotes(io.LimitReader(resp.Body, maxResponseSize))
+	var failure *APIError
+	if err != nil && !errors.As(err, &failure) {
+		c.stats.decodeError(path)
+	}
+	return bid, ask, err
+}
+
+// decodeBestQuotes reads a depth response up to the first level of both sides
+func decodeBestQuotes(r io.Reader) (bid, ask decimal.Decimal, err error) {
+	dec := json.NewDecoder(r)
+	success, message, code := true, "", ErrorCode(0)
+	if err := expectDelim(dec, '{'); err != nil {
+		return bid, ask, err
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return bid, ask, err
+		}
+		switch key {
+		case "success":
+			err = dec.Decode(&success)
+		case "message":
+			err = dec.Decode(&message)
+		case "errorCode":
+			err = dec.Decode(&code)
+		case "result":
+			if !success {
+				return bid, ask, &APIError{Code: code, Message: message}
+			}
+			return decodeBestLevels(dec)
+		default:
+			var skip json.RawMessage
+			err = dec.Decode(&skip)
+		}
+		if err != nil {
+			return bid, ask, err
+		}
+	}
+	if !success {
+		return bid, ask, &APIError{Code: code, Message: message}
+	}
+	return bid, ask, errors.New("depth response without result")
+}
+
+// decodeBestLevels reads a depth result object until both sides were seen
+func decodeBestLevels(dec *json.Decoder) (bid, ask decimal.Decimal, err error) {
+	if err := expectDelim(dec, '{'); err != nil {
+		return bid, ask, err
+	}
+	var asks, bids bool
+	for dec.More() && !(asks && bids) {
+		side, err := dec.Token()
+		if err != nil {
+			return bid, ask, err
+		}
+		switch side {
+		case "asks":
+			ask, err = firstLevelPrice(dec)
+			asks = true
+		case "bids":
+			bid, err = firstLevelPrice(dec)
+			bids = true
+		default:
+			var skip json.RawMessage
+			err = dec.Decode(&skip)
+		}
+		if err != nil {
+			return bid, ask, err
+		}
+	}
+	if !asks || !bids {
+		return bid, ask, errors.New("depth response without asks or bids")
+	}
+	return bid, ask, nil
+}
+
+// firstLevelPrice decodes the price of the first level of a side, zero when it is empty,
+// and skips the other levels
+func firstLevelPrice(dec *json.Decoder) (price decimal.Decimal, err error) {
+	if err := expectDelim(dec, '['); err != nil {
+		return price, err
+	}
+	if dec.More() {
+		var level PriceLevel
+		if err := dec.Decode(&level); err != nil {
+			return price, err
+		}
+		price = level.Price
+	}
+	for dec.More() {
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return price, err
+		}
+	}
+	return price, expectDelim(dec, ']')
+}
+
+// expectDelim reads the next token, failing unless it is delim
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected %v, got %v", delim, token)
+	}
+	return nil
+}
+
+type MarketPrecision struct {
+	Money int `json:"money"`
+	Stock int `json:"stock"`
+	Fee   int `json:"fee"`
+}
+
+// UnmarshalJSON accepts the precisions as strings or numbers, both are sent depending on the API version
+func (p *MarketPrecision) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Money flexNumber `json:"money"`
+		Stock flexNumber `json:"stock"`
+		Fee   flexNumber `json:"fee"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	var precisions [3]int64
+	for i, n := range []flexNumber{v.Money, v.Stock, v.Fee} {
+		var err error
+		if precisions[i], err = n.Int64(); err != nil {
+			return fmt.Errorf("market precision: %v", err)
+		}
+	}
+	*p = MarketPrecision{Money: int(precisions[0]), Stock: int(precisions[1]), Fee: int(precisions[2])}
+	return nil
+}
+
+type MarketLimits struct {
+	MinAmount decimal.Decimal `json:"min_amount"`
+	MaxAmount decimal.Decimal `json:"max_amount"`
+	StepSize  decimal.Decimal `json:"step_size"`
+	MinPrice  decimal.Decimal `json:"min_price"`
+	MaxPrice  decimal.Decimal `json:"max_price"`
+	TickSize  decimal.Decimal `json:"tick_size"`
+	MinTotal  decimal.Decimal `json:"min_total"`
+}
+
+// MarketInfo describes a listed market, Stock being the base and Money the quote currency
+type MarketInfo struct {
+	Name      Market          `json:"name"`
+	Stock     Currency        `json:"stock"`
+	Money     Currency        `json:"money"`
+	Precision MarketPrecision `json:"precision"`
+	Limits    MarketLimits    `json:"limits"`
+}
+
+type MarketsResp = Envelope[[]MarketInfo]
+
+// Ticker is the 24h ticker of a market, as returned by GetTicker, GetTickers and the state
+// channel of the websocket, which has no bid and ask
+type Ticker struct {
+	Bid    decimal.Decimal `json:"bid"`
+	Ask    decimal.Decimal `json:"ask"`
+	Open   decimal.Decimal `json:"open"`
+	Low    decimal.Decimal `json:"low"`
+	High   decimal.Decimal `json:"high"`
+	Last   decimal.Decimal `json:"last"`
+	Volume decimal.Decimal `json:"vol"`
+	Deal   decimal.Decimal `json:"deal"`
+	// At is the server time of the ticker, or the time it was received from the websocket
+	At time.Time `json:"-"`
+}
+
+// Spread returns ask - bid, zero when either is missing
+func (t Ticker) Spread() decimal.Decimal {
+	if !t.Bid.IsPositive() || !t.Ask.IsPositive() {
+		return decimal.Zero
+	}
+	return t.Ask.Sub(t.Bid)
+}
+
+// SpreadBps returns the spread in basis points of the mid price, zero when bid or ask is missing
+func (t Ticker) SpreadBps() decimal.Decimal {
+	spread := t.Spread()
+	if spread.IsZero() {
+		return decimal.Zero
+	}
+	mid := t.Ask.Add(t.Bid).Div(decimal.NewFromInt(2))
+	return spread.Div(mid).Mul(decimal.NewFromInt(10000))
+}
+
+// Change returns the change of the last price from the open in percent, zero without an open price
+func (t Ticker) Change() decimal.Decimal {
+	if t.Open.IsZero() {
+		return decimal.Zero
+	}
+	return t.Last.Sub(t.Open).Div(t.Open).Mul(decimal.NewFromInt(100))
+}
+
+// UnmarshalJSON accepts the volume as "vol" (all tickers) or "volume" (single ticker)
+func (t *Ticker) UnmarshalJSON(data []byte) error {
+	type plain Ticker
+	var v struct {
+		plain
+		Volume *decimal.Decimal `json:"volume"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*t = Ticker(v.plain)
+	if v.Volume != nil {
+		t.Volume = *v.Volume
+	}
+	return nil
+}
+
+type TickerResp = Envelope[Ticker]
+
+type TickerEntry struct {
+	At     ExchangeTimeSeconds `json:"at"`
+	Ticker Ticker              `json:"ticker"`
+}
+
+type TickersResp = Envelope[map[string]TickerEntry]
+
+// GetMarkets returns all markets listed on the exchange.
+// The API doesn't tell which of them the account may trade.
+func (c *client) GetMarkets(ctx context.Context) (*MarketsResp, error) {
+	var result MarketsResp
+	if err := c.getPublic(ctx, "/public/markets", nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetTickers returns the 24h tickers of all markets keyed by market name
+func (c *client) GetTickers(ctx context.Context) (*TickersResp, error) {
+	var result TickersResp
+	if err := c.getPublic(ctx, "/public/tickers", nil, &result); err != nil {
+		return nil, err
+	}
+	for market, entry := range result.Result {
+		entry.Ticker.At = entry.At.Time
+		result.Result[market] = entry
+	}
+	return &result, nil
+}
+
+// GetTicker returns the 24h ticker of market
+func (c *client) GetTicker(ctx context.Context, market string) (*TickerResp, error) {
+	market, err := c.ResolveMarket(ctx, market)
+	if err != nil {
+		return nil, err
+	}
+	var result TickerResp
+	if err := c.getPublic(ctx, "/public/ticker", url.Values{"market": {market}}, &result); err != nil {
+		return nil, err
+	}
+	if result.CurrentTime > 0 {
+		result.Result.At = TimestampToTime(result.CurrentTime)
+	}
+	return &result, nil
+}
+
+// GetKlines returns up to limit candles of market, offset counting candles back from the most recent one.
+// Candles whose period hasn't ended yet have Closed false.
+func (c *client) GetKlines(ctx context.Context, market string, interval KlineInterval, offset int, limit int) (*KlinesResp, error) {
+	market, err := c.ResolveMarket(ctx, market)
+	if err != nil {
+		return nil, err
+	}
+	params := url.Values{}
+	params.Set("market", market)
+	params.Set("interval", string(interval))
+	params.Set("offset", strconv.Itoa(offset))
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+	var result KlinesResp
+	if err := c.getPublic(ctx, "/public/market/kline", params, &result); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for i := range result.Result {
+		result.Result[i].setInterval(interval, now)
+	}
+	return &result, nil
+}
+
+// Trade is a public trade of a market
+type Trade struct {
+	ID     int64           `json:"id"`
+	Time   float64         `json:"time"`
+	Price  decimal.Decimal `json:"price"`
+	Amount decimal.Decimal `json:"amount"`
+	// Type is the taker side, buy or sell
+	Type Side `json:"type"`
+}
+
+type HistoryResp Envelope[[]Trade]
+
+// SortOrder orders trades by id
+type SortOrder int
+
+const (
+	OldestFirst SortOrder = iota
+	NewestFirst
+)
+
+// Filter returns the trades taken on side, all of them when side is empty, sorted in order.
+// The history endpoint has no side or order parameter, so this is done client side on the
+// trades already fetched: it doesn't reduce what GetHistory requests or transfers.
+func (r *HistoryResp) Filter(side Side, order SortOrder) ([]Trade, error) {
+	return FilterTrades(r.Result, side, order)
+}
+
+// FilterTrades returns the trades taken on side, all of them when side is empty, sorted in order
+func FilterTrades(trades []Trade, side Side, order SortOrder) ([]Trade, error) {
+	if side != "" {
+		parsed, err := ParseSide(string(side))
+		if err != nil {
+			return nil, err
+		}
+		side = parsed
+	}
+	result := make([]Trade, 0, len(trades))
+	for _, t := range trades {
+		if side == "" || t.Type == side {
+			result = append(result, t)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if order == NewestFirst {
+			return result[i].ID > result[j].ID
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result, nil
+}
+
+// GetHistory returns up to limit public trades of market with an id greater than lastID
+func (c *client) GetHistory(ctx context.Context, market string, lastID int64, limit int) (*HistoryResp, error) {
+	market, err := c.ResolveMarket(ctx, market)
+	if err != nil {
+		return nil, err
+	}
+	params := url.Values{}
+	params.Set("market", market)
+	params.Set("lastId", strconv.FormatInt(lastID, 10))
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+	var result HistoryResp
+	if err := c.getPublic(ctx, "/public/history", params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// HistorySince collects the public trades of market with an id greater than lastID,
+// paging by id from the last trade of each page until a short page or until max
+// trades, when positive, are collected. Trades are sorted by id. A page repeating or
+// going back before the cursor stops paging with ErrPaginationInconsistent and the trades collected so far.
+func (c *client) HistorySince(ctx context.Context, market string, lastID int64, max int) ([]Trade, error) {
+	var trades []Trade
+	seen := make(map[int64]bool)
+	for {
+		limit := tradeBackfillLimit
+		if max > 0 && max-len(trades) < limit {
+			limit = max - len(trades)
+		}
+		page, err := c.GetHistory(ctx, market, lastID, limit)
+		if err != nil {
+			return trades, err
+		}
+		if !page.Success {
+			return trades, page.Err()
+		}
+		result := append([]Trade(nil), page.Result...)
+		sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+		for _, t := range result {
+			if t.ID <= lastID || seen[t.ID] {
+				return trades, fmt.Errorf("%w: trade %d repeated after cursor %d", ErrPaginationInconsistent, t.ID, lastID)
+			}
+			seen[t.ID] = true
+			trades = append(trades, t)
+		}
+		if len(result) < limit || max > 0 && len(trades) >= max {
+			return trades, nil
+		}
+		lastID = result[len(result)-1].ID
+	}
+}
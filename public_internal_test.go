@@ -0,0 +1,70 @@
+package gop2b
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+// largeDepthBody returns a depth response with levels price levels on each side
+func largeDepthBody(levels int) []byte {
+	var b bytes.Buffer
+	b.WriteString(`{"success":true,"message":"","result":{"asks":[`)
+	for i := 0; i < levels; i++ {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, `["%d.%06d","%d.125"]`, 37000+i/100, i%100*10000, i%50+1)
+	}
+	b.WriteString(`],"bids":[`)
+	for i := 0; i < levels; i++ {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, `["%d.%06d","%d.5"]`, 36999-i/100, i%100*10000, i%50+1)
+	}
+	b.WriteString(`]},"cache_time":1700000000.1,"current_time":1700000000.2}`)
+	return b.Bytes()
+}
+
+func TestDecodeBestQuotesMatchesFullDecode(t *testing.T) {
+	body := largeDepthBody(1000)
+	bid, ask, err := decodeBestQuotes(bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var full DepthResp
+	if err := json.Unmarshal(body, &full); err != nil {
+		t.Fatal(err)
+	}
+	if !bid.Equal(full.Result.Bids[0].Price) || !ask.Equal(full.Result.Asks[0].Price) {
+		t.Errorf("best quotes %s/%s, full decode %s/%s", bid, ask, full.Result.Bids[0].Price, full.Result.Asks[0].Price)
+	}
+}
+
+// BenchmarkBestQuotes compares reading the best quotes off a 1000 level book as a stream
+// with decoding the whole book
+func BenchmarkBestQuotes(b *testing.B) {
+	body := largeDepthBody(1000)
+	b.Run("stream", func(b *testing.B) {
+		b.SetBytes(int64(len(body)))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, _, err := decodeBestQuotes(bytes.NewReader(body)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("full", func(b *testing.B) {
+		b.SetBytes(int64(len(body)))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var resp DepthResp
+			if err := json.Unmarshal(body, &resp); err != nil {
+				b.Fatal(err)
+			}
+			_, _ = resp.Result.Bids[0].Price, resp.Result.Asks[0].Price
+		}
+	})
+}
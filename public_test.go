@@ -0,0 +1,199 @@
+package gop2b_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sutapurachina/gop2b"
+)
+
+func TestGetDepth(t *testing.T) {
+	client, server := newTestClient(t)
+	resp, err := client.GetDepth(context.Background(), "ETH_BTC", 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if base, err := resp.Result.Market.Base(); resp.Result.Market != "ETH_BTC" || base != "ETH" || err != nil {
+		t.Errorf("market %q, base %q, %v", resp.Result.Market, base, err)
+	}
+	// current_time is a float, exact to a microsecond
+	if want := time.Unix(1700000000, 200000000); resp.Result.At.Sub(want).Abs() > time.Microsecond {
+		t.Errorf("at %s, want the server time %s", resp.Result.At, want)
+	}
+
+	// without current_time the snapshot is timestamped when received rather than in 1970
+	server.SetResponse("/public/depth/result", `{"success":true,"message":"","result":{"asks":[],"bids":[]}}`)
+	before := time.Now()
+	resp, err = client.GetDepth(context.Background(), "ETH_BTC", 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if at := resp.Result.At; at.Before(before) || at.After(time.Now()) {
+		t.Errorf("at %s without current_time, want the receive time", at)
+	}
+}
+
+func TestFilterTrades(t *testing.T) {
+	trades := []gop2b.Trade{
+		{ID: 3, Type: gop2b.SideSell},
+		{ID: 1, Type: gop2b.SideBuy},
+		{ID: 4, Type: gop2b.SideBuy},
+		{ID: 2, Type: gop2b.SideSell},
+	}
+	tests := []struct {
+		name  string
+		side  gop2b.Side
+		order gop2b.SortOrder
+		want  []int64
+	}{
+		{"all oldest first", "", gop2b.OldestFirst, []int64{1, 2, 3, 4}},
+		{"all newest first", "", gop2b.NewestFirst, []int64{4, 3, 2, 1}},
+		{"buys", gop2b.SideBuy, gop2b.OldestFirst, []int64{1, 4}},
+		{"sells newest first", gop2b.SideSell, gop2b.NewestFirst, []int64{3, 2}},
+		{"upper case side", "SELL", gop2b.OldestFirst, []int64{2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := gop2b.FilterTrades(trades, tt.side, tt.order)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids := []int64{}
+			for _, trade := range got {
+				ids = append(ids, trade.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("trades %v, want %v", ids, tt.want)
+			}
+		})
+	}
+	if trades[0].ID != 3 {
+		t.Errorf("input reordered to %+v", trades)
+	}
+	if _, err := gop2b.FilterTrades(trades, "hold", gop2b.OldestFirst); err == nil {
+		t.Error("invalid side accepted")
+	}
+}
+
+func TestHistoryFilter(t *testing.T) {
+	client, server := newTestClient(t)
+	resp, err := client.GetHistory(context.Background(), "ETH_BTC", 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sells, err := resp.Filter(gop2b.SideSell, gop2b.NewestFirst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sells) != 1 || sells[0].ID != 1002 {
+		t.Errorf("sells %+v, want trade 1002", sells)
+	}
+	if len(resp.Result) != 2 {
+		t.Errorf("filtering changed the response to %d trades", len(resp.Result))
+	}
+	// filtering happens on the fetched page, nothing is requested again
+	if n := server.Requests("/public/history"); n != 1 {
+		t.Errorf("%d requests, want 1", n)
+	}
+}
+
+// historyServer serves public trades with an id greater than lastId, newest first like the exchange
+type historyServer struct {
+	mu  sync.Mutex
+	ids []int64
+	// onRequest runs after every request, to change the history between pages
+	onRequest func(s *historyServer)
+}
+
+func (s *historyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	lastID, _ := strconv.ParseInt(r.URL.Query().Get("lastId"), 10, 64)
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	var page []map[string]interface{}
+	for _, id := range s.ids {
+		if id > lastID && len(page) < limit {
+			page = append([]map[string]interface{}{{"id": id, "time": 1700000000, "price": "0.055", "amount": "1", "type": "buy"}}, page...)
+		}
+	}
+	if s.onRequest != nil {
+		s.onRequest(s)
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "message": "", "result": page})
+}
+
+func newHistoryServer(t *testing.T, s *historyServer) gop2b.Client {
+	t.Helper()
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+	client, err := gop2b.NewClient("", "", gop2b.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestHistorySinceShiftingHistory(t *testing.T) {
+	s := &historyServer{}
+	// ids of the exchange aren't contiguous
+	for id := int64(10); id <= 2500; id += 10 {
+		s.ids = append(s.ids, id)
+	}
+	// trades keep coming in while paging
+	s.onRequest = func(s *historyServer) {
+		last := s.ids[len(s.ids)-1]
+		for i := int64(1); i <= 7; i++ {
+			s.ids = append(s.ids, last+i*3)
+		}
+	}
+	client := newHistoryServer(t, s)
+	trades, err := client.HistorySince(context.Background(), "ETH_BTC", 995, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var want []int64
+	for _, id := range s.ids {
+		if id > 995 && id <= trades[len(trades)-1].ID {
+			want = append(want, id)
+		}
+	}
+	var got []int64
+	for _, trade := range trades {
+		got = append(got, trade.ID)
+	}
+	// every trade after the cursor once, in order, up to the short page that ended paging
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%d trades %v..., want %d", len(got), got[:5], len(want))
+	}
+	if len(got) < 150 {
+		t.Errorf("%d trades, want more than one page", len(got))
+	}
+}
+
+func TestHistorySinceRepeatedTrade(t *testing.T) {
+	s := &historyServer{}
+	for id := int64(1); id <= 250; id++ {
+		s.ids = append(s.ids, id)
+	}
+	// after the first page trade 150 is served twice
+	s.onRequest = func(s *historyServer) { s.ids = append([]int64{150}, s.ids...) }
+	client := newHistoryServer(t, s)
+	trades, err := client.HistorySince(context.Background(), "ETH_BTC", 0, 0)
+	if !errors.Is(err, gop2b.ErrPaginationInconsistent) {
+		t.Fatalf("error %v, want ErrPaginationInconsistent", err)
+	}
+	// the trades up to the first copy are kept
+	if len(trades) != 150 || trades[149].ID != 150 {
+		t.Errorf("%d trades collected before the inconsistency, want 150", len(trades))
+	}
+}
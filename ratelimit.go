@@ -0,0 +1,63 @@
+package gop2b
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// rateLimiter spaces outgoing requests evenly so that no more than the
+// configured amount is sent per period. A nil limiter never blocks.
+type rateLimiter struct {
+	mu       sync.Mutex
+	requests int
+	interval time.Duration
+	next     time.Time
+}
+
+func newRateLimiter(requests int, per time.Duration) *rateLimiter {
+	if requests <= 0 || per <= 0 {
+		return nil
+	}
+	return &rateLimiter{requests: requests, interval: per / time.Duration(requests)}
+}
+
+// remaining returns the slots of the coming period not yet taken by waiting or sent requests
+func (l *rateLimiter) remaining() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	ahead := time.Until(l.next)
+	if ahead <= 0 || l.interval <= 0 {
+		return l.requests
+	}
+	taken := int((ahead + l.interval - 1) / l.interval)
+	return max(l.requests-taken, 0)
+}
+
+// wait blocks until the next request slot is available or ctx is done
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if l == nil {
+		return ctx.Err()
+	}
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	slot := l.next
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	delay := time.Until(slot)
+	if delay <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
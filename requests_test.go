@@ -0,0 +1,113 @@
+package gop2b_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/sutapurachina/gop2b"
+)
+
+// setFlags sets every bool field of the struct v points to, embedded structs included, so
+// that client-side options tagged json:"-" show up if they leak into the request body
+func setFlags(v reflect.Value) {
+	v = v.Elem()
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		switch {
+		case !field.CanSet():
+		case field.Kind() == reflect.Bool:
+			field.SetBool(true)
+		case field.Kind() == reflect.Struct && v.Type().Field(i).Anonymous:
+			setFlags(field.Addr())
+		}
+	}
+}
+
+// TestRequestJSONKeys checks every signed request sends the parameter names of the API docs
+func TestRequestJSONKeys(t *testing.T) {
+	tests := []struct {
+		request interface{}
+		keys    []string
+	}{
+		{&gop2b.AccountBalancesRequest{}, []string{"request", "nonce"}},
+		{&gop2b.AccountCurrencyBalanceRequest{Currency: "BTC"}, []string{"request", "nonce", "currency"}},
+		{
+			&gop2b.NewOrderRequest{Market: "ETH_BTC", Side: "buy", Amount: decimal.NewFromInt(1), Price: decimal.NewFromInt(1)},
+			[]string{"request", "nonce", "market", "side", "amount", "price"},
+		},
+		{&gop2b.OpenOrdersRequest{Market: "ETH_BTC", Limit: 10}, []string{"request", "nonce", "market", "offset", "limit"}},
+		{
+			&gop2b.OrderHistoryRequest{StartTime: 1, EndTime: 2, Limit: 10},
+			[]string{"request", "nonce", "startTime", "endTime", "offset", "limit"},
+		},
+	}
+	for _, tt := range tests {
+		setFlags(reflect.ValueOf(tt.request))
+		data, err := json.Marshal(tt.request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(data, &fields); err != nil {
+			t.Fatal(err)
+		}
+		var keys []string
+		for key := range fields {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		want := append([]string(nil), tt.keys...)
+		sort.Strings(want)
+		if strings.Join(keys, ",") != strings.Join(want, ",") {
+			t.Errorf("%T marshalled to %s, want the keys %v", tt.request, data, tt.keys)
+		}
+	}
+}
+
+// TestPublicQueryKeys checks the public endpoints get the parameter names of the API docs
+func TestPublicQueryKeys(t *testing.T) {
+	var mu sync.Mutex
+	queries := map[string]url.Values{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		queries[r.URL.Path] = r.URL.Query()
+		mu.Unlock()
+		_, _ = io.WriteString(w, `{"success":true,"message":"","result":[]}`)
+	}))
+	defer server.Close()
+	client, err := gop2b.NewClient("", "", gop2b.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	_, _ = client.GetDepth(ctx, "ETH_BTC", 10, "0.01")
+	_, _ = client.GetKlines(ctx, "ETH_BTC", gop2b.Interval1h, 5, 10)
+	_, _ = client.GetHistory(ctx, "ETH_BTC", 42, 10)
+	_, _ = client.GetTicker(ctx, "ETH_BTC")
+	want := map[string]url.Values{
+		"/public/depth/result": {"market": {"ETH_BTC"}, "limit": {"10"}, "interval": {"0.01"}},
+		"/public/market/kline": {"market": {"ETH_BTC"}, "interval": {"1h"}, "offset": {"5"}, "limit": {"10"}},
+		"/public/history":      {"market": {"ETH_BTC"}, "lastId": {"42"}, "limit": {"10"}},
+		"/public/ticker":       {"market": {"ETH_BTC"}},
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(queries) != len(want) {
+		t.Errorf("requests to %v, want %d endpoints", queries, len(want))
+	}
+	for path, query := range want {
+		if got, ok := queries[path]; !ok || got.Encode() != query.Encode() {
+			t.Errorf("%s sent %s, want %s", path, got.Encode(), query.Encode())
+		}
+	}
+}
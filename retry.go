@@ -0,0 +1,110 @@
+package gop2b
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// maintenanceRetryDelay is the wait before retrying during maintenance when the server gives no Retry-After
+const maintenanceRetryDelay = time.Minute
+
+// maxRetryBackoff caps the doubled backoff and the Retry-After of a 429 response
+const maxRetryBackoff = time.Minute
+
+type retryPolicy struct {
+	attempts int
+	backoff  time.Duration
+}
+
+// WithRetry retries failed public GET requests up to attempts times in total, waiting backoff
+// doubled after each attempt, up to a minute. Network errors, 429 and 5xx responses are retried,
+// a 429 waits for the server Retry-After instead when it gives one, up to a minute as well.
+// Maintenance waits for the server Retry-After or a minute. Responses that fail to decode,
+// IP bans and signed POST requests are never retried.
+func WithRetry(attempts int, backoff time.Duration) Option {
+	return func(c *client) {
+		c.retry = retryPolicy{attempts: attempts, backoff: backoff}
+	}
+}
+
+// delay returns the wait before the next attempt, false when err is not worth retrying
+func (p retryPolicy) delay(err error, attempt int) (time.Duration, bool) {
+	var maintenance *MaintenanceError
+	var status *StatusError
+	switch {
+	case errors.Is(err, ErrIPBanned):
+		return 0, false
+	case errors.As(err, &maintenance):
+		if maintenance.RetryAfter > 0 {
+			return maintenance.RetryAfter, true
+		}
+		return maintenanceRetryDelay, true
+	case errors.As(err, &status):
+		if status.StatusCode != http.StatusTooManyRequests && status.StatusCode < 500 {
+			return 0, false
+		}
+		if status.StatusCode == http.StatusTooManyRequests && status.RetryAfter > 0 {
+			return min(status.RetryAfter, maxRetryBackoff), true
+		}
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return 0, false
+	case isDecodeError(err):
+		// the same body would come back
+		return 0, false
+	}
+	backoff := p.backoff << (attempt - 1)
+	if backoff <= 0 || backoff > maxRetryBackoff {
+		backoff = maxRetryBackoff
+	}
+	return backoff, true
+}
+
+// withRetry calls fn until it succeeds, fails permanently or the attempts are exhausted.
+// fn gets ctx carrying the attempt number. CallOptions.NoRetry of ctx allows a single attempt.
+func (c *client) withRetry(ctx context.Context, endpoint string, fn func(ctx context.Context) error) error {
+	attempts := c.retry.attempts
+	if callOptionsFromContext(ctx).NoRetry {
+		attempts = 1
+	}
+	for attempt := 1; ; attempt++ {
+		err := fn(context.WithValue(ctx, attemptKey{}, attempt))
+		if err == nil || attempt >= attempts {
+			return contextError(ctx, err)
+		}
+		delay, ok := c.retry.delay(err, attempt)
+		if !ok {
+			return contextError(ctx, err)
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return contextError(ctx, err)
+		case <-timer.C:
+		}
+		c.metrics.IncRetry(endpoint)
+		spanFromContext(ctx).observeRetry()
+	}
+}
+
+// contextError makes err match the error of ctx with errors.Is once ctx is done, whichever
+// layer err comes from, such as an HTTP status of the attempt before a cancelled retry wait
+func contextError(ctx context.Context, err error) error {
+	if err == nil || ctx.Err() == nil || errors.Is(err, ctx.Err()) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ctx.Err(), err)
+}
+
+type attemptKey struct{}
+
+// attemptFromContext returns the attempt number of the request ctx belongs to, 1 outside of withRetry
+func attemptFromContext(ctx context.Context) int {
+	if attempt, ok := ctx.Value(attemptKey{}).(int); ok {
+		return attempt
+	}
+	return 1
+}
@@ -0,0 +1,46 @@
+package gop2b
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := retryPolicy{attempts: 10, backoff: time.Second}
+	var result struct{ Success bool }
+	decodeErr := decodeResponse([]byte(`{"success":`), &result)
+	tests := []struct {
+		name    string
+		err     error
+		attempt int
+		want    time.Duration
+		retry   bool
+	}{
+		{"network", fmt.Errorf("connection reset"), 1, time.Second, true},
+		{"doubled", fmt.Errorf("connection reset"), 3, 4 * time.Second, true},
+		{"backoff capped", fmt.Errorf("connection reset"), 9, maxRetryBackoff, true},
+		{"5xx", &StatusError{StatusCode: http.StatusBadGateway}, 2, 2 * time.Second, true},
+		{"4xx", &StatusError{StatusCode: http.StatusNotFound}, 1, 0, false},
+		{"429 without Retry-After", &StatusError{StatusCode: http.StatusTooManyRequests}, 2, 2 * time.Second, true},
+		{"429 Retry-After", &StatusError{StatusCode: http.StatusTooManyRequests, RetryAfter: 5 * time.Second}, 1, 5 * time.Second, true},
+		{"429 Retry-After capped", &StatusError{StatusCode: http.StatusTooManyRequests, RetryAfter: time.Hour}, 1, maxRetryBackoff, true},
+		{"decode", decodeErr, 1, 0, false},
+		{"wrapped decode", fmt.Errorf("markets: %w", decodeErr), 1, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, retry := policy.delay(tt.err, tt.attempt)
+			if got != tt.want || retry != tt.retry {
+				t.Errorf("delay %s, %v, want %s, %v", got, retry, tt.want, tt.retry)
+			}
+		})
+	}
+	var syntax *json.SyntaxError
+	if !errors.As(decodeErr, &syntax) {
+		t.Errorf("decode error %v doesn't unwrap to the json error", decodeErr)
+	}
+}
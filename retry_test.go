@@ -0,0 +1,168 @@
+package gop2b_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sutapurachina/gop2b"
+)
+
+func TestRetryTransient(t *testing.T) {
+	client, server := newTestClient(t, gop2b.WithRetry(3, time.Millisecond))
+	server.SetError("/public/markets", http.StatusBadGateway, "bad gateway")
+	_, err := client.GetMarkets(context.Background())
+	var status *gop2b.StatusError
+	if !errors.As(err, &status) || status.StatusCode != http.StatusBadGateway {
+		t.Fatalf("error %v, want the 502", err)
+	}
+	if n := server.Requests("/public/markets"); n != 3 {
+		t.Errorf("%d requests, want 3", n)
+	}
+}
+
+func TestRetryMaintenanceBacksOffLonger(t *testing.T) {
+	client, server := newTestClient(t, gop2b.WithRetry(3, time.Millisecond))
+	server.SetError("/public/markets", http.StatusServiceUnavailable, fixture(t, "error_maintenance.json"))
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	_, err := client.GetMarkets(ctx)
+	if !errors.Is(err, gop2b.ErrMaintenance) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error %v, want maintenance and the deadline", err)
+	}
+	// a transient error would have been retried after a millisecond
+	if n := server.Requests("/public/markets"); n != 1 {
+		t.Errorf("%d requests, want 1 before the maintenance wait", n)
+	}
+}
+
+func TestRetryClientErrorNotRetried(t *testing.T) {
+	client, server := newTestClient(t, gop2b.WithRetry(3, time.Millisecond))
+	server.SetError("/public/markets", http.StatusNotFound, "not found")
+	if _, err := client.GetMarkets(context.Background()); err == nil {
+		t.Fatal("no error")
+	}
+	if n := server.Requests("/public/markets"); n != 1 {
+		t.Errorf("%d requests, want 1", n)
+	}
+}
+
+func TestRetryDecodeErrorNotRetried(t *testing.T) {
+	client, server := newTestClient(t, gop2b.WithRetry(3, time.Millisecond))
+	server.SetResponse("/public/markets", `{"success":true,"result":[{"name":`)
+	if _, err := client.GetMarkets(context.Background()); err == nil {
+		t.Fatal("no error")
+	}
+	if n := server.Requests("/public/markets"); n != 1 {
+		t.Errorf("%d requests, want 1", n)
+	}
+}
+
+func TestRetryTooManyRequestsRetryAfter(t *testing.T) {
+	var requests atomic.Int32
+	markets := fixture(t, "public_markets.json")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = io.WriteString(w, markets)
+	}))
+	defer server.Close()
+	client, err := gop2b.NewClient("", "", gop2b.WithBaseURL(server.URL), gop2b.WithRetry(2, time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if _, err := client.GetMarkets(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// the backoff alone would have retried after a millisecond
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %s, want the 1s Retry-After", elapsed)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("%d requests, want 2", n)
+	}
+}
+
+func TestContextErrors(t *testing.T) {
+	calls := []struct {
+		name string
+		path string
+		call func(ctx context.Context, client gop2b.Client) error
+	}{
+		{"GetMarkets", "/public/markets", func(ctx context.Context, client gop2b.Client) error {
+			_, err := client.GetMarkets(ctx)
+			return err
+		}},
+		{"GetDepth", "/public/depth/result", func(ctx context.Context, client gop2b.Client) error {
+			_, err := client.GetDepth(ctx, "ETH_BTC", 0, "")
+			return err
+		}},
+		{"GetPublic", "/public/ticker", func(ctx context.Context, client gop2b.Client) error {
+			return client.GetPublic(ctx, "/public/ticker", url.Values{"market": {"ETH_BTC"}}, nil)
+		}},
+		{"PostOpenOrders", "/orders", func(ctx context.Context, client gop2b.Client) error {
+			_, err := client.PostOpenOrders(ctx, &gop2b.OpenOrdersRequest{Market: "ETH_BTC", Limit: 10})
+			return err
+		}},
+		{"PostSigned", "/account/balances", func(ctx context.Context, client gop2b.Client) error {
+			return client.PostSigned(ctx, "/account/balances", nil, nil)
+		}},
+	}
+	for _, tt := range calls {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := newTestClient(t, gop2b.WithRetry(3, time.Millisecond))
+			server.SetLatency(tt.path, time.Second)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(50*time.Millisecond, cancel)
+			err := tt.call(ctx, client)
+			if !errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("cancelled: error %v, want context.Canceled", err)
+			}
+
+			ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			err = tt.call(ctx, client)
+			if !errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+				t.Errorf("timed out: error %v, want context.DeadlineExceeded", err)
+			}
+			var apiErr *gop2b.APIError
+			if errors.As(err, &apiErr) {
+				t.Errorf("context error wrapped in %#v", apiErr)
+			}
+		})
+	}
+}
+
+func TestContextCancelledDuringRetryWait(t *testing.T) {
+	client, server := newTestClient(t, gop2b.WithRetry(5, time.Second))
+	server.SetError("/public/markets", http.StatusBadGateway, "bad gateway")
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	start := time.Now()
+	_, err := client.GetMarkets(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("error %v, want context.Canceled", err)
+	}
+	// the last attempt is kept along with the context error
+	var status *gop2b.StatusError
+	if !errors.As(err, &status) || status.StatusCode != http.StatusBadGateway {
+		t.Errorf("error %v doesn't carry the failed attempt", err)
+	}
+	if elapsed := time.Since(start); elapsed > 900*time.Millisecond {
+		t.Errorf("returned after %s, the retry wait wasn't cut short", elapsed)
+	}
+	if n := server.Requests("/public/markets"); n != 1 {
+		t.Errorf("%d requests, want 1", n)
+	}
+}
@@ -0,0 +1,77 @@
+package gop2b
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrClientShutdown is returned by requests made after Shutdown
+var ErrClientShutdown = errors.New("client shut down")
+
+// requestContext derives a context from ctx which is also cancelled by Shutdown
+// and bounded by the CallOptions timeout of ctx
+func (c *client) requestContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	var cancel context.CancelFunc
+	if timeout := callOptionsFromContext(ctx).Timeout; timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	stop := context.AfterFunc(c.ctx, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// goBackground runs fn in a goroutine Shutdown waits for, fn must return once c.ctx is done
+func (c *client) goBackground(fn func()) error {
+	c.backgroundMu.Lock()
+	defer c.backgroundMu.Unlock()
+	if c.ctx.Err() != nil {
+		return ErrClientShutdown
+	}
+	c.background.Add(1)
+	go func() {
+		defer c.background.Done()
+		fn()
+	}()
+	return nil
+}
+
+// Shutdown aborts in-flight requests and retry waits, stops the background loops such as
+// PollDepth and waits for them to exit, at most until ctx is done.
+// Requests made after Shutdown fail with ErrClientShutdown.
+func (c *client) Shutdown(ctx context.Context) error {
+	c.backgroundMu.Lock()
+	c.cancel()
+	c.backgroundMu.Unlock()
+
+	stopped := make(chan struct{})
+	go func() {
+		c.background.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Shutdown closes the websocket like Close but waits at most until ctx is done
+// for the read, keepalive and reconnect loops to exit
+func (w *WSClient) Shutdown(ctx context.Context) error {
+	closed := make(chan struct{})
+	go func() {
+		_ = w.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,93 @@
+package gop2b_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"go.uber.org/goleak"
+
+	"github.com/sutapurachina/gop2b"
+	"github.com/sutapurachina/gop2b/gop2btest"
+)
+
+func TestShutdown(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	server := gop2btest.NewServer()
+	defer server.Close()
+	client, err := server.Client(gop2b.WithRetry(5, time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updates, err := client.PollDepth(context.Background(), "ETH_BTC", 10, "", 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-updates
+
+	// a request waiting for its retry in a minute
+	server.SetError("/public/tickers", http.StatusBadGateway, "bad gateway")
+	retrying := make(chan error, 1)
+	go func() {
+		_, err := client.GetTickers(context.Background())
+		retrying <- err
+	}()
+	for server.Requests("/public/tickers") == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := client.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-retrying:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("retried request failed with %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("retry wait not aborted")
+	}
+	for range updates {
+	}
+	if _, err := client.GetMarkets(context.Background()); !errors.Is(err, gop2b.ErrClientShutdown) {
+		t.Errorf("request after shutdown failed with %v, want ErrClientShutdown", err)
+	}
+	if _, err := client.PollDepth(context.Background(), "ETH_BTC", 10, "", time.Second); err == nil {
+		t.Error("PollDepth started after shutdown")
+	}
+}
+
+func TestWSClientShutdownReconnecting(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	server := gop2btest.NewWsServer()
+	defer server.Close()
+	ws := gop2b.NewWSClient(gop2b.WithWSURL(server.URL))
+	if err := ws.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	deals, err := ws.SubscribeDeals(context.Background(), "ETH_BTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the client waits a second before redialing
+	server.Disconnect()
+	for ws.State() == gop2b.ConnConnected {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	if err := ws.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for range deals {
+	}
+	if state := ws.State(); state != gop2b.ConnClosed {
+		t.Errorf("state %s, want closed", state)
+	}
+}
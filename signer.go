@@ -0,0 +1,40 @@
+package gop2b
+
+// Signer signs the payload of private requests.
+// The payload is the base64 encoded JSON body, sent as is in the PayloadHeader.
+type Signer interface {
+	// Sign returns the signature of payload with the API secret
+	Sign(secret string, payload []byte) string
+	// PayloadHeader is the header carrying the base64 payload
+	PayloadHeader() string
+	// SignatureHeader is the header carrying the signature
+	SignatureHeader() string
+}
+
+// HMACSHA512Signer is the default Signer, the hex encoded HMAC-SHA512 of the payload
+type HMACSHA512Signer struct{}
+
+// Sign returns the same signature as Signature
+func (HMACSHA512Signer) Sign(secret string, payload []byte) string {
+	return Signature(secret, string(payload))
+}
+
+// PayloadHeader returns HeaderXTxcPayload
+func (HMACSHA512Signer) PayloadHeader() string {
+	return HeaderXTxcPayload
+}
+
+// SignatureHeader returns HeaderXTxcSignature
+func (HMACSHA512Signer) SignatureHeader() string {
+	return HeaderXTxcSignature
+}
+
+// WithSigner replaces the HMAC-SHA512 signing of private requests, nil keeps the default
+func WithSigner(signer Signer) Option {
+	return func(c *client) {
+		if signer == nil {
+			signer = HMACSHA512Signer{}
+		}
+		c.signer = signer
+	}
+}
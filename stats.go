@@ -0,0 +1,167 @@
+package gop2b
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// latencyBuckets are the upper bounds of the request latency histogram, the last bucket is unbounded
+var latencyBuckets = [...]time.Duration{
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// EndpointStats are the cumulative counters of an endpoint since the client was created or ResetStats
+type EndpointStats struct {
+	// Requests counts HTTP attempts, retries included
+	Requests uint64
+	// NetworkErrors counts attempts without a response, ClientErrors and ServerErrors
+	// responses with a 4xx and 5xx status, DecodeErrors responses that failed to decode
+	NetworkErrors uint64
+	ClientErrors  uint64
+	ServerErrors  uint64
+	DecodeErrors  uint64
+	// P50, P90 and P99 are latency percentiles, the upper bound of the histogram bucket
+	// they fall in, or Max past the last bucket of 10s. Max is the slowest attempt.
+	P50 time.Duration
+	P90 time.Duration
+	P99 time.Duration
+	Max time.Duration
+}
+
+// Errors returns the total of all error classes
+func (s EndpointStats) Errors() uint64 {
+	return s.NetworkErrors + s.ClientErrors + s.ServerErrors + s.DecodeErrors
+}
+
+type endpointCounters struct {
+	requests      atomic.Uint64
+	networkErrors atomic.Uint64
+	clientErrors  atomic.Uint64
+	serverErrors  atomic.Uint64
+	decodeErrors  atomic.Uint64
+	buckets       [len(latencyBuckets) + 1]atomic.Uint64
+	max           atomic.Int64
+}
+
+// requestStats keeps counters per endpoint path. The zero value is ready to use.
+type requestStats struct {
+	mu        sync.RWMutex
+	endpoints map[string]*endpointCounters
+}
+
+func (s *requestStats) counters(endpoint string) *endpointCounters {
+	s.mu.RLock()
+	counters := s.endpoints[endpoint]
+	s.mu.RUnlock()
+	if counters != nil {
+		return counters
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.endpoints == nil {
+		s.endpoints = make(map[string]*endpointCounters)
+	}
+	if counters = s.endpoints[endpoint]; counters == nil {
+		counters = &endpointCounters{}
+		s.endpoints[endpoint] = counters
+	}
+	return counters
+}
+
+// observe records an attempt of endpoint, status being zero when no response arrived
+func (s *requestStats) observe(endpoint string, status int, d time.Duration) {
+	counters := s.counters(endpoint)
+	counters.requests.Add(1)
+	switch {
+	case status == 0:
+		counters.networkErrors.Add(1)
+	case status >= 500:
+		counters.serverErrors.Add(1)
+	case status >= 400:
+		counters.clientErrors.Add(1)
+	}
+	bucket := len(latencyBuckets)
+	for i, bound := range latencyBuckets {
+		if d <= bound {
+			bucket = i
+			break
+		}
+	}
+	counters.buckets[bucket].Add(1)
+	for {
+		max := counters.max.Load()
+		if int64(d) <= max || counters.max.CompareAndSwap(max, int64(d)) {
+			break
+		}
+	}
+}
+
+func (s *requestStats) decodeError(endpoint string) {
+	s.counters(endpoint).decodeErrors.Add(1)
+}
+
+func (s *requestStats) snapshot() map[string]EndpointStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make(map[string]EndpointStats, len(s.endpoints))
+	for endpoint, counters := range s.endpoints {
+		stats := EndpointStats{
+			Requests:      counters.requests.Load(),
+			NetworkErrors: counters.networkErrors.Load(),
+			ClientErrors:  counters.clientErrors.Load(),
+			ServerErrors:  counters.serverErrors.Load(),
+			DecodeErrors:  counters.decodeErrors.Load(),
+			Max:           time.Duration(counters.max.Load()),
+		}
+		var buckets [len(latencyBuckets) + 1]uint64
+		var total uint64
+		for i := range buckets {
+			buckets[i] = counters.buckets[i].Load()
+			total += buckets[i]
+		}
+		percentile := func(p float64) time.Duration {
+			if total == 0 {
+				return 0
+			}
+			rank := uint64(math.Ceil(p * float64(total)))
+			var seen uint64
+			for i, n := range buckets[:len(latencyBuckets)] {
+				if seen += n; seen >= rank {
+					return min(latencyBuckets[i], stats.Max)
+				}
+			}
+			return stats.Max
+		}
+		stats.P50, stats.P90, stats.P99 = percentile(0.5), percentile(0.9), percentile(0.99)
+		result[endpoint] = stats
+	}
+	return result
+}
+
+func (s *requestStats) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.endpoints = nil
+}
+
+// Stats returns the request counters and latencies per endpoint path, such as "/public/ticker"
+func (c *client) Stats() map[string]EndpointStats {
+	return c.stats.snapshot()
+}
+
+// ResetStats clears all endpoint statistics
+func (c *client) ResetStats() {
+	c.stats.reset()
+}
@@ -0,0 +1,170 @@
+package gop2b
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// tickerBulkThreshold is the amount of markets above which a single GetTickers call is
+// cheaper than one GetTicker call per market
+const tickerBulkThreshold = 5
+
+// TickerUpdate is the latest ticker of a market
+type TickerUpdate struct {
+	Market string
+	Ticker Ticker
+	// At is the server time of the ticker
+	At time.Time
+}
+
+// TickerRefresher keeps the tickers of a set of markets fresh in the background.
+// Markets are refreshed every interval with one GetTickers call when there are more than a
+// few of them, one GetTicker call per market otherwise, so requests go through the client rate limiter.
+type TickerRefresher struct {
+	rest     PublicClient
+	interval time.Duration
+
+	mu      sync.RWMutex
+	markets map[string]bool
+	latest  map[string]TickerUpdate
+
+	updates chan TickerUpdate
+}
+
+// NewTickerRefresher starts refreshing the tickers of markets every interval until ctx is
+// done, then closes the updates channel. The first refresh runs immediately.
+func NewTickerRefresher(ctx context.Context, rest PublicClient, interval time.Duration, markets ...string) (*TickerRefresher, error) {
+	if interval <= 0 {
+		return nil, errors.New("refresh interval must be positive")
+	}
+	r := &TickerRefresher{
+		rest:     rest,
+		interval: interval,
+		markets:  make(map[string]bool),
+		latest:   make(map[string]TickerUpdate),
+		updates:  make(chan TickerUpdate, 64),
+	}
+	r.Add(markets...)
+	go r.run(ctx)
+	return r, nil
+}
+
+// Updates returns the channel of changed tickers, closed once the refresher stops.
+// It must be drained, refreshing waits while the channel is full.
+func (r *TickerRefresher) Updates() <-chan TickerUpdate {
+	return r.updates
+}
+
+// Get returns the latest ticker of market, ok is false until it was fetched once
+func (r *TickerRefresher) Get(market string) (TickerUpdate, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, ok := r.latest[market]
+	return t, ok
+}
+
+// Add starts refreshing markets from the next refresh on
+func (r *TickerRefresher) Add(markets ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, m := range markets {
+		r.markets[m] = true
+	}
+}
+
+// Remove stops refreshing markets and forgets their tickers
+func (r *TickerRefresher) Remove(markets ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, m := range markets {
+		delete(r.markets, m)
+		delete(r.latest, m)
+	}
+}
+
+// Markets returns the refreshed markets sorted by name
+func (r *TickerRefresher) Markets() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	markets := make([]string, 0, len(r.markets))
+	for m := range r.markets {
+		markets = append(markets, m)
+	}
+	sort.Strings(markets)
+	return markets
+}
+
+func (r *TickerRefresher) run(ctx context.Context) {
+	defer close(r.updates)
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		for _, u := range r.refresh(ctx) {
+			select {
+			case r.updates <- u:
+			case <-ctx.Done():
+				return
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refresh fetches the tickers of all markets and returns the ones that changed.
+// Markets failing to refresh keep their previous ticker.
+func (r *TickerRefresher) refresh(ctx context.Context) []TickerUpdate {
+	markets := r.Markets()
+	var fetched []TickerUpdate
+	if len(markets) > tickerBulkThreshold {
+		resp, err := r.rest.GetTickers(ctx)
+		if err != nil || !resp.Success {
+			return nil
+		}
+		for _, m := range markets {
+			if entry, ok := resp.Result[m]; ok {
+				fetched = append(fetched, TickerUpdate{Market: m, Ticker: entry.Ticker, At: entry.Ticker.At})
+			}
+		}
+	} else {
+		for _, m := range markets {
+			resp, err := r.rest.GetTicker(ctx, m)
+			if err != nil || !resp.Success {
+				continue
+			}
+			if resp.Result.At.IsZero() {
+				resp.Result.At = time.Now()
+			}
+			fetched = append(fetched, TickerUpdate{Market: m, Ticker: resp.Result, At: resp.Result.At})
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var changed []TickerUpdate
+	for _, u := range fetched {
+		if !r.markets[u.Market] {
+			// removed while fetching
+			continue
+		}
+		if prev, ok := r.latest[u.Market]; ok && sameTicker(prev.Ticker, u.Ticker) {
+			continue
+		}
+		r.latest[u.Market] = u
+		changed = append(changed, u)
+	}
+	return changed
+}
+
+// sameTicker reports whether all values of a and b are equal
+func sameTicker(a, b Ticker) bool {
+	return a.Bid.Equal(b.Bid) && a.Ask.Equal(b.Ask) && a.Open.Equal(b.Open) && a.Low.Equal(b.Low) &&
+		a.High.Equal(b.High) && a.Last.Equal(b.Last) && a.Volume.Equal(b.Volume) &&
+		a.Deal.Equal(b.Deal)
+}
@@ -0,0 +1,180 @@
+package gop2b
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExchangeTime is a time the exchange sends as unix seconds with a fraction or as integer
+// unix milliseconds, either as a JSON number or a numeric string. Null, an empty string and 0
+// decode to the zero time.
+//
+// The unit is told apart by magnitude: values of at least millisTimestampThreshold, which
+// would be seconds after the year 33658, are milliseconds. Milliseconds before September
+// 2001 would be read as seconds. It is only used for fields whose unit varies, fields with a
+// known unit use ExchangeTimeSeconds or ExchangeTimeMillis.
+type ExchangeTime struct {
+	time.Time
+}
+
+// millisTimestampThreshold is the magnitude from which ExchangeTime reads milliseconds, 1e12
+// is 2001-09-09 in milliseconds and far beyond any time in seconds
+const millisTimestampThreshold = 1e12
+
+// UnmarshalJSON reads the value exactly, without going through a float. The time is zero
+// after an error.
+func (t *ExchangeTime) UnmarshalJSON(data []byte) error {
+	t.Time = time.Time{}
+	s, err := timestampText(data)
+	if err != nil || s == "" {
+		return err
+	}
+	t.Time, err = parseTimestamp(s, true)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp %s", data)
+	}
+	return nil
+}
+
+// parseTimestamp parses unix seconds with a fraction, or milliseconds from
+// millisTimestampThreshold on when guessMillis is set
+func parseTimestamp(s string, guessMillis bool) (time.Time, error) {
+	sec, frac, _ := strings.Cut(s, ".")
+	seconds, err := strconv.ParseInt(sec, 10, 64)
+	if err != nil || len(frac) > 9 || strings.Trim(frac, "0123456789") != "" || strings.HasPrefix(s, "-") {
+		// exponents, negative or sub-nanosecond values are rare enough to go through a float
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+			return time.Time{}, fmt.Errorf("invalid timestamp %q", s)
+		}
+		if guessMillis && math.Abs(f) >= millisTimestampThreshold {
+			f /= 1000
+		}
+		return zeroOr(TimestampToTime(f), f == 0), nil
+	}
+	var nanos int64
+	if frac != "" {
+		if nanos, err = strconv.ParseInt(frac+strings.Repeat("0", 9-len(frac)), 10, 64); err != nil {
+			return time.Time{}, fmt.Errorf("invalid timestamp %q", s)
+		}
+	}
+	if guessMillis && seconds >= millisTimestampThreshold {
+		// the fraction is of a millisecond
+		return time.UnixMilli(seconds).Add(time.Duration(nanos / 1e3)), nil
+	}
+	return zeroOr(time.Unix(seconds, nanos), seconds == 0 && nanos == 0), nil
+}
+
+// MarshalJSON writes unix seconds with a fraction as a JSON number, null for the zero time
+func (t ExchangeTime) MarshalJSON() ([]byte, error) {
+	return marshalSeconds(t.Time), nil
+}
+
+// ExchangeTimeSeconds is a time the exchange sends as unix seconds, with or without a
+// fraction, as a JSON number or a numeric string. Null, an empty string and 0 decode to the
+// zero time. Unlike ExchangeTime it never reads milliseconds, so times before 2001 or after
+// 33658 keep their unit.
+type ExchangeTimeSeconds struct {
+	time.Time
+}
+
+// UnmarshalJSON reads the seconds exactly, the time is zero after an error
+func (t *ExchangeTimeSeconds) UnmarshalJSON(data []byte) error {
+	t.Time = time.Time{}
+	s, err := timestampText(data)
+	if err != nil || s == "" {
+		return err
+	}
+	t.Time, err = parseTimestamp(s, false)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp %s", data)
+	}
+	return nil
+}
+
+// MarshalJSON writes unix seconds with a fraction as a JSON number, null for the zero time
+func (t ExchangeTimeSeconds) MarshalJSON() ([]byte, error) {
+	return marshalSeconds(t.Time), nil
+}
+
+// marshalSeconds writes t as unix seconds with a fraction, null for the zero time
+func marshalSeconds(t time.Time) []byte {
+	if t.IsZero() {
+		return []byte("null")
+	}
+	// UnixNano would overflow after 2262
+	seconds, nanos := t.Unix(), int64(t.Nanosecond())
+	sign := ""
+	if seconds < 0 && nanos > 0 {
+		sign, seconds, nanos = "-", -seconds-1, 1e9-nanos
+	} else if seconds < 0 {
+		sign, seconds = "-", -seconds
+	}
+	s := sign + strconv.FormatInt(seconds, 10)
+	if nanos != 0 {
+		s += strings.TrimRight(fmt.Sprintf(".%09d", nanos), "0")
+	}
+	return []byte(s)
+}
+
+// ExchangeTimeMillis is a time sent as integer unix milliseconds, as a JSON number or a
+// numeric string. Null, an empty string and 0 decode to the zero time. The documented
+// endpoints all send seconds; it is meant for results of other endpoints decoded with
+// GetPublic or PostSigned.
+type ExchangeTimeMillis struct {
+	time.Time
+}
+
+// UnmarshalJSON reads the milliseconds, the time is zero after an error
+func (t *ExchangeTimeMillis) UnmarshalJSON(data []byte) error {
+	t.Time = time.Time{}
+	s, err := timestampText(data)
+	if err != nil || s == "" {
+		return err
+	}
+	ms, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid millisecond timestamp %s", data)
+	}
+	t.Time = zeroOr(time.UnixMilli(ms), ms == 0)
+	return nil
+}
+
+// MarshalJSON writes unix milliseconds as a JSON number, null for the zero time
+func (t ExchangeTimeMillis) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return []byte(strconv.FormatInt(t.UnixMilli(), 10)), nil
+}
+
+// timestampText returns the text of a JSON number or numeric string, empty for null
+func timestampText(data []byte) (string, error) {
+	var v interface{}
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.UseNumber()
+	if err := decoder.Decode(&v); err != nil {
+		return "", err
+	}
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case json.Number:
+		return v.String(), nil
+	case string:
+		return strings.TrimSpace(v), nil
+	}
+	return "", fmt.Errorf("invalid timestamp %s", data)
+}
+
+// zeroOr returns the zero time when zero is set, t otherwise
+func zeroOr(t time.Time, zero bool) time.Time {
+	if zero {
+		return time.Time{}
+	}
+	return t
+}
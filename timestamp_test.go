@@ -0,0 +1,135 @@
+package gop2b_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/sutapurachina/gop2b"
+)
+
+func TestExchangeTimeUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		input string
+		want  time.Time
+	}{
+		{`1700000000.5`, time.Date(2023, 11, 14, 22, 13, 20, 500_000_000, time.UTC)},
+		{`"1700000000.5"`, time.Date(2023, 11, 14, 22, 13, 20, 500_000_000, time.UTC)},
+		{`1700000000`, time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC)},
+		{`1.7e9`, time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC)},
+		// integer milliseconds
+		{`1700000000500`, time.Date(2023, 11, 14, 22, 13, 20, 500_000_000, time.UTC)},
+		{`"1700000000500"`, time.Date(2023, 11, 14, 22, 13, 20, 500_000_000, time.UTC)},
+		{`1700000000500.25`, time.Date(2023, 11, 14, 22, 13, 20, 500_250_000, time.UTC)},
+		{`1.7000000005e12`, time.Date(2023, 11, 14, 22, 13, 20, 500_000_000, time.UTC)},
+		{`1000000000000`, time.Date(2001, 9, 9, 1, 46, 40, 0, time.UTC)},
+		// seconds before 2001 and after 2286 stay seconds
+		{`946684799`, time.Date(1999, 12, 31, 23, 59, 59, 0, time.UTC)},
+		{`"86400.25"`, time.Date(1970, 1, 2, 0, 0, 0, 250_000_000, time.UTC)},
+		{`10000000000`, time.Date(2286, 11, 20, 17, 46, 40, 0, time.UTC)},
+		{`99999999999.5`, time.Date(5138, 11, 16, 9, 46, 39, 500_000_000, time.UTC)},
+		{`-86400`, time.Date(1969, 12, 31, 0, 0, 0, 0, time.UTC)},
+		{`null`, time.Time{}},
+		{`""`, time.Time{}},
+		{`0`, time.Time{}},
+		{`"0.0"`, time.Time{}},
+	}
+	for _, tt := range tests {
+		var got gop2b.ExchangeTime
+		if err := json.Unmarshal([]byte(tt.input), &got); err != nil {
+			t.Errorf("%s: %v", tt.input, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("%s decoded to %s, want %s", tt.input, got.UTC(), tt.want)
+		}
+	}
+}
+
+func TestExchangeTimeInvalid(t *testing.T) {
+	for _, input := range []string{`"abc"`, `true`, `{}`, `"NaN"`, `"1e400"`} {
+		got := gop2b.ExchangeTime{Time: time.Now()}
+		if err := got.UnmarshalJSON([]byte(input)); err == nil {
+			t.Errorf("%s decoded to %s", input, got)
+		}
+		if !got.IsZero() {
+			t.Errorf("%s left %s after the error, want the zero time", input, got)
+		}
+	}
+}
+
+func TestExchangeTimeRoundTrip(t *testing.T) {
+	for _, want := range []time.Time{
+		time.Date(2023, 11, 14, 22, 13, 20, 123_456_789, time.UTC),
+		time.Date(1999, 12, 31, 23, 59, 59, 0, time.UTC),
+		time.Date(2300, 1, 1, 0, 0, 0, 500_000_000, time.UTC),
+	} {
+		data, err := json.Marshal(gop2b.ExchangeTime{Time: want})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got gop2b.ExchangeTime
+		if err := json.Unmarshal(data, &got); err != nil || !got.Equal(want) {
+			t.Errorf("%s encoded as %s decoded to %s, %v", want, data, got, err)
+		}
+	}
+	if data, _ := json.Marshal(gop2b.ExchangeTime{}); string(data) != "null" {
+		t.Errorf("zero time encoded as %s, want null", data)
+	}
+}
+
+func TestExchangeTimeMillis(t *testing.T) {
+	tests := []struct {
+		input string
+		want  time.Time
+	}{
+		{`1700000000500`, time.Date(2023, 11, 14, 22, 13, 20, 500_000_000, time.UTC)},
+		{`"1700000000500"`, time.Date(2023, 11, 14, 22, 13, 20, 500_000_000, time.UTC)},
+		// milliseconds before 2001, which ExchangeTime would read as seconds
+		{`946684799000`, time.Date(1999, 12, 31, 23, 59, 59, 0, time.UTC)},
+		{`null`, time.Time{}},
+		{`0`, time.Time{}},
+	}
+	for _, tt := range tests {
+		var got gop2b.ExchangeTimeMillis
+		if err := json.Unmarshal([]byte(tt.input), &got); err != nil || !got.Equal(tt.want) {
+			t.Errorf("%s decoded to %s, %v, want %s", tt.input, got.UTC(), err, tt.want)
+		}
+	}
+	got := gop2b.ExchangeTimeMillis{Time: time.Now()}
+	if err := got.UnmarshalJSON([]byte(`"1700000000.5"`)); err == nil || !got.IsZero() {
+		t.Errorf("fractional milliseconds decoded to %s, %v", got, err)
+	}
+}
+
+func TestExchangeTimeSeconds(t *testing.T) {
+	tests := []struct {
+		input string
+		want  time.Time
+	}{
+		{`1700000000.5`, time.Date(2023, 11, 14, 22, 13, 20, 500_000_000, time.UTC)},
+		{`"1700000000"`, time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC)},
+		{`946684799`, time.Date(1999, 12, 31, 23, 59, 59, 0, time.UTC)},
+		{`10000000000.25`, time.Date(2286, 11, 20, 17, 46, 40, 250_000_000, time.UTC)},
+		// seconds past the magnitude ExchangeTime reads as milliseconds
+		{`1000000000000`, time.Unix(1e12, 0)},
+		{`null`, time.Time{}},
+		{`""`, time.Time{}},
+		{`0`, time.Time{}},
+	}
+	for _, tt := range tests {
+		var got gop2b.ExchangeTimeSeconds
+		if err := json.Unmarshal([]byte(tt.input), &got); err != nil || !got.Equal(tt.want) {
+			t.Errorf("%s decoded to %s, %v, want %s", tt.input, got.UTC(), err, tt.want.UTC())
+		}
+	}
+	got := gop2b.ExchangeTimeSeconds{Time: time.Now()}
+	if err := got.UnmarshalJSON([]byte(`"abc"`)); err == nil || !got.IsZero() {
+		t.Errorf("invalid seconds decoded to %s, %v", got, err)
+	}
+	want := time.Date(2300, 1, 1, 0, 0, 0, 500_000_000, time.UTC)
+	data, err := json.Marshal(gop2b.ExchangeTimeSeconds{Time: want})
+	if err != nil || string(data) != "10413792000.5" {
+		t.Errorf("%s encoded as %s, %v", want, data, err)
+	}
+}
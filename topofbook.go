@@ -0,0 +1,156 @@
+package gop2b
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// QuoteSource tells where a TopOfBook update comes from
+type QuoteSource int
+
+const (
+	SourceWebsocket QuoteSource = iota
+	SourceREST
+)
+
+func (s QuoteSource) String() string {
+	if s == SourceREST {
+		return "rest"
+	}
+	return "websocket"
+}
+
+// TopOfBook is the best bid and ask of a market
+type TopOfBook struct {
+	Market    string
+	Bid       decimal.Decimal
+	BidAmount decimal.Decimal
+	Ask       decimal.Decimal
+	AskAmount decimal.Decimal
+	// At is the local time the quote was received, strictly increasing along a stream
+	At     time.Time
+	Source QuoteSource
+}
+
+// topOfBookDepth is the amount of levels requested for the top of book
+const topOfBookDepth = 5
+
+// NewTopOfBookStream streams the best bid and ask of market from the depth channel of ws,
+// which must be connected. While the websocket is down, the book is polled from rest every
+// pollInterval until the websocket delivers a fresh snapshot again. Updates are only sent when
+// the quote or its source change, and stale REST results overtaken by websocket updates are dropped.
+// The channel is closed once ctx is done or the depth subscription ends.
+func NewTopOfBookStream(ctx context.Context, rest PublicClient, ws *WSClient, market string, pollInterval time.Duration) (<-chan TopOfBook, error) {
+	if pollInterval <= 0 {
+		return nil, errors.New("poll interval must be positive")
+	}
+	updates, err := ws.SubscribeDepth(ctx, market, topOfBookDepth, "0")
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan TopOfBook, wsChannelBuffer)
+	go func() {
+		defer close(out)
+		defer func() {
+			if ctx.Err() != nil {
+				unsubscribeCtx, cancel := context.WithTimeout(context.Background(), wsWriteTimeout)
+				_ = ws.Unsubscribe(unsubscribeCtx, ChannelDepth)
+				cancel()
+			}
+		}()
+		book := NewOrderBook(market)
+		var last TopOfBook
+		// live is set while the websocket book is current
+		live := false
+		emit := func(quote TopOfBook) bool {
+			if !quote.At.After(last.At) {
+				return true
+			}
+			if quote.Source == last.Source && quote.Bid.Equal(last.Bid) && quote.Ask.Equal(last.Ask) &&
+				quote.BidAmount.Equal(last.BidAmount) && quote.AskAmount.Equal(last.AskAmount) {
+				return true
+			}
+			select {
+			case out <- quote:
+				last = quote
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		poll := func() bool {
+			pollCtx, cancel := context.WithTimeout(ctx, pollInterval)
+			defer cancel()
+			resp, err := rest.GetDepth(pollCtx, market, topOfBookDepth, "")
+			if err != nil || !resp.Success || live {
+				return ctx.Err() == nil
+			}
+			snapshot := resp.Result
+			if len(snapshot.Bids) == 0 || len(snapshot.Asks) == 0 {
+				return true
+			}
+			return emit(TopOfBook{
+				Market:    market,
+				Bid:       snapshot.Bids[0].Price,
+				BidAmount: snapshot.Bids[0].Amount,
+				Ask:       snapshot.Asks[0].Price,
+				AskAmount: snapshot.Asks[0].Amount,
+				At:        time.Now(),
+				Source:    SourceREST,
+			})
+		}
+
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if !ws.Connected() {
+					live = false
+				}
+				if !live && !poll() {
+					return
+				}
+			case update, ok := <-updates:
+				if !ok {
+					return
+				}
+				if update.Reconnected && !update.Full {
+					// the book missed updates while disconnected, wait for the fresh snapshot
+					live = false
+					continue
+				}
+				if !update.Full && !live {
+					continue
+				}
+				if err := book.Apply(update); err != nil {
+					// the websocket client requests a snapshot after a gap
+					live = false
+					continue
+				}
+				live = true
+				bid, ask, ok := book.Best()
+				if !ok {
+					continue
+				}
+				if !emit(TopOfBook{
+					Market:    market,
+					Bid:       bid.Price,
+					BidAmount: bid.Amount,
+					Ask:       ask.Price,
+					AskAmount: ask.Amount,
+					At:        update.At,
+					Source:    SourceWebsocket,
+				}) {
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
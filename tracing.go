@@ -0,0 +1,128 @@
+package gop2b
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Span describes a REST call or a websocket request, see WithTracing
+type Span struct {
+	// Name is the endpoint path of a REST call, such as "/public/ticker",
+	// or the method of a websocket request, such as "depth.subscribe"
+	Name  string
+	Start time.Time
+	// End and Err are set when the span ends
+	End time.Time
+	Err error
+
+	mu            sync.Mutex
+	statusCode    int
+	retries       int
+	rateLimitWait time.Duration
+}
+
+// StatusCode returns the HTTP status of the last attempt, zero when no response arrived or for websocket requests
+func (s *Span) StatusCode() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.statusCode
+}
+
+// Retries returns how many times the request was sent again after a failure
+func (s *Span) Retries() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.retries
+}
+
+// RateLimitWait returns the time all attempts waited for the client rate limiter
+func (s *Span) RateLimitWait() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rateLimitWait
+}
+
+// SpanHooks are called around every traced operation, from the calling goroutine.
+// OnSpanStart receives the context passed to the method and returns the context the operation
+// continues with, so a tracer can store its own span there and propagate it, for example to
+// an instrumented transport set with WithTransport. Either hook may be nil.
+type SpanHooks struct {
+	OnSpanStart func(ctx context.Context, span *Span) context.Context
+	OnSpanEnd   func(ctx context.Context, span *Span)
+}
+
+// WithTracing calls hooks around every REST call. Retries of a call belong to its span.
+func WithTracing(hooks SpanHooks) Option {
+	return func(c *client) {
+		c.tracing = hooks
+	}
+}
+
+// WithWSTracing calls hooks around every websocket request, such as subscribe or query calls
+func WithWSTracing(hooks SpanHooks) WSOption {
+	return func(w *WSClient) {
+		w.tracing = hooks
+	}
+}
+
+type spanKey struct{}
+
+// start begins a span named name, returning the context carrying it. There is no span without hooks.
+func (h SpanHooks) start(ctx context.Context, name string) (context.Context, *Span) {
+	if h.OnSpanStart == nil && h.OnSpanEnd == nil {
+		return ctx, nil
+	}
+	span := &Span{Name: name, Start: time.Now()}
+	if h.OnSpanStart != nil {
+		if spanCtx := h.OnSpanStart(ctx, span); spanCtx != nil {
+			ctx = spanCtx
+		}
+	}
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// end ends span with the outcome err
+func (h SpanHooks) end(ctx context.Context, span *Span, err error) {
+	if span == nil {
+		return
+	}
+	span.End = time.Now()
+	span.Err = err
+	if h.OnSpanEnd != nil {
+		h.OnSpanEnd(ctx, span)
+	}
+}
+
+// spanFromContext returns the span of the operation ctx belongs to, nil when there is none
+func spanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+func (s *Span) observeStatus(status int) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.statusCode = status
+	s.mu.Unlock()
+}
+
+func (s *Span) observeRetry() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.retries++
+	s.mu.Unlock()
+}
+
+func (s *Span) observeRateLimitWait(d time.Duration) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.rateLimitWait += d
+	s.mu.Unlock()
+}
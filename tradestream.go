@@ -0,0 +1,84 @@
+package gop2b
+
+import (
+	"context"
+	"sort"
+)
+
+// tradeBackfillLimit is the page size used to fill gaps from the REST history
+const tradeBackfillLimit = 100
+
+// NewTradeStream returns the public trades of market in order of strictly increasing id.
+// It subscribes to the deals channel of ws, which must be connected, and after every
+// reconnect fills the trades missed while disconnected from the REST history of rest
+// before resuming live delivery. Trades seen twice are dropped.
+// If the REST backfill fails the stream resumes live delivery and the missed trades are lost.
+// The channel is closed once ctx is done or the deals subscription ends.
+func NewTradeStream(ctx context.Context, rest PublicClient, ws *WSClient, market string) (<-chan Trade, error) {
+	updates, err := ws.SubscribeDeals(ctx, market)
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan Trade, wsChannelBuffer)
+	go func() {
+		defer close(out)
+		defer func() {
+			if ctx.Err() != nil {
+				unsubscribeCtx, cancel := context.WithTimeout(context.Background(), wsWriteTimeout)
+				_ = ws.Unsubscribe(unsubscribeCtx, ChannelDeals)
+				cancel()
+			}
+		}()
+		var lastID int64
+		emit := func(trades []Trade) bool {
+			sort.Slice(trades, func(i, j int) bool { return trades[i].ID < trades[j].ID })
+			for _, t := range trades {
+				if t.ID <= lastID {
+					continue
+				}
+				select {
+				case out <- t:
+					lastID = t.ID
+				case <-ctx.Done():
+					return false
+				}
+			}
+			return true
+		}
+		backfill := func() bool {
+			for {
+				page, err := rest.GetHistory(ctx, market, lastID, tradeBackfillLimit)
+				if err != nil || !page.Success {
+					return ctx.Err() == nil
+				}
+				before := lastID
+				if !emit(page.Result) {
+					return false
+				}
+				if lastID == before || len(page.Result) < tradeBackfillLimit {
+					return true
+				}
+			}
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case update, ok := <-updates:
+				if !ok {
+					return
+				}
+				if update.Market != market {
+					continue
+				}
+				if update.Reconnected && lastID > 0 && !backfill() {
+					return
+				}
+				if !emit(update.Deals) {
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
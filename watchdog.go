@@ -0,0 +1,196 @@
+package gop2b
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// WatchdogEvent reports a feed becoming stale or recovering
+type WatchdogEvent struct {
+	Feed string
+	// Stale is true when the feed became stale and false when it recovered
+	Stale bool
+	At    time.Time
+	// LastUpdate is the time of the latest update of the feed
+	LastUpdate time.Time
+}
+
+// WatchdogOptions configures a Watchdog
+type WatchdogOptions struct {
+	// CheckInterval is how often feeds are checked, a tenth of the shortest staleness threshold when zero
+	CheckInterval time.Duration
+	// RecoverAfter is how long a stale feed must keep delivering updates before it is
+	// reported recovered, so a single stray update doesn't flap the verdict
+	RecoverAfter time.Duration
+}
+
+type watchedFeed struct {
+	staleAfter time.Duration
+	last       time.Time
+	stale      bool
+	// resumed is the first update after the feed went stale, zero while silent
+	resumed time.Time
+}
+
+// Watchdog tracks the liveness of market data feeds and emits an event when one stays
+// silent for longer than its threshold and when it recovers. Quiet markets may have no
+// trades for a long time, so depth or state subscriptions, which the exchange pushes
+// periodically, are the better liveness signal than deals.
+type Watchdog struct {
+	opts   WatchdogOptions
+	events chan WatchdogEvent
+	mu     sync.Mutex
+	feeds  map[string]*watchedFeed
+	wake   chan struct{}
+}
+
+// NewWatchdog creates a watchdog checking its feeds until ctx is done, when Events is closed
+func NewWatchdog(ctx context.Context, opts WatchdogOptions) *Watchdog {
+	w := &Watchdog{
+		opts:   opts,
+		events: make(chan WatchdogEvent, 16),
+		feeds:  make(map[string]*watchedFeed),
+		wake:   make(chan struct{}, 1),
+	}
+	go w.run(ctx)
+	return w
+}
+
+// Events returns the stale and recovery events
+func (w *Watchdog) Events() <-chan WatchdogEvent {
+	return w.events
+}
+
+// Add starts watching a feed which is stale after staleAfter without Touch
+func (w *Watchdog) Add(feed string, staleAfter time.Duration) {
+	w.mu.Lock()
+	w.feeds[feed] = &watchedFeed{staleAfter: staleAfter, last: time.Now()}
+	w.mu.Unlock()
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Remove stops watching a feed
+func (w *Watchdog) Remove(feed string) {
+	w.mu.Lock()
+	delete(w.feeds, feed)
+	w.mu.Unlock()
+}
+
+// Touch records an update of feed
+func (w *Watchdog) Touch(feed string) {
+	now := time.Now()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	f := w.feeds[feed]
+	if f == nil {
+		return
+	}
+	if f.stale && f.resumed.IsZero() {
+		f.resumed = now
+	}
+	f.last = now
+}
+
+// Healthy reports whether none of the feeds is stale
+func (w *Watchdog) Healthy() bool {
+	return len(w.Stale()) == 0
+}
+
+// Stale returns the names of the stale feeds, sorted
+func (w *Watchdog) Stale() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var stale []string
+	for name, f := range w.feeds {
+		if f.stale {
+			stale = append(stale, name)
+		}
+	}
+	sort.Strings(stale)
+	return stale
+}
+
+// Watch forwards the updates of in to the returned channel, touching feed on each one.
+// The feed is added to w with the staleAfter threshold and removed once in is closed.
+func Watch[T any](w *Watchdog, feed string, in <-chan T, staleAfter time.Duration) <-chan T {
+	w.Add(feed, staleAfter)
+	out := make(chan T, cap(in))
+	go func() {
+		defer close(out)
+		defer w.Remove(feed)
+		for v := range in {
+			w.Touch(feed)
+			out <- v
+		}
+	}()
+	return out
+}
+
+func (w *Watchdog) run(ctx context.Context) {
+	defer close(w.events)
+	for {
+		interval := w.checkInterval()
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-w.wake:
+			timer.Stop()
+			continue
+		case <-timer.C:
+		}
+		for _, event := range w.check(time.Now()) {
+			select {
+			case w.events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+func (w *Watchdog) checkInterval() time.Duration {
+	if w.opts.CheckInterval > 0 {
+		return w.opts.CheckInterval
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	interval := time.Second
+	for _, f := range w.feeds {
+		if d := f.staleAfter / 10; d > 0 && d < interval {
+			interval = d
+		}
+	}
+	return interval
+}
+
+// check updates the state of every feed and returns the resulting events
+func (w *Watchdog) check(now time.Time) []WatchdogEvent {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var events []WatchdogEvent
+	for name, f := range w.feeds {
+		silent := now.Sub(f.last) > f.staleAfter
+		switch {
+		case !f.stale && silent:
+			f.stale = true
+			f.resumed = time.Time{}
+			events = append(events, WatchdogEvent{Feed: name, Stale: true, At: now, LastUpdate: f.last})
+		case f.stale && silent:
+			// went silent again before recovering
+			f.resumed = time.Time{}
+		case f.stale && !f.resumed.IsZero() && now.Sub(f.resumed) >= w.opts.RecoverAfter:
+			f.stale = false
+			f.resumed = time.Time{}
+			events = append(events, WatchdogEvent{Feed: name, Stale: false, At: now, LastUpdate: f.last})
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Feed < events[j].Feed })
+	return events
+}
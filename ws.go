@@ -0,0 +1,1098 @@
+package gop2b
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/shopspring/decimal"
+)
+
+// WSChannel is a public websocket channel
+type WSChannel string
+
+const (
+	ChannelKline WSChannel = "kline"
+	ChannelPrice WSChannel = "price"
+	ChannelState WSChannel = "state"
+	ChannelDeals WSChannel = "deals"
+	ChannelDepth WSChannel = "depth"
+)
+
+const (
+	wsPingInterval  = 30 * time.Second
+	wsWriteTimeout  = 10 * time.Second
+	wsReconnectMin  = time.Second
+	wsReconnectMax  = 30 * time.Second
+	wsChannelBuffer = 64
+)
+
+var (
+	// ErrWSClosed is returned by calls on a closed WSClient
+	ErrWSClosed = errors.New("websocket client closed")
+	// ErrWSNotConnected is returned by calls while the websocket is not connected
+	ErrWSNotConnected = errors.New("websocket not connected")
+)
+
+// WSError is an error returned by the websocket server for a request
+type WSError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *WSError) Error() string {
+	return fmt.Sprintf("websocket error %d: %s", e.Code, e.Message)
+}
+
+// wsFrame is either a reply to a request, identified by ID, or a channel notification
+type wsFrame struct {
+	ID     *int64          `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *WSError        `json:"error"`
+}
+
+type wsReply struct {
+	result json.RawMessage
+	err    error
+}
+
+type wsSubscription struct {
+	method string
+	params []interface{}
+	// resyncing is set while a snapshot requested after dropped updates is outstanding
+	resyncing bool
+	// handle decodes a notification, reconnected is set on the first one after a reconnect
+	handle      func(params json.RawMessage, reconnected bool)
+	close       func()
+	reconnected bool
+}
+
+// WSClient is a client of the p2pb2b public websocket API.
+// It keeps the connection alive with pings, reconnects with backoff when it drops
+// and subscribes again to every channel it was subscribed to.
+type WSClient struct {
+	url    string
+	dialer *websocket.Dialer
+
+	ctx      context.Context
+	cancel   context.CancelFunc
+	stopped  chan struct{}
+	stopOnce sync.Once
+
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	conn    *websocket.Conn
+	started bool
+	nextID  int64
+	pending map[int64]chan wsReply
+	subs    map[WSChannel]*wsSubscription
+
+	buffer   int
+	policy   WSOverflowPolicy
+	policies map[WSChannel]WSOverflowPolicy
+	onDrop   func(channel WSChannel)
+	onGap    func(gap DepthGap)
+	tracing  SpanHooks
+	onFrame  func(direction FrameDirection, frame []byte)
+	dropped  map[WSChannel]uint64
+	metrics  MetricsCollector
+	// strictEnums is set by WithWSStrictEnums
+	strictEnums bool
+
+	stateMu      sync.Mutex
+	state        ConnState
+	states       chan ConnState
+	statesClosed bool
+}
+
+// ConnState is the state of the connection of a WSClient. The public websocket has no
+// authentication, so a connected client is ready for subscriptions.
+type ConnState int
+
+const (
+	// ConnDisconnected is the state before Connect and after the connection dropped
+	ConnDisconnected ConnState = iota
+	// ConnConnecting is the state while Connect dials
+	ConnConnecting
+	ConnConnected
+	// ConnReconnecting is the state while redialing after the connection dropped
+	ConnReconnecting
+	// ConnClosed is the final state once the client is closed
+	ConnClosed
+)
+
+func (s ConnState) String() string {
+	switch s {
+	case ConnDisconnected:
+		return "disconnected"
+	case ConnConnecting:
+		return "connecting"
+	case ConnConnected:
+		return "connected"
+	case ConnReconnecting:
+		return "reconnecting"
+	case ConnClosed:
+		return "closed"
+	}
+	return "unknown"
+}
+
+// wsStateBuffer is the amount of state changes kept for a slow StateChanges reader
+const wsStateBuffer = 16
+
+// WSOption configures optional WSClient behaviour
+type WSOption func(*WSClient)
+
+// WithWSURL overrides the websocket endpoint
+func WithWSURL(url string) WSOption {
+	return func(w *WSClient) {
+		w.url = url
+	}
+}
+
+// WSOverflowPolicy selects what happens when a subscriber doesn't keep up and its channel is full
+type WSOverflowPolicy int
+
+const (
+	// PolicyBlock waits for the subscriber, stalling the whole connection meanwhile
+	PolicyBlock WSOverflowPolicy = iota
+	// PolicyDropOldest discards the oldest buffered update to make room
+	PolicyDropOldest
+	// PolicyDropNewest discards the incoming update
+	PolicyDropNewest
+	// PolicyDisconnect discards the incoming update and drops the connection, the next
+	// update after the reconnect has Reconnected set
+	PolicyDisconnect
+)
+
+// WithWSChannelBuffer sets the buffer size of subscription channels, 64 by default
+func WithWSChannelBuffer(n int) WSOption {
+	return func(w *WSClient) {
+		if n >= 0 {
+			w.buffer = n
+		}
+	}
+}
+
+// WithWSOverflowPolicy sets the overflow policy of channels, of all of them when none
+// is given. PolicyBlock is the default. Dropped depth updates are followed by a full
+// snapshot, as the book is out of sync until then.
+func WithWSOverflowPolicy(policy WSOverflowPolicy, channels ...WSChannel) WSOption {
+	return func(w *WSClient) {
+		if len(channels) == 0 {
+			w.policy = policy
+			return
+		}
+		for _, c := range channels {
+			w.policies[c] = policy
+		}
+	}
+}
+
+// WithWSDropHook calls fn for every update dropped by an overflow policy, from the read loop
+func WithWSDropHook(fn func(channel WSChannel)) WSOption {
+	return func(w *WSClient) {
+		w.onDrop = fn
+	}
+}
+
+// WithWSGapHook calls fn for every gap in the depth update sequence, from the read loop.
+// The client discards diffs and requests a full snapshot after a gap.
+func WithWSGapHook(fn func(gap DepthGap)) WSOption {
+	return func(w *WSClient) {
+		w.onGap = fn
+	}
+}
+
+// FrameDirection tells received websocket frames from sent ones
+type FrameDirection string
+
+const (
+	FrameIn  FrameDirection = "in"
+	FrameOut FrameDirection = "out"
+)
+
+// WithWSFrameHook calls fn with every raw frame received or sent, such as to capture a
+// session for gop2btest.ReplayFrames. Received frames are passed from the read loop, sent
+// ones while holding the write lock, so fn must not block.
+func WithWSFrameHook(fn func(direction FrameDirection, frame []byte)) WSOption {
+	return func(w *WSClient) {
+		w.onFrame = fn
+	}
+}
+
+// NewWSClient creates a websocket client, call Connect to open the connection
+func NewWSClient(opts ...WSOption) *WSClient {
+	w := &WSClient{
+		url:     websocketApi,
+		dialer:  websocket.DefaultDialer,
+		stopped: make(chan struct{}),
+		pending: make(map[int64]chan wsReply),
+		subs:    make(map[WSChannel]*wsSubscription),
+
+		buffer:   wsChannelBuffer,
+		policies: make(map[WSChannel]WSOverflowPolicy),
+		dropped:  make(map[WSChannel]uint64),
+		metrics:  NopMetrics{},
+		states:   make(chan ConnState, wsStateBuffer),
+	}
+	w.ctx, w.cancel = context.WithCancel(context.Background())
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// Connect dials the websocket and starts serving it in the background.
+// ctx bounds the whole lifetime of the client: once it is done the client is closed as by
+// Close, stopping the read, keepalive and reconnect loops and closing all subscription channels.
+func (w *WSClient) Connect(ctx context.Context) error {
+	w.mu.Lock()
+	if w.ctx.Err() != nil {
+		w.mu.Unlock()
+		return ErrWSClosed
+	}
+	if w.started {
+		w.mu.Unlock()
+		return errors.New("websocket already connected")
+	}
+	w.started = true
+	w.mu.Unlock()
+	w.setState(ConnConnecting)
+
+	conn, _, err := w.dialer.DialContext(ctx, w.url, nil)
+	w.mu.Lock()
+	if err == nil && w.ctx.Err() != nil {
+		conn.Close()
+		err = ErrWSClosed
+	}
+	if err != nil {
+		w.started = false
+		closed := w.ctx.Err() != nil
+		w.mu.Unlock()
+		if closed {
+			// Close is waiting for the client to stop
+			w.shutdown()
+		} else {
+			w.setState(ConnDisconnected)
+		}
+		return err
+	}
+	w.conn = conn
+	w.mu.Unlock()
+	w.setState(ConnConnected)
+	stopAfter := context.AfterFunc(ctx, func() {
+		_ = w.Close()
+	})
+	go func() {
+		defer stopAfter()
+		w.run(conn)
+	}()
+	return nil
+}
+
+// Close closes the connection, stops reconnecting and closes all subscription channels
+func (w *WSClient) Close() error {
+	w.cancel()
+	w.mu.Lock()
+	conn, started := w.conn, w.started
+	w.mu.Unlock()
+	if conn != nil {
+		w.writeMu.Lock()
+		_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(wsWriteTimeout))
+		w.writeMu.Unlock()
+		conn.Close()
+	}
+	if !started {
+		w.shutdown()
+		return nil
+	}
+	<-w.stopped
+	return nil
+}
+
+// Connected reports whether the websocket is currently connected
+func (w *WSClient) Connected() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.conn != nil
+}
+
+// State returns the current connection state
+func (w *WSClient) State() ConnState {
+	w.stateMu.Lock()
+	defer w.stateMu.Unlock()
+	return w.state
+}
+
+// StateChanges returns the channel of connection state changes, closed after ConnClosed.
+// A connection drop emits ConnDisconnected, ConnReconnecting and ConnConnected once back.
+// The state machine never waits for the reader: past the last 16 changes the oldest is dropped.
+func (w *WSClient) StateChanges() <-chan ConnState {
+	return w.states
+}
+
+// setState records a state change, ignoring repeated states and changes after ConnClosed
+func (w *WSClient) setState(state ConnState) {
+	w.stateMu.Lock()
+	defer w.stateMu.Unlock()
+	if w.statesClosed || w.state == state {
+		return
+	}
+	w.state = state
+	select {
+	case w.states <- state:
+	default:
+		// the reader may take a change in between, so neither the drop nor the send may block
+		select {
+		case <-w.states:
+		default:
+		}
+		select {
+		case w.states <- state:
+		default:
+		}
+	}
+	if state == ConnClosed {
+		w.statesClosed = true
+		close(w.states)
+	}
+}
+
+// Ping sends server.ping and waits for the reply
+func (w *WSClient) Ping(ctx context.Context) error {
+	_, err := w.call(ctx, "server.ping")
+	return err
+}
+
+// Unsubscribe stops the notifications of channel and closes its subscription channel
+func (w *WSClient) Unsubscribe(ctx context.Context, channel WSChannel) error {
+	w.mu.Lock()
+	sub := w.subs[channel]
+	delete(w.subs, channel)
+	w.mu.Unlock()
+	if sub != nil {
+		sub.close()
+	}
+	_, err := w.call(ctx, string(channel)+".unsubscribe")
+	return err
+}
+
+// Dropped returns the amount of updates of channel dropped by its overflow policy
+func (w *WSClient) Dropped(channel WSChannel) uint64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.dropped[channel]
+}
+
+func (w *WSClient) overflowPolicy(channel WSChannel) WSOverflowPolicy {
+	if p, ok := w.policies[channel]; ok {
+		return p
+	}
+	return w.policy
+}
+
+// overflow records an update of channel dropped from the read loop and applies the policy.
+// resync requests a new snapshot, for channels whose updates are diffs.
+func (w *WSClient) overflow(channel WSChannel, resync bool) {
+	w.mu.Lock()
+	w.dropped[channel]++
+	conn := w.conn
+	w.mu.Unlock()
+	if w.onDrop != nil {
+		w.onDrop(channel)
+	}
+	if conn == nil {
+		return
+	}
+	if w.overflowPolicy(channel) == PolicyDisconnect {
+		// serve sees the read fail and reconnects, resubscribing everything
+		conn.Close()
+		return
+	}
+	if resync {
+		w.resync(channel)
+	}
+}
+
+// resync subscribes to channel again unless a snapshot is already outstanding,
+// the server answers a repeated subscribe with a full snapshot
+func (w *WSClient) resync(channel WSChannel) {
+	w.mu.Lock()
+	conn := w.conn
+	sub := w.subs[channel]
+	if conn == nil || sub == nil || sub.resyncing {
+		w.mu.Unlock()
+		return
+	}
+	sub.resyncing = true
+	w.nextID++
+	req := newWsRequest(sub.method, sub.params...)
+	req.Id = w.nextID
+	w.mu.Unlock()
+	// the reply is ignored
+	_ = w.write(conn, req)
+}
+
+// resynced clears the outstanding snapshot request of channel
+func (w *WSClient) resynced(channel WSChannel) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if sub := w.subs[channel]; sub != nil {
+		sub.resyncing = false
+	}
+}
+
+// dropUnsynced counts an update of channel discarded while waiting for a snapshot
+func (w *WSClient) dropUnsynced(channel WSChannel) {
+	w.mu.Lock()
+	w.dropped[channel]++
+	w.mu.Unlock()
+	if w.onDrop != nil {
+		w.onDrop(channel)
+	}
+}
+
+// run serves the connection and reconnects until the client is closed
+func (w *WSClient) run(conn *websocket.Conn) {
+	defer w.shutdown()
+	for {
+		w.serve(conn)
+		w.failPending(ErrWSNotConnected)
+		if conn = w.redial(); conn == nil {
+			return
+		}
+		w.resubscribe(conn)
+	}
+}
+
+// serve reads from conn until it fails, pinging it in the meantime
+func (w *WSClient) serve(conn *websocket.Conn) {
+	stopPing := make(chan struct{})
+	go w.keepalive(conn, stopPing)
+	defer func() {
+		close(stopPing)
+		conn.Close()
+		w.mu.Lock()
+		if w.conn == conn {
+			w.conn = nil
+		}
+		w.mu.Unlock()
+		if w.ctx.Err() == nil {
+			w.setState(ConnDisconnected)
+		}
+	}()
+	for {
+		// the server answers the keepalive pings, so a silent connection is a dead one
+		_ = conn.SetReadDeadline(time.Now().Add(2 * wsPingInterval))
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if w.onFrame != nil {
+			w.onFrame(FrameIn, data)
+		}
+		var frame wsFrame
+		if err := json.Unmarshal(data, &frame); err != nil {
+			continue
+		}
+		w.dispatch(&frame)
+	}
+}
+
+func (w *WSClient) keepalive(conn *websocket.Conn, stop <-chan struct{}) {
+	ticker := time.NewTicker(wsPingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			w.mu.Lock()
+			w.nextID++
+			req := newPingRequest()
+			req.Id = w.nextID
+			w.mu.Unlock()
+			if err := w.write(conn, req); err != nil {
+				conn.Close()
+				return
+			}
+		}
+	}
+}
+
+// redial reconnects with exponential backoff, returning nil once the client is closed
+func (w *WSClient) redial() *websocket.Conn {
+	delay := wsReconnectMin
+	for {
+		timer := time.NewTimer(delay)
+		select {
+		case <-w.ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+		w.setState(ConnReconnecting)
+		conn, _, err := w.dialer.DialContext(w.ctx, w.url, nil)
+		if err == nil {
+			w.mu.Lock()
+			if w.ctx.Err() != nil {
+				w.mu.Unlock()
+				conn.Close()
+				return nil
+			}
+			w.conn = conn
+			w.mu.Unlock()
+			w.setState(ConnConnected)
+			return conn
+		}
+		if delay *= 2; delay > wsReconnectMax {
+			delay = wsReconnectMax
+		}
+	}
+}
+
+// resubscribe sends the subscribe requests again, the replies are read by the next serve
+func (w *WSClient) resubscribe(conn *websocket.Conn) {
+	w.mu.Lock()
+	var requests []*wsRequest
+	for _, sub := range w.subs {
+		sub.reconnected = true
+		sub.resyncing = false
+		w.nextID++
+		req := newWsRequest(sub.method, sub.params...)
+		req.Id = w.nextID
+		requests = append(requests, req)
+	}
+	w.mu.Unlock()
+	for _, req := range requests {
+		if err := w.write(conn, req); err != nil {
+			return
+		}
+	}
+}
+
+func (w *WSClient) dispatch(frame *wsFrame) {
+	if frame.Method != "" {
+		channel := WSChannel(strings.TrimSuffix(frame.Method, ".update"))
+		w.metrics.ObserveWsEvent(string(channel))
+		w.mu.Lock()
+		sub := w.subs[channel]
+		var reconnected bool
+		if sub != nil {
+			reconnected, sub.reconnected = sub.reconnected, false
+		}
+		w.mu.Unlock()
+		if sub != nil {
+			sub.handle(frame.Params, reconnected)
+		}
+		return
+	}
+	if frame.ID == nil {
+		return
+	}
+	w.mu.Lock()
+	ch := w.pending[*frame.ID]
+	delete(w.pending, *frame.ID)
+	w.mu.Unlock()
+	if ch == nil {
+		return
+	}
+	if frame.Error != nil {
+		ch <- wsReply{err: frame.Error}
+		return
+	}
+	ch <- wsReply{result: frame.Result}
+}
+
+// call sends a request and waits for its reply
+func (w *WSClient) call(ctx context.Context, method string, params ...interface{}) (result json.RawMessage, err error) {
+	ctx, span := w.tracing.start(ctx, method)
+	defer func() { w.tracing.end(ctx, span, err) }()
+	w.mu.Lock()
+	if w.ctx.Err() != nil {
+		w.mu.Unlock()
+		return nil, ErrWSClosed
+	}
+	conn := w.conn
+	if conn == nil {
+		w.mu.Unlock()
+		return nil, ErrWSNotConnected
+	}
+	w.nextID++
+	req := newWsRequest(method, params...)
+	req.Id = w.nextID
+	reply := make(chan wsReply, 1)
+	w.pending[req.Id] = reply
+	w.mu.Unlock()
+
+	forget := func() {
+		w.mu.Lock()
+		delete(w.pending, req.Id)
+		w.mu.Unlock()
+	}
+	if err := w.write(conn, req); err != nil {
+		forget()
+		return nil, err
+	}
+	select {
+	case r := <-reply:
+		return r.result, r.err
+	case <-ctx.Done():
+		forget()
+		return nil, ctx.Err()
+	case <-w.ctx.Done():
+		forget()
+		return nil, ErrWSClosed
+	}
+}
+
+func (w *WSClient) write(conn *websocket.Conn, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	w.writeMu.Lock()
+	defer w.writeMu.Unlock()
+	if w.onFrame != nil {
+		w.onFrame(FrameOut, data)
+	}
+	_ = conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	return conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// subscribe registers the subscription of channel, replacing the previous one, and sends the subscribe request
+func (w *WSClient) subscribe(ctx context.Context, channel WSChannel, params []interface{}, handle func(json.RawMessage, bool), closeFn func()) error {
+	return w.subscribeWith(ctx, channel, string(channel)+".subscribe", params, handle, closeFn)
+}
+
+// subscribeWith is subscribe with another request method than the channel's subscribe
+func (w *WSClient) subscribeWith(ctx context.Context, channel WSChannel, method string, params []interface{}, handle func(json.RawMessage, bool), closeFn func()) error {
+	sub := &wsSubscription{
+		method: method,
+		params: params,
+		handle: handle,
+		close:  closeFn,
+	}
+	w.mu.Lock()
+	if w.ctx.Err() != nil {
+		w.mu.Unlock()
+		closeFn()
+		return ErrWSClosed
+	}
+	old := w.subs[channel]
+	w.subs[channel] = sub
+	w.mu.Unlock()
+	if old != nil {
+		old.close()
+	}
+
+	if _, err := w.call(ctx, sub.method, params...); err != nil {
+		w.mu.Lock()
+		if w.subs[channel] == sub {
+			delete(w.subs, channel)
+		}
+		w.mu.Unlock()
+		closeFn()
+		return err
+	}
+	return nil
+}
+
+func (w *WSClient) failPending(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for id, ch := range w.pending {
+		ch <- wsReply{err: err}
+		delete(w.pending, id)
+	}
+}
+
+// shutdown fails pending calls and closes all subscriptions once the client is closed
+func (w *WSClient) shutdown() {
+	w.failPending(ErrWSClosed)
+	w.mu.Lock()
+	subs := w.subs
+	w.subs = make(map[WSChannel]*wsSubscription)
+	w.mu.Unlock()
+	for _, sub := range subs {
+		sub.close()
+	}
+	w.setState(ConnClosed)
+	w.stopOnce.Do(func() { close(w.stopped) })
+}
+
+// wsStream is the channel side of a subscription. Sends never block a closed stream,
+// a full stream blocks or drops according to its overflow policy.
+type wsStream[T any] struct {
+	out      chan T
+	stop     chan struct{}
+	once     sync.Once
+	mu       sync.Mutex
+	policy   WSOverflowPolicy
+	overflow func()
+}
+
+func newWSStream[T any](size int, policy WSOverflowPolicy, overflow func()) *wsStream[T] {
+	return &wsStream[T]{out: make(chan T, size), stop: make(chan struct{}), policy: policy, overflow: overflow}
+}
+
+func (s *wsStream[T]) send(v T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sendLocked(v)
+}
+
+// replace discards the buffered updates, superseded by v, before sending v
+func (s *wsStream[T]) replace(v T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for {
+		select {
+		case <-s.out:
+			continue
+		default:
+		}
+		break
+	}
+	s.sendLocked(v)
+}
+
+func (s *wsStream[T]) sendLocked(v T) {
+	select {
+	case <-s.stop:
+		return
+	default:
+	}
+	if s.policy == PolicyBlock {
+		select {
+		case s.out <- v:
+		case <-s.stop:
+		}
+		return
+	}
+	select {
+	case s.out <- v:
+		return
+	default:
+	}
+	if s.policy == PolicyDropOldest {
+		// sends are serialized by mu, so the slot freed here stays free unless the buffer is unbuffered
+		select {
+		case <-s.out:
+		default:
+		}
+		select {
+		case s.out <- v:
+		default:
+		}
+	}
+	s.overflow()
+}
+
+func (s *wsStream[T]) close() {
+	s.once.Do(func() {
+		close(s.stop)
+		s.mu.Lock()
+		close(s.out)
+		s.mu.Unlock()
+	})
+}
+
+// DealsUpdate is a notification of the deals channel
+type DealsUpdate struct {
+	Market string
+	Deals  []Trade
+	// Reconnected is set on the first update after the connection was re-established,
+	// trades done while disconnected are missing before it
+	Reconnected bool
+}
+
+// SubscribeKline subscribes to the candles of market, replacing any previous kline subscription.
+// The exchange pushes the current candle on every change; candles get Interval and Closed set
+// like GetKlines does, and when a new period starts the last update of the previous candle
+// is sent again with Closed set, so its final values arrive exactly once as closed.
+func (w *WSClient) SubscribeKline(ctx context.Context, market string, interval KlineInterval) (<-chan Kline, error) {
+	seconds := int64(interval.Duration() / time.Second)
+	if seconds <= 0 {
+		return nil, fmt.Errorf("%w: unknown kline interval %q", ErrInvalidRequest, interval)
+	}
+	stream := newWSStream[Kline](w.buffer, w.overflowPolicy(ChannelKline), func() {
+		w.overflow(ChannelKline, false)
+	})
+	// current is the latest open candle, only used from the read loop
+	var current *Kline
+	handle := func(raw json.RawMessage, reconnected bool) {
+		var klines []Kline
+		if err := json.Unmarshal(raw, &klines); err != nil {
+			return
+		}
+		now := time.Now()
+		for _, k := range klines {
+			k.setInterval(interval, now)
+			if current != nil && k.Time.After(current.Time) {
+				closed := *current
+				closed.Closed = true
+				stream.send(closed)
+			}
+			current = nil
+			if !k.Closed {
+				open := k
+				current = &open
+			}
+			stream.send(k)
+		}
+	}
+	if err := w.subscribe(ctx, ChannelKline, []interface{}{market, seconds}, handle, stream.close); err != nil {
+		return nil, err
+	}
+	return stream.out, nil
+}
+
+// SubscribeDeals subscribes to the public trades of markets, replacing any previous deals subscription.
+// The server sends the latest trades of each market right after subscribing.
+func (w *WSClient) SubscribeDeals(ctx context.Context, markets ...string) (<-chan DealsUpdate, error) {
+	stream := newWSStream[DealsUpdate](w.buffer, w.overflowPolicy(ChannelDeals), func() {
+		w.overflow(ChannelDeals, false)
+	})
+	params := make([]interface{}, len(markets))
+	for i, m := range markets {
+		params[i] = m
+	}
+	handle := func(raw json.RawMessage, reconnected bool) {
+		update, err := decodeDealsUpdate(raw)
+		if err != nil {
+			return
+		}
+		if w.strictEnums && checkEnums(reflect.ValueOf(update)) != nil {
+			return
+		}
+		update.Reconnected = reconnected
+		stream.send(update)
+	}
+	if err := w.subscribe(ctx, ChannelDeals, params, handle, stream.close); err != nil {
+		return nil, err
+	}
+	return stream.out, nil
+}
+
+// Summary is a notification of the state channel, the rolling statistics of a market
+type Summary struct {
+	Market string
+	// Period is the window of the statistics, 24 hours
+	Period time.Duration
+	// Ticker has no bid and ask, At is when the update was received
+	Ticker Ticker
+	// Reconnected is set on the first update after the connection was re-established
+	Reconnected bool
+}
+
+// SubscribeMarketSummary subscribes to the rolling 24h statistics of markets, replacing any
+// previous state subscription. The server pushes a Summary of a market whenever it changes,
+// a live alternative to polling GetTickers.
+func (w *WSClient) SubscribeMarketSummary(ctx context.Context, markets ...string) (<-chan Summary, error) {
+	stream := newWSStream[Summary](w.buffer, w.overflowPolicy(ChannelState), func() {
+		w.overflow(ChannelState, false)
+	})
+	params := make([]interface{}, len(markets))
+	for i, m := range markets {
+		params[i] = m
+	}
+	handle := func(raw json.RawMessage, reconnected bool) {
+		var update Summary
+		var stats struct {
+			Period int64           `json:"period"`
+			Last   decimal.Decimal `json:"last"`
+			Open   decimal.Decimal `json:"open"`
+			High   decimal.Decimal `json:"high"`
+			Low    decimal.Decimal `json:"low"`
+			Volume decimal.Decimal `json:"volume"`
+			Deal   decimal.Decimal `json:"deal"`
+		}
+		if err := decodeWSParams(raw, &update.Market, &stats); err != nil {
+			return
+		}
+		update.Period = time.Duration(stats.Period) * time.Second
+		update.Ticker = Ticker{
+			Open:   stats.Open,
+			Low:    stats.Low,
+			High:   stats.High,
+			Last:   stats.Last,
+			Volume: stats.Volume,
+			Deal:   stats.Deal,
+			At:     time.Now(),
+		}
+		update.Reconnected = reconnected
+		stream.send(update)
+	}
+	if err := w.subscribe(ctx, ChannelState, params, handle, stream.close); err != nil {
+		return nil, err
+	}
+	return stream.out, nil
+}
+
+// SubscribeDepth subscribes to the order book of market, replacing any previous depth subscription.
+// limit is the amount of levels per side, interval the price merge interval ("0" for none).
+// The first update, and the first after every reconnect, is a full snapshot. A diff whose
+// Sequence doesn't follow the previous one is discarded, reported to the WithWSGapHook
+// hook and followed by a full snapshot.
+func (w *WSClient) SubscribeDepth(ctx context.Context, market string, limit int, interval string) (<-chan DepthUpdate, error) {
+	if interval == "" {
+		interval = "0"
+	}
+	// outOfSync is set once an update was dropped or skipped, later diffs are useless until the
+	// next snapshot. sequence is the Sequence of the last update. Both are only used from the read loop.
+	var outOfSync bool
+	var sequence int64
+	stream := newWSStream[DepthUpdate](w.buffer, w.overflowPolicy(ChannelDepth), func() {
+		outOfSync = true
+		w.overflow(ChannelDepth, true)
+	})
+	handle := func(raw json.RawMessage, reconnected bool) {
+		update, err := decodeDepthUpdate(raw)
+		if err != nil {
+			return
+		}
+		update.At = time.Now()
+		update.Reconnected = reconnected
+		switch {
+		case update.Full:
+			outOfSync = false
+			sequence = update.Sequence
+			w.resynced(ChannelDepth)
+			stream.replace(update)
+		case outOfSync:
+			w.dropUnsynced(ChannelDepth)
+		case sequenceGap(sequence, update.Sequence):
+			outOfSync = true
+			if w.onGap != nil {
+				w.onGap(DepthGap{Market: update.Market, Expected: sequence + 1, Got: update.Sequence})
+			}
+			w.resync(ChannelDepth)
+		default:
+			sequence = update.Sequence
+			stream.send(update)
+		}
+	}
+	if err := w.subscribe(ctx, ChannelDepth, []interface{}{market, limit, interval}, handle, stream.close); err != nil {
+		return nil, err
+	}
+	return stream.out, nil
+}
+
+// SubscribeDepthMarkets subscribes to the order books of markets on this one connection with
+// depth.subscribe_multi, replacing any previous depth subscription. The updates of all markets
+// arrive on the returned channel, told apart by Market. Every market has its own snapshots and
+// sequence as with SubscribeDepth, but a gap in the diffs of one market requests new snapshots
+// of all of them, the server only resending the whole subscription.
+func (w *WSClient) SubscribeDepthMarkets(ctx context.Context, limit int, interval string, markets ...string) (<-chan DepthUpdate, error) {
+	if len(markets) == 0 {
+		return nil, fmt.Errorf("%w: no markets to subscribe", ErrInvalidRequest)
+	}
+	if interval == "" {
+		interval = "0"
+	}
+	// sequences holds the Sequence of the last update of every market, outOfSync the markets
+	// waiting for a snapshot. Both are only used from the read loop.
+	sequences := make(map[Market]int64, len(markets))
+	outOfSync := make(map[Market]bool, len(markets))
+	params := make([]interface{}, 0, len(markets))
+	for _, market := range markets {
+		if _, ok := sequences[Market(market)]; ok {
+			continue
+		}
+		sequences[Market(market)] = 0
+		params = append(params, []interface{}{market, limit, interval})
+	}
+	stream := newWSStream[DepthUpdate](w.buffer, w.overflowPolicy(ChannelDepth), func() {
+		// the dropped update could be of any market
+		for market := range sequences {
+			outOfSync[market] = true
+		}
+		w.overflow(ChannelDepth, true)
+	})
+	handle := func(raw json.RawMessage, reconnected bool) {
+		update, err := decodeDepthUpdate(raw)
+		if err != nil {
+			return
+		}
+		last, ok := sequences[update.Market]
+		if !ok {
+			return
+		}
+		update.At = time.Now()
+		update.Reconnected = reconnected
+		switch {
+		case update.Full:
+			delete(outOfSync, update.Market)
+			sequences[update.Market] = update.Sequence
+			w.resynced(ChannelDepth)
+			// the buffered updates of the other markets are still current, so nothing is replaced
+			stream.send(update)
+		case outOfSync[update.Market]:
+			w.dropUnsynced(ChannelDepth)
+		case sequenceGap(last, update.Sequence):
+			outOfSync[update.Market] = true
+			if w.onGap != nil {
+				w.onGap(DepthGap{Market: update.Market, Expected: last + 1, Got: update.Sequence})
+			}
+			w.resync(ChannelDepth)
+		default:
+			sequences[update.Market] = update.Sequence
+			stream.send(update)
+		}
+	}
+	if err := w.subscribeWith(ctx, ChannelDepth, "depth.subscribe_multi", params, handle, stream.close); err != nil {
+		return nil, err
+	}
+	return stream.out, nil
+}
+
+// decodeDealsUpdate decodes the params of a deals notification: market and trades
+func decodeDealsUpdate(raw json.RawMessage) (DealsUpdate, error) {
+	var update DealsUpdate
+	err := decodeWSParams(raw, &update.Market, &update.Deals)
+	return update, err
+}
+
+// decodeDepthUpdate decodes the params of a depth notification: the full flag, the levels
+// with the update id and the market
+func decodeDepthUpdate(raw json.RawMessage) (DepthUpdate, error) {
+	var update DepthUpdate
+	var levels struct {
+		Asks     []PriceLevel `json:"asks"`
+		Bids     []PriceLevel `json:"bids"`
+		UpdateID int64        `json:"update_id"`
+	}
+	if err := decodeWSParams(raw, &update.Full, &levels, &update.Market); err != nil {
+		return DepthUpdate{}, err
+	}
+	update.Asks, update.Bids = levels.Asks, levels.Bids
+	update.Sequence = levels.UpdateID
+	return update, nil
+}
+
+// decodeWSParams decodes the positional notification params into targets
+func decodeWSParams(raw json.RawMessage, targets ...interface{}) error {
+	var params []json.RawMessage
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return err
+	}
+	if len(params) < len(targets) {
+		return fmt.Errorf("expected %d params, got %d", len(targets), len(params))
+	}
+	for i, t := range targets {
+		if err := json.Unmarshal(params[i], t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
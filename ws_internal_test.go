@@ -0,0 +1,35 @@
+package gop2b
+
+import (
+	"testing"
+	"time"
+)
+
+// TestSetStateConcurrentReader races setState against a reader draining the full buffer,
+// which must never leave setState blocked on the drop of the oldest change
+func TestSetStateConcurrentReader(t *testing.T) {
+	w := &WSClient{states: make(chan ConnState, 1)}
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		for {
+			select {
+			case <-w.states:
+			case <-stop:
+				return
+			}
+		}
+	}()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100000; i++ {
+			w.setState(ConnState(i%2 + 1))
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("setState blocked")
+	}
+}
@@ -0,0 +1,391 @@
+package gop2b_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/goleak"
+
+	"github.com/sutapurachina/gop2b"
+	"github.com/sutapurachina/gop2b/gop2btest"
+)
+
+// newTestWS starts a websocket server and connects a client to it, both closed with the test
+func newTestWS(t *testing.T, opts ...gop2b.WSOption) (*gop2b.WSClient, *gop2btest.WsServer) {
+	t.Helper()
+	server := gop2btest.NewWsServer()
+	t.Cleanup(server.Close)
+	ws := gop2b.NewWSClient(append([]gop2b.WSOption{gop2b.WithWSURL(server.URL)}, opts...)...)
+	t.Cleanup(func() { _ = ws.Close() })
+	if err := ws.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return ws, server
+}
+
+// dealsFrame is a deals notification of ETH_BTC with a single trade of id
+func dealsFrame(id int64) string {
+	return gop2btest.Notification("deals", "ETH_BTC", []map[string]interface{}{
+		{"id": id, "time": 1700000000.5, "price": "0.055", "amount": "1", "type": "buy"},
+	})
+}
+
+// depthFrame is a depth notification of ETH_BTC with one ask at price
+func depthFrame(full bool, updateID int64, price string) string {
+	return gop2btest.Notification("depth", full, map[string]interface{}{
+		"asks":      [][]string{{price, "1"}},
+		"bids":      [][]string{},
+		"update_id": updateID,
+	}, "ETH_BTC")
+}
+
+// eventually polls cond until it holds, failing the test after a second
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	eventuallyWithin(t, time.Second, what, cond)
+}
+
+// eventuallyWithin polls cond until it holds, failing the test after d
+func eventuallyWithin(t *testing.T, d time.Duration, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(d)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// receive reads the next value of ch, failing the test after a second
+func receive[T any](t *testing.T, ch <-chan T) T {
+	t.Helper()
+	select {
+	case v, ok := <-ch:
+		if !ok {
+			t.Fatal("channel closed")
+		}
+		return v
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for an update")
+	}
+	panic("unreachable")
+}
+
+func TestWSClientConnectContextCancel(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	server := gop2btest.NewWsServer()
+	defer server.Close()
+	ws := gop2b.NewWSClient(gop2b.WithWSURL(server.URL))
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := ws.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	deals, err := ws.SubscribeDeals(context.Background(), "ETH_BTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	depth, err := ws.SubscribeDepth(context.Background(), "ETH_BTC", 10, "0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	states := ws.StateChanges()
+
+	cancel()
+	for range deals {
+	}
+	for range depth {
+	}
+	for range states {
+	}
+	if state := ws.State(); state != gop2b.ConnClosed {
+		t.Errorf("state %s, want closed", state)
+	}
+	if err := ws.Ping(context.Background()); err != gop2b.ErrWSClosed {
+		t.Errorf("ping after cancel: %v, want ErrWSClosed", err)
+	}
+}
+
+func TestWSStateChanges(t *testing.T) {
+	server := gop2btest.NewWsServer()
+	defer server.Close()
+	ws := gop2b.NewWSClient(gop2b.WithWSURL(server.URL))
+	states := ws.StateChanges()
+	if err := ws.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// the first reconnect waits a second
+	next := func() gop2b.ConnState {
+		t.Helper()
+		select {
+		case state := <-states:
+			return state
+		case <-time.After(3 * time.Second):
+			t.Fatal("timed out waiting for a state change")
+		}
+		panic("unreachable")
+	}
+	expect := func(want ...gop2b.ConnState) {
+		t.Helper()
+		for _, w := range want {
+			if got := next(); got != w {
+				t.Fatalf("state %s, want %s", got, w)
+			}
+		}
+	}
+
+	expect(gop2b.ConnConnecting, gop2b.ConnConnected)
+	server.Disconnect()
+	expect(gop2b.ConnDisconnected, gop2b.ConnReconnecting, gop2b.ConnConnected)
+	if n := server.Connections(); n != 2 {
+		t.Errorf("%d connections, want 2", n)
+	}
+	if err := ws.Close(); err != nil {
+		t.Fatal(err)
+	}
+	expect(gop2b.ConnClosed)
+	if state, ok := <-states; ok {
+		t.Errorf("state %s after closed, want the channel closed", state)
+	}
+}
+
+func TestWSOverflowDropNewest(t *testing.T) {
+	ws, server := newTestWS(t, gop2b.WithWSChannelBuffer(1), gop2b.WithWSOverflowPolicy(gop2b.PolicyDropNewest))
+	deals, err := ws.SubscribeDeals(context.Background(), "ETH_BTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id := int64(1); id <= 3; id++ {
+		server.Send(dealsFrame(id))
+	}
+	eventually(t, "two drops", func() bool { return ws.Dropped(gop2b.ChannelDeals) == 2 })
+	if update := receive(t, deals); update.Deals[0].ID != 1 {
+		t.Errorf("kept trade %d, want the oldest", update.Deals[0].ID)
+	}
+}
+
+func TestWSOverflowDropOldest(t *testing.T) {
+	dropped := make(chan gop2b.WSChannel, 10)
+	ws, server := newTestWS(t,
+		gop2b.WithWSChannelBuffer(1),
+		gop2b.WithWSOverflowPolicy(gop2b.PolicyDropOldest),
+		gop2b.WithWSDropHook(func(channel gop2b.WSChannel) { dropped <- channel }),
+	)
+	deals, err := ws.SubscribeDeals(context.Background(), "ETH_BTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id := int64(1); id <= 3; id++ {
+		server.Send(dealsFrame(id))
+	}
+	for i := 0; i < 2; i++ {
+		if channel := receive(t, dropped); channel != gop2b.ChannelDeals {
+			t.Errorf("drop hook called for %s", channel)
+		}
+	}
+	if update := receive(t, deals); update.Deals[0].ID != 3 {
+		t.Errorf("kept trade %d, want the newest", update.Deals[0].ID)
+	}
+}
+
+func TestWSOverflowBlock(t *testing.T) {
+	ws, server := newTestWS(t, gop2b.WithWSChannelBuffer(1))
+	deals, err := ws.SubscribeDeals(context.Background(), "ETH_BTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id := int64(1); id <= 3; id++ {
+		server.Send(dealsFrame(id))
+	}
+	// the read loop waits for the consumer, so the ping reply isn't read
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := ws.Ping(ctx); err != context.DeadlineExceeded {
+		t.Errorf("ping while blocked: %v, want the deadline", err)
+	}
+	for id := int64(1); id <= 3; id++ {
+		if update := receive(t, deals); update.Deals[0].ID != id {
+			t.Errorf("trade %d, want %d", update.Deals[0].ID, id)
+		}
+	}
+	if n := ws.Dropped(gop2b.ChannelDeals); n != 0 {
+		t.Errorf("%d dropped", n)
+	}
+}
+
+func TestWSOverflowDisconnect(t *testing.T) {
+	ws, server := newTestWS(t, gop2b.WithWSChannelBuffer(1), gop2b.WithWSOverflowPolicy(gop2b.PolicyDisconnect))
+	deals, err := ws.SubscribeDeals(context.Background(), "ETH_BTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.Send(dealsFrame(1))
+	server.Send(dealsFrame(2))
+	eventually(t, "a drop", func() bool { return ws.Dropped(gop2b.ChannelDeals) == 1 })
+	if update := receive(t, deals); update.Deals[0].ID != 1 || update.Reconnected {
+		t.Errorf("first update %+v", update)
+	}
+	// the client redials after a second and subscribes again
+	eventuallyWithin(t, 3*time.Second, "a resubscribe", func() bool { return len(server.Requests("deals.subscribe")) == 2 })
+	server.Send(dealsFrame(3))
+	if update := receive(t, deals); update.Deals[0].ID != 3 || !update.Reconnected {
+		t.Errorf("update after reconnect %+v, want trade 3 marked reconnected", update)
+	}
+	if n := server.Connections(); n != 2 {
+		t.Errorf("%d connections, want 2", n)
+	}
+}
+
+func TestWSOverflowDepthResnapshot(t *testing.T) {
+	ws, server := newTestWS(t, gop2b.WithWSChannelBuffer(1), gop2b.WithWSOverflowPolicy(gop2b.PolicyDropNewest))
+	server.OnSubscribe("depth", depthFrame(true, 1, "0.055"))
+	depth, err := ws.SubscribeDepth(context.Background(), "ETH_BTC", 10, "0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "the snapshot", func() bool { return len(depth) == 1 })
+	server.OnSubscribe("depth", depthFrame(true, 5, "0.057"))
+	server.Send(depthFrame(false, 2, "0.056"))
+	eventually(t, "a resnapshot request", func() bool { return len(server.Requests("depth.subscribe")) == 2 })
+	// the stale snapshot may still be buffered, the dropped diff must never arrive
+	for update := receive(t, depth); update.Sequence != 5; update = receive(t, depth) {
+		if !update.Full {
+			t.Fatalf("diff %+v after the drop, want the new snapshot", update)
+		}
+	}
+	if n := ws.Dropped(gop2b.ChannelDepth); n != 1 {
+		t.Errorf("%d dropped, want 1", n)
+	}
+}
+
+func TestWSDepthSequenceGap(t *testing.T) {
+	gaps := make(chan gop2b.DepthGap, 1)
+	ws, server := newTestWS(t, gop2b.WithWSGapHook(func(gap gop2b.DepthGap) { gaps <- gap }))
+	server.OnSubscribe("depth", depthFrame(true, 10, "0.055"))
+	depth, err := ws.SubscribeDepth(context.Background(), "ETH_BTC", 10, "0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if update := receive(t, depth); !update.Full || update.Sequence != 10 {
+		t.Fatalf("first update %+v, want the snapshot", update)
+	}
+	server.Send(depthFrame(false, 11, "0.056"))
+	if update := receive(t, depth); update.Full || update.Sequence != 11 {
+		t.Fatalf("update %+v, want diff 11", update)
+	}
+
+	server.OnSubscribe("depth", depthFrame(true, 20, "0.057"))
+	server.Send(depthFrame(false, 13, "0.058"))
+	gap := receive(t, gaps)
+	if want := (gop2b.DepthGap{Market: "ETH_BTC", Expected: 12, Got: 13}); gap != want {
+		t.Errorf("gap %+v, want %+v", gap, want)
+	}
+	// diffs are discarded until the snapshot requested after the gap
+	if update := receive(t, depth); !update.Full || update.Sequence != 20 {
+		t.Errorf("update after the gap %+v, want the new snapshot", update)
+	}
+	if n := len(server.Requests("depth.subscribe")); n != 2 {
+		t.Errorf("%d depth subscribes, want a resnapshot", n)
+	}
+}
+
+func TestWSDepthMarketsSequenceGap(t *testing.T) {
+	gaps := make(chan gop2b.DepthGap, 1)
+	ws, server := newTestWS(t, gop2b.WithWSGapHook(func(gap gop2b.DepthGap) { gaps <- gap }))
+	ltcFrame := func(full bool, updateID int64) string {
+		return gop2btest.Notification("depth", full, map[string]interface{}{
+			"asks": [][]string{{"0.003", "1"}}, "bids": [][]string{}, "update_id": updateID,
+		}, "LTC_BTC")
+	}
+	server.OnSubscribe("depth", depthFrame(true, 10, "0.055"), ltcFrame(true, 100))
+	depth, err := ws.SubscribeDepthMarkets(context.Background(), 10, "", "ETH_BTC", "LTC_BTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []gop2b.Market{"ETH_BTC", "LTC_BTC"} {
+		if update := receive(t, depth); !update.Full || update.Market != want {
+			t.Fatalf("update %+v, want the snapshot of %s", update, want)
+		}
+	}
+	// every market follows its own sequence, a market not subscribed is ignored
+	server.Send(ltcFrame(false, 101))
+	server.Send(gop2btest.Notification("depth", false, map[string]interface{}{"asks": [][]string{}, "bids": [][]string{}, "update_id": 1}, "BTC_USDT"))
+	server.Send(depthFrame(false, 11, "0.056"))
+	for _, want := range []gop2b.Market{"LTC_BTC", "ETH_BTC"} {
+		if update := receive(t, depth); update.Full || update.Market != want {
+			t.Fatalf("update %+v, want a diff of %s", update, want)
+		}
+	}
+
+	server.OnSubscribe("depth", depthFrame(true, 20, "0.057"), ltcFrame(true, 200))
+	server.Send(depthFrame(false, 13, "0.058"))
+	if gap, want := receive(t, gaps), (gop2b.DepthGap{Market: "ETH_BTC", Expected: 12, Got: 13}); gap != want {
+		t.Errorf("gap %+v, want %+v", gap, want)
+	}
+	for _, want := range []int64{20, 200} {
+		if update := receive(t, depth); !update.Full || update.Sequence != want {
+			t.Errorf("update after the gap %+v, want snapshot %d", update, want)
+		}
+	}
+	if n := len(server.Requests("depth.subscribe_multi")); n != 2 {
+		t.Errorf("%d depth subscribes, want a resnapshot", n)
+	}
+	if _, err := ws.SubscribeDepthMarkets(context.Background(), 10, ""); !errors.Is(err, gop2b.ErrInvalidRequest) {
+		t.Errorf("no markets: %v, want ErrInvalidRequest", err)
+	}
+}
+
+// stateFrame is a state notification of market as sent by the exchange
+func stateFrame(market, last string) string {
+	return gop2btest.Notification("state", market, map[string]interface{}{
+		"period": 86400,
+		"last":   last,
+		"open":   "0.0545",
+		"high":   "0.0555",
+		"low":    "0.054",
+		"volume": "1250.5",
+		"deal":   "68.7775",
+	})
+}
+
+func TestSubscribeMarketSummary(t *testing.T) {
+	ws, server := newTestWS(t)
+	server.OnSubscribe("state", stateFrame("ETH_BTC", "0.055"))
+	before := time.Now()
+	summaries, err := ws.SubscribeMarketSummary(context.Background(), "ETH_BTC", "BTC_USDT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	requests := server.Requests("state.subscribe")
+	if len(requests) != 1 || len(requests[0].Params) != 2 ||
+		string(requests[0].Params[0]) != `"ETH_BTC"` || string(requests[0].Params[1]) != `"BTC_USDT"` {
+		t.Fatalf("subscribe requests %+v, want one for both markets", requests)
+	}
+
+	summary := receive(t, summaries)
+	if summary.Market != "ETH_BTC" || summary.Period != 24*time.Hour || summary.Reconnected {
+		t.Errorf("summary %+v", summary)
+	}
+	ticker := summary.Ticker
+	checkDecimal(t, "last", ticker.Last, "0.055")
+	checkDecimal(t, "open", ticker.Open, "0.0545")
+	checkDecimal(t, "high", ticker.High, "0.0555")
+	checkDecimal(t, "low", ticker.Low, "0.054")
+	checkDecimal(t, "volume", ticker.Volume, "1250.5")
+	checkDecimal(t, "deal", ticker.Deal, "68.7775")
+	if !ticker.Bid.IsZero() || !ticker.Ask.IsZero() {
+		t.Errorf("bid %s ask %s, the state channel has neither", ticker.Bid, ticker.Ask)
+	}
+	if ticker.At.Before(before) || ticker.At.After(time.Now()) {
+		t.Errorf("received at %s", ticker.At)
+	}
+
+	server.Send(stateFrame("BTC_USDT", "37000"))
+	if summary := receive(t, summaries); summary.Market != "BTC_USDT" {
+		t.Errorf("summary of %s, want BTC_USDT", summary.Market)
+	} else {
+		checkDecimal(t, "BTC_USDT last", summary.Ticker.Last, "37000")
+	}
+}